@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/agents"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+func runAgents(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: plctl agents rotate <name> [-o file] [--yes] | audit [--max-age 90d] [--idle 30d] [--name-pattern re] [--json] | activity [--since 7d] [--json]")
+	}
+	switch args[0] {
+	case "rotate":
+		return runAgentsRotate(ctx, args[1:])
+	case "audit":
+		return runAgentsAudit(ctx, args[1:])
+	case "activity":
+		return runAgentsActivity(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown agents subcommand %q", args[0])
+}
+
+func runAgentsRotate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("agents rotate", flag.ContinueOnError)
+	out := fs.String("o", "", "write the new key to `file` (default <new-name>.key)")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	name, rest := "", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, rest = args[0], args[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		return errors.New("usage: plctl agents rotate <name> [-o file] [--yes]")
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	next := agents.NextName(name)
+	if !confirmTarget(apiURL) || (!*yes && !confirm(fmt.Sprintf("Rotate %s to %s and delete %s?", name, next, name))) {
+		return nil
+	}
+
+	r := &agents.Rotator{
+		Ops: client,
+		Verify: func(ctx context.Context, key string) (string, error) {
+			granted, err := api.NewClient(apiURL, key, "").Handshake(ctx)
+			if err != nil {
+				return "", err
+			}
+			return granted.Agent, nil
+		},
+		Store: func(name, key string) error {
+			if *out == "" {
+				*out = name + ".key"
+			}
+			f, err := os.OpenFile(*out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(f, key); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		},
+	}
+	rot, err := r.Rotate(ctx, name)
+	if rot != nil {
+		fmt.Fprintln(os.Stderr, ui.SuccessStyle.Render(fmt.Sprintf("Provisioned %s (%s), verified over /ops/ws, key written to %s", rot.New.Name, rot.New.TrustLevel, *out)))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, ui.SuccessStyle.Render("Deleted "+name))
+	return nil
+}
+
+func runAgentsAudit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("agents audit", flag.ContinueOnError)
+	maxAge := fs.String("max-age", "90d", "flag agents created longer ago than this")
+	idle := fs.String("idle", "30d", "flag write-trust agents with no events in this window")
+	pattern := fs.String("name-pattern", agents.NamePattern.String(), "naming convention `regexp`")
+	asJSON := fs.Bool("json", false, "print entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a := &agents.Auditor{}
+	var err error
+	if a.MaxAge, err = api.ParseDuration(*maxAge); err != nil {
+		return err
+	}
+	if a.Idle, err = api.ParseDuration(*idle); err != nil {
+		return err
+	}
+	if a.Names, err = regexp.Compile(*pattern); err != nil {
+		return fmt.Errorf("--name-pattern: %w", err)
+	}
+	r, err := resolveReader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	a.Ops = r
+	entries, err := a.Audit(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	flagged := 0
+	for _, e := range entries {
+		status := ui.SuccessStyle.Render("ok")
+		if len(e.Findings) > 0 {
+			flagged++
+			parts := make([]string, len(e.Findings))
+			for i, f := range e.Findings {
+				parts[i] = f.Kind + ": " + f.Detail
+			}
+			status = ui.ErrorStyle.Render(strings.Join(parts, "; "))
+		}
+		fmt.Printf("%-24s %-6s %5s  %s\n", e.Name, e.TrustLevel, fmt.Sprintf("%dd", e.AgeDays), status)
+	}
+	fmt.Fprintln(os.Stderr, ui.DimStyle.Render(fmt.Sprintf("%d of %d agent(s) flagged", flagged, len(entries))))
+	return nil
+}
+
+func runAgentsActivity(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("agents activity", flag.ContinueOnError)
+	since := fs.String("since", "7d", "timestamp or lookback (7d, 24h)")
+	asJSON := fs.Bool("json", false, "print activity as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sinceTS, err := api.ParseSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+	r, err := resolveReader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	activity, _, err := agents.CollectActivity(ctx, r, sinceTS, "")
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type row struct {
+			agents.Activity
+			Status string `json:"status"`
+		}
+		out := make([]row, len(activity))
+		for i, a := range activity {
+			out[i] = row{a, a.Status()}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Println(ui.DimStyle.Render("Agent activity since " + sinceTS))
+	fmt.Print(renderActivity(activity))
+	return nil
+}
+
+// renderActivity lays out per-actor counters, one row per actor.
+func renderActivity(activity []agents.Activity) string {
+	columns := []ui.Column{
+		{Header: "Actor", Width: 28},
+		{Header: "Trust", Width: 5},
+		{Header: "Conn", Width: 5},
+		{Header: "Disc", Width: 5},
+		{Header: "Grant", Width: 5},
+		{Header: "Deny", Width: 5},
+		{Header: "Unauth", Width: 6},
+		{Header: "Limited", Width: 7},
+		{Header: "Revokes", Width: 7},
+		{Header: "AuthFail", Width: 8},
+		{Header: "Last Seen", Width: 20},
+		{Header: "Status", Width: 9},
+	}
+	rows := make([][]string, len(activity))
+	for i, a := range activity {
+		trust, last := a.TrustLevel, a.LastSeen
+		if !a.Active && a.Name != "" {
+			trust = "gone"
+		}
+		if last == "" {
+			last = "-"
+		}
+		rows[i] = []string{
+			a.Actor, trust,
+			strconv.Itoa(a.Connects), strconv.Itoa(a.Disconnects),
+			strconv.Itoa(a.Granted), strconv.Itoa(a.Denied),
+			strconv.Itoa(a.Unauthorized), strconv.Itoa(a.RateLimited),
+			strconv.Itoa(a.Revocations), strconv.Itoa(a.AuthFailures),
+			last, a.Status(),
+		}
+	}
+	return ui.RenderTable(columns, rows)
+}
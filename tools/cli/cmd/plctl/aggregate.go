@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/aggregate"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/query"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// runEventsAggregate pages raw events and groups them locally, answering
+// what the server's per-type stats cannot: counts by any key, distinct
+// users and IPs, top-N and time histograms.
+func runEventsAggregate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events aggregate", flag.ContinueOnError)
+	by := fs.String("by", "", "comma-separated group keys: fields, functions or target (type,ip,hour(created_at))")
+	interval := fs.String("interval", "", "add a histogram with buckets this wide (15m, 1h, 1d)")
+	since := fs.String("since", "24h", "timestamp or lookback (7d, 24h)")
+	top := fs.Int("top", 20, "groups to list; the rest are folded into one row (0 for all)")
+	where := fs.String("where", "", "only count events matching a query expression")
+	scan := fs.Int("scan", 50000, "stop after reading this many events per target (0 for no cap)")
+	asCSV := fs.Bool("csv", false, "print groups as CSV")
+	asJSON := fs.Bool("json", false, "print the aggregation as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || (*asCSV && *asJSON) {
+		return errors.New("usage: plctl events aggregate [--by type,ip] [--interval 1h] [--since 24h] [--top 20] [--where '<expr>'] [--scan 50000] [--csv | --json]")
+	}
+
+	now := time.Now()
+	sinceStr, err := api.ParseSince(*since, now)
+	if err != nil {
+		return err
+	}
+	var width time.Duration
+	if *interval != "" {
+		if width, err = api.ParseDuration(*interval); err != nil || width <= 0 {
+			return fmt.Errorf("invalid interval %q: want a duration (15m, 1h, 1d)", *interval)
+		}
+		start, _ := api.ParseTimestamp(sinceStr)
+		if n := now.Sub(start) / width; n > aggregate.MaxBuckets {
+			return fmt.Errorf("--interval %s over --since %s makes %d buckets; the limit is %d", *interval, *since, n, aggregate.MaxBuckets)
+		}
+	}
+	agg, err := aggregate.New(aggregate.SplitKeys(*by), width)
+	var ke *aggregate.KeyError
+	var qe *query.Error
+	if errors.As(err, &ke) && errors.As(err, &qe) {
+		return errors.New("--by " + ke.Key + ":\n" + qe.Caret(ke.Key))
+	}
+	if err != nil {
+		return err
+	}
+	var q *query.Query
+	if *where != "" {
+		q, err = query.Parse(*where)
+		if errors.As(err, &qe) {
+			return errors.New("--where:\n" + qe.Caret(*where))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	p := api.EventsParams{}
+	if q != nil {
+		p = q.Params()
+	}
+	p.Since, p.Limit = sinceStr, *scan
+	results, err := queryTargets(ctx, func(ctx context.Context, r api.Reader) ([]api.Event, error) {
+		return api.AllEvents(ctx, r, p)
+	})
+	if err != nil {
+		return err
+	}
+	failed, partial := addResults(agg, results, q, *scan)
+	for _, t := range partial {
+		fmt.Fprintf(os.Stderr, "%s: stopped after %d events; counts are partial (raise --scan)\n", t, *scan)
+	}
+	res := agg.Result(*top)
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	case *asCSV:
+		if err := writeAggregateCSV(os.Stdout, res); err != nil {
+			return err
+		}
+	case res.Total.Count > 0:
+		fmt.Print(renderAggregate(res))
+	}
+	return reportFailures(failed, len(results))
+}
+
+// addResults feeds each target's events through q (when set) into agg. It
+// returns the targets that failed and those whose scan cap was reached.
+func addResults(agg *aggregate.Aggregator, results []fleet.Result[[]api.Event], q *query.Query, scan int) (failed []fleet.Failure, partial []string) {
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, fleet.Failure{Target: r.Target, Err: r.Err})
+			continue
+		}
+		if scan > 0 && len(r.Value) >= scan {
+			partial = append(partial, r.Target)
+		}
+		for _, e := range r.Value {
+			if q == nil || q.Match(e) {
+				agg.Add(r.Target, e)
+			}
+		}
+	}
+	return failed, partial
+}
+
+// renderAggregate renders groups with their share of the total, distinct
+// users and IPs and, with an interval, a per-group sparkline. With an
+// interval and no keys it renders the histogram itself.
+func renderAggregate(r *aggregate.Result) string {
+	var b strings.Builder
+	if len(r.By) == 0 && len(r.Buckets) > 0 {
+		peak := 0
+		for _, n := range r.Total.Histogram {
+			peak = max(peak, n)
+		}
+		rows := make([][]string, len(r.Buckets))
+		for i, t := range r.Buckets {
+			n := r.Total.Histogram[i]
+			rows[i] = []string{bucketLabel(t, r.Interval), strconv.Itoa(n), strings.Repeat("█", n*40/max(peak, 1))}
+		}
+		b.WriteString(ui.RenderTable([]ui.Column{{Header: "Bucket", Width: 16}, {Header: "Count", Width: 7}, {Header: "", Width: 40}}, rows))
+		b.WriteString("\n")
+	} else if len(r.By) > 0 {
+		groups := r.Groups
+		if r.Other != nil {
+			other := *r.Other
+			other.Key = append(other.Key, make([]string, len(r.By)-1)...)
+			groups = append(groups[:len(groups):len(groups)], other)
+		}
+		var columns []ui.Column
+		for i, k := range r.By {
+			w := len(k)
+			for _, g := range groups {
+				w = max(w, len([]rune(g.Key[i])))
+			}
+			columns = append(columns, ui.Column{Header: k, Width: min(w, 40)})
+		}
+		columns = append(columns,
+			ui.Column{Header: "Count", Width: 7},
+			ui.Column{Header: "%", Width: 5},
+			ui.Column{Header: "Users", Width: 6},
+			ui.Column{Header: "IPs", Width: 6})
+		if len(r.Buckets) > 0 {
+			columns = append(columns, ui.Column{Header: "Trend", Width: len(r.Buckets)})
+		}
+		rows := make([][]string, len(groups))
+		for i, g := range groups {
+			row := append(append([]string(nil), g.Key...),
+				strconv.Itoa(g.Count),
+				fmt.Sprintf("%.1f", float64(g.Count)*100/float64(max(r.Total.Count, 1))),
+				strconv.Itoa(g.Users),
+				strconv.Itoa(g.IPs))
+			if len(r.Buckets) > 0 {
+				row = append(row, sparkline(g.Histogram))
+			}
+			rows[i] = row
+		}
+		b.WriteString(ui.RenderTable(columns, rows))
+		b.WriteString("\n")
+	}
+
+	summary := fmt.Sprintf("%d events, %d users, %d IPs", r.Total.Count, r.Total.Users, r.Total.IPs)
+	if len(r.Buckets) > 0 {
+		summary += fmt.Sprintf(" in %d × %s buckets from %s", len(r.Buckets), shortDuration(r.Interval), bucketLabel(r.Buckets[0], r.Interval))
+	}
+	b.WriteString(ui.DimStyle.Render(summary) + "\n")
+	return b.String()
+}
+
+// shortDuration formats d as the user would type it: 1d, 1h, 15m.
+func shortDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders counts scaled to their own peak; zero is a space.
+func sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	out := make([]rune, len(counts))
+	for i, n := range counts {
+		out[i] = ' '
+		if n > 0 {
+			out[i] = sparks[(n*len(sparks)-1)/peak]
+		}
+	}
+	return string(out)
+}
+
+// bucketLabel shows a bucket start as a date for whole-day intervals and
+// with the time of day otherwise, in UTC.
+func bucketLabel(t time.Time, interval time.Duration) string {
+	if interval%(24*time.Hour) == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// writeAggregateCSV writes one row per group: the keys, count, users, IPs
+// and, with an interval, one column per bucket headed by its RFC 3339
+// start. Folded groups are the last row.
+func writeAggregateCSV(out io.Writer, r *aggregate.Result) error {
+	w := csv.NewWriter(out)
+	header := append(append([]string(nil), r.By...), "count", "users", "ips")
+	for _, t := range r.Buckets {
+		header = append(header, t.Format(time.RFC3339))
+	}
+	w.Write(header)
+	groups := r.Groups
+	if r.Other != nil {
+		groups = append(groups[:len(groups):len(groups)], *r.Other)
+	}
+	for _, g := range groups {
+		row := make([]string, len(r.By), len(header))
+		copy(row, g.Key)
+		row = append(row, strconv.Itoa(g.Count), strconv.Itoa(g.Users), strconv.Itoa(g.IPs))
+		for _, n := range g.Histogram {
+			row = append(row, strconv.Itoa(n))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// heatmapMsg carries event counts by type and hour of day.
+type heatmapMsg struct {
+	pivot   *aggregate.Pivot
+	since   string
+	partial []string
+	failed  []fleet.Failure
+	err     error
+}
+
+// hours are the heatmap columns.
+var hours = func() []string {
+	out := make([]string, 24)
+	for h := range out {
+		out[h] = strconv.Itoa(h)
+	}
+	return out
+}()
+
+// fetchHeatmap counts events since since by type and UTC hour of day, on
+// every fleet target or on the model's reader, reading at most queryScan
+// events per target.
+func (m model) fetchHeatmap(since string) tea.Cmd {
+	targets, r := m.targets, m.reader
+	return func() tea.Msg {
+		p := api.EventsParams{Since: since, Limit: queryScan}
+		var results []fleet.Result[[]api.Event]
+		if targets != nil {
+			results = doTargets(context.Background(), targets, func(ctx context.Context, r api.Reader) ([]api.Event, error) {
+				return api.AllEvents(ctx, r, p)
+			})
+		} else {
+			events, err := api.AllEvents(context.Background(), r, p)
+			results = []fleet.Result[[]api.Event]{{Value: events, Err: err}}
+		}
+		agg, err := aggregate.New([]string{"type", "hour(created_at)"}, 0)
+		if err != nil {
+			return heatmapMsg{err: err}
+		}
+		failed, partial := addResults(agg, results, nil, queryScan)
+		if len(failed) == len(results) {
+			if targets == nil {
+				return heatmapMsg{err: failed[0].Err}
+			}
+			return heatmapMsg{err: allFailed(failed, len(targets))}
+		}
+		pivot, err := agg.Result(0).Pivot(hours)
+		return heatmapMsg{pivot: pivot, since: since, partial: partial, failed: failed, err: err}
+	}
+}
+
+// heatShades map a cell's share of the busiest cell to a glyph.
+var heatShades = []string{"··", "░░", "▒▒", "▓▓", "██"}
+
+// renderHeatmap renders event types by hour of day, shaded relative to
+// the busiest cell, with at most maxRows types.
+func renderHeatmap(p *aggregate.Pivot, maxRows int) string {
+	var b strings.Builder
+	b.WriteString(ui.HeaderStyle.Render(fmt.Sprintf("%-24s", "Event Type")))
+	for _, h := range p.Cols {
+		b.WriteString(" " + ui.HeaderStyle.Render(fmt.Sprintf("%2s", h)))
+	}
+	b.WriteString("  " + ui.HeaderStyle.Render("Total") + "\n")
+
+	rows := min(len(p.Rows), maxRows)
+	for i := range rows {
+		label := []rune(p.Rows[i])
+		if len(label) > 24 {
+			label = append(label[:23], '…')
+		}
+		fmt.Fprintf(&b, "%-24s", string(label))
+		for _, n := range p.Cells[i] {
+			switch {
+			case n == 0:
+				b.WriteString(" " + ui.DimStyle.Render("  "))
+			default:
+				shade := heatShades[min((n*len(heatShades)-1)/max(p.Max, 1), len(heatShades)-1)]
+				if n == p.Max {
+					shade = ui.ErrorStyle.Render(shade)
+				}
+				b.WriteString(" " + shade)
+			}
+		}
+		fmt.Fprintf(&b, "  %d\n", p.RowTotals[i])
+	}
+	if more := len(p.Rows) - rows; more > 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("+%d more types\n", more)))
+	}
+	b.WriteString("\n" + ui.DimStyle.Render(fmt.Sprintf("·· few  ░░ ▒▒ ▓▓  ██ busiest hour (%d events)", p.Max)) + "\n")
+	return b.String()
+}
+
+func (m model) viewHeatmap() string {
+	var b strings.Builder
+	if m.dataErr != nil {
+		b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.dataErr)))
+		b.WriteString(ui.DimStyle.Render("\n\nenter continue • q quit"))
+		return b.String()
+	}
+	if m.heatmap == nil {
+		b.WriteString(ui.DimStyle.Render("Loading..."))
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("Event Heatmap (hour of day UTC, since %s)\n\n", m.eventSince))
+	b.WriteString(renderFailures(m.failed))
+	for _, t := range m.heatPartial {
+		if t == "" {
+			t = "source"
+		}
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%s: only the newest %d events counted\n", t, queryScan)))
+	}
+	if len(m.heatmap.Rows) == 0 {
+		b.WriteString(ui.DimStyle.Render("No events in time window."))
+		b.WriteString(ui.DimStyle.Render("\n\nenter continue • q quit"))
+		return b.String()
+	}
+	maxRows := 30
+	if m.height > 0 {
+		maxRows = max(m.height-12, 5)
+	}
+	b.WriteString(renderHeatmap(m.heatmap, maxRows))
+	b.WriteString(ui.DimStyle.Render("\nenter continue • q quit"))
+	return b.String()
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/archive"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/offline"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+const archiveUsage = "usage: plctl [--contexts a,b] archive sync [--db file] [--since ts] [--follow] [--json] | status [--db file] [--json] | verify [--db file] [--json] | export [--db file] [--since 7d] [--until ts]"
+
+// runArchive keeps an append-only local copy of each target's security
+// events, readable later with --offline.
+func runArchive(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(archiveUsage)
+	}
+	switch args[0] {
+	case "sync":
+		return runArchiveSync(ctx, args[1:])
+	case "status":
+		return runArchiveStatus(ctx, args[1:])
+	case "verify":
+		return runArchiveVerify(ctx, args[1:])
+	case "export":
+		return runArchiveExport(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown archive subcommand %q", args[0])
+}
+
+// archiveFile is a target's archive.
+type archiveFile struct {
+	target string
+	path   string
+}
+
+// archiveFiles maps the selected targets to their archives: db if set,
+// otherwise each target's default path.
+func archiveFiles(db string) ([]archiveFile, error) {
+	if db != "" {
+		return []archiveFile{{target: filepath.Base(db), path: db}}, nil
+	}
+	if offlinePath != "" {
+		return nil, errors.New("archive with --offline needs --db")
+	}
+	targets, err := resolveTargets()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]archiveFile, len(targets))
+	for i, t := range targets {
+		files[i] = archiveFile{target: t.Name, path: archive.DefaultPath(t.Name)}
+	}
+	return files, nil
+}
+
+// openExisting opens an archive that sync has already created.
+func openExisting(path string) (*archive.Archive, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("no archive at %s; run plctl archive sync", path)
+	}
+	return archive.Open(path)
+}
+
+type syncResult struct {
+	Target        string `json:"target"`
+	Path          string `json:"path"`
+	SyncedThrough string `json:"synced_through,omitempty"`
+	archive.Stats
+}
+
+func runArchiveSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("archive sync", flag.ContinueOnError)
+	db := fs.String("db", "", "archive `file` (default <user config dir>/plctl/archive/<target>.db)")
+	since := fs.String("since", "", "first sync only: timestamp or lookback to start from (default: everything the source has)")
+	follow := fs.Bool("follow", false, "keep archiving from the live event stream after catching up")
+	asJSON := fs.Bool("json", false, "print one JSON object per target")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	start := "1970-01-01T00:00:00Z"
+	if *since != "" {
+		var err error
+		if start, err = api.ParseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// A local export can seed an archive, e.g. from a backup.sh archive
+	// older than the Worker's retention.
+	if offlinePath != "" {
+		if *db == "" || *follow {
+			return errors.New("archive sync with --offline needs --db and cannot --follow")
+		}
+		src, err := offline.Open(offlinePath)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		r, err := syncArchive(ctx, archiveFile{target: filepath.Base(*db), path: *db}, src, start)
+		printSync(r, err, *asJSON)
+		return err
+	}
+
+	targets, err := resolveTargets()
+	if err != nil {
+		return err
+	}
+	if *db != "" && len(targets) > 1 {
+		return errors.New("--db names one archive; drop it to use one file per context")
+	}
+	files := make([]archiveFile, len(targets))
+	for i, t := range targets {
+		files[i] = archiveFile{target: t.Name, path: archive.DefaultPath(t.Name)}
+		if *db != "" {
+			files[i].path = *db
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := make([]error, len(targets))
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ops := resolveTransport(t.Client)
+			defer ops.Close()
+			r, err := syncArchive(ctx, files[i], ops, start)
+			mu.Lock()
+			printSync(r, err, *asJSON)
+			mu.Unlock()
+			if err == nil && *follow {
+				err = followArchive(ctx, t, files[i].path, ops, &mu)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	var failed []fleet.Failure
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fleet.Failure{Target: targets[i].Name, Err: err})
+		}
+	}
+	if len(targets) == 1 && len(failed) == 1 {
+		return failed[0].Err
+	}
+	return reportFailures(failed, len(targets))
+}
+
+// syncArchive catches one archive up with r.
+func syncArchive(ctx context.Context, f archiveFile, r api.Reader, since string) (syncResult, error) {
+	res := syncResult{Target: f.target, Path: f.path}
+	a, err := archive.Open(f.path)
+	if err != nil {
+		return res, err
+	}
+	defer a.Close()
+	res.Stats, err = a.Sync(ctx, r, since)
+	if mark, merr := a.SyncedThrough(ctx); merr == nil {
+		res.SyncedThrough = mark
+	}
+	return res, err
+}
+
+func printSync(r syncResult, err error, asJSON bool) {
+	if asJSON {
+		if err == nil {
+			json.NewEncoder(os.Stdout).Encode(r)
+		}
+		return
+	}
+	if r.Conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d event(s) differ from the archived copy, which was kept (run archive verify)\n", r.Target, r.Conflicts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: sync stopped after archiving %d events: %v\n", r.Target, r.Added, err)
+		return
+	}
+	fmt.Printf("%s  +%d events (%d already archived)  synced through %s  %s\n",
+		ui.HeaderStyle.Render(r.Target), r.Added, r.Duplicates+r.Conflicts, orDash(r.SyncedThrough), ui.DimStyle.Render(r.Path))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// followArchive appends pushed events until ctx is cancelled. Each
+// (re)connect first catches up over REST to fill the gap; while the stream
+// stays connected after that, each event also advances the synced-through
+// mark.
+func followArchive(ctx context.Context, t fleet.Target, path string, ops api.Reader, mu *sync.Mutex) error {
+	a, err := archive.Open(path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	live := false
+	sealed := ""
+	warn := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(os.Stderr, t.Name+": "+format+"\n", args...)
+	}
+	return followEvents(ctx, t.Name, t.Client, nil, nil, streamHandlers{
+		Connect: func(*api.Subscription) {
+			st, err := a.Sync(ctx, ops, "")
+			if err != nil {
+				warn("catch-up failed, retrying on the next connect: %v", err)
+				return
+			}
+			live = true
+			if st.Added > 0 {
+				warn("caught up %d events", st.Added)
+			}
+		},
+		Event: func(e api.Event) {
+			st, err := a.Add(ctx, []api.Event{e})
+			switch {
+			case err != nil:
+				warn("archive event %d: %v", e.ID, err)
+				return
+			case st.Conflicts > 0:
+				warn("event %d differs from the archived copy, which was kept", e.ID)
+			}
+			if !live {
+				return
+			}
+			if err := a.Mark(ctx, e.CreatedAt); err != nil {
+				warn("%v", err)
+			}
+			if d := e.CreatedAt[:min(len(e.CreatedAt), len(time.DateOnly))]; d != sealed {
+				sealed = d
+				if err := a.Seal(ctx); err != nil {
+					warn("seal: %v", err)
+				}
+			}
+		},
+		Disconnect: func(error) { live = false },
+	})
+}
+
+func runArchiveStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("archive status", flag.ContinueOnError)
+	db := fs.String("db", "", "archive `file`")
+	asJSON := fs.Bool("json", false, "print one JSON object per archive")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files, err := archiveFiles(*db)
+	if err != nil {
+		return err
+	}
+	var failed []fleet.Failure
+	for _, f := range files {
+		s, err := archiveStatus(ctx, f.path)
+		if err != nil {
+			failed = append(failed, fleet.Failure{Target: f.target, Err: err})
+			continue
+		}
+		if *asJSON {
+			json.NewEncoder(os.Stdout).Encode(struct {
+				Target string `json:"target"`
+				*archive.Status
+			}{f.target, s})
+			continue
+		}
+		fmt.Printf("%s  %s\n", ui.HeaderStyle.Render(f.target), ui.DimStyle.Render(s.Path))
+		fmt.Printf("  %-16s %d (%s → %s)\n", "events", s.Events, orDash(s.First), orDash(s.Last))
+		fmt.Printf("  %-16s %s\n", "synced through", orDash(s.SyncedThrough))
+		fmt.Printf("  %-16s %d (%d sealed)\n", "days", s.Days, s.SealedDays)
+		fmt.Printf("  %-16s %.1f MB\n", "size", float64(s.Bytes)/(1<<20))
+	}
+	if len(files) == 1 && len(failed) == 1 {
+		return failed[0].Err
+	}
+	return reportFailures(failed, len(files))
+}
+
+func archiveStatus(ctx context.Context, path string) (*archive.Status, error) {
+	a, err := openExisting(path)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+	return a.Status(ctx)
+}
+
+func runArchiveVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("archive verify", flag.ContinueOnError)
+	db := fs.String("db", "", "archive `file`")
+	asJSON := fs.Bool("json", false, "print one JSON object per archive")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files, err := archiveFiles(*db)
+	if err != nil {
+		return err
+	}
+	bad := 0
+	for _, f := range files {
+		r, err := archiveVerify(ctx, f.path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render(fmt.Sprintf("%s: %v", f.target, err)))
+			bad++
+			continue
+		}
+		if !r.OK() {
+			bad++
+		}
+		if *asJSON {
+			json.NewEncoder(os.Stdout).Encode(struct {
+				Target string `json:"target"`
+				OK     bool   `json:"ok"`
+				*archive.Report
+			}{f.target, r.OK(), r})
+			continue
+		}
+		fmt.Print(renderVerify(f.target, r))
+	}
+	if bad > 0 {
+		return fmt.Errorf("%d of %d archive(s) failed verification", bad, len(files))
+	}
+	return nil
+}
+
+func archiveVerify(ctx context.Context, path string) (*archive.Report, error) {
+	a, err := openExisting(path)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+	return a.Verify(ctx)
+}
+
+func renderVerify(target string, r *archive.Report) string {
+	var b []byte
+	status := ui.SuccessStyle.Render("ok")
+	if !r.OK() {
+		status = ui.ErrorStyle.Render("FAILED")
+	}
+	b = fmt.Appendf(b, "%s  %s  %d sealed day(s) checked\n", ui.HeaderStyle.Render(target), status, r.SealedDays)
+	for _, msg := range r.Integrity {
+		b = fmt.Appendf(b, "  %s\n", ui.ErrorStyle.Render("integrity: "+msg))
+	}
+	for _, m := range r.Mismatched {
+		b = fmt.Appendf(b, "  %s\n", ui.ErrorStyle.Render(fmt.Sprintf("%s: events changed since sealing (%d sealed, %d now)", m.Day, m.SealedEvents, m.Events)))
+	}
+	for _, g := range r.Gaps {
+		ids := fmt.Sprintf("id %d", g.From)
+		if g.To > g.From {
+			ids = fmt.Sprintf("ids %d–%d", g.From, g.To)
+		}
+		b = fmt.Appendf(b, "  %s\n", ui.DimStyle.Render(ids+" not archived (skipped by the server, or missed while offline)"))
+	}
+	return string(b)
+}
+
+// runArchiveExport prints archived events as NDJSON, oldest first, in the
+// shape watch --replay reads.
+func runArchiveExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("archive export", flag.ContinueOnError)
+	db := fs.String("db", "", "archive `file`")
+	since := fs.String("since", "", "timestamp or lookback (default: everything)")
+	until := fs.String("until", "", "timestamp or lookback to stop before")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files, err := archiveFiles(*db)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return errors.New("archive export reads one archive; use --context or --db")
+	}
+	now := time.Now()
+	var sinceStr, untilStr string
+	if *since != "" {
+		if sinceStr, err = api.ParseSince(*since, now); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if untilStr, err = api.ParseSince(*until, now); err != nil {
+			return err
+		}
+	}
+	a, err := openExisting(files[0].path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	enc := json.NewEncoder(os.Stdout)
+	return a.Export(ctx, sinceStr, untilStr, func(e api.Event) error { return enc.Encode(e) })
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/ui"
+	"github.com/private-landing/cli/internal/userauth"
+	"golang.org/x/term"
+)
+
+const authUsage = "usage: plctl auth register|login|me|refresh|logout|change-password [--jar file] [--email addr] [--password-stdin]"
+
+// runAuth exercises the public end-user auth API. It needs only the API
+// URL; the agent key is used by 'me --check' to look the session up.
+func runAuth(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(authUsage)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("auth "+sub, flag.ContinueOnError)
+	jarPath := fs.String("jar", "", "cookie jar `file` (default <user config dir>/plctl/auth/<host>.json)")
+	var email *string
+	var passwordStdin *bool
+	if sub == "register" || sub == "login" || sub == "change-password" {
+		passwordStdin = fs.Bool("password-stdin", false, "read the password(s) from stdin, one per line")
+	}
+	if sub == "register" || sub == "login" {
+		email = fs.String("email", "", "account email")
+	}
+	check := false
+	if sub == "me" {
+		fs.BoolVar(&check, "check", false, "look up the token's sid with the agent key (ListSessions)")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	apiURL, err := resolveURL()
+	if err != nil {
+		return err
+	}
+	if *jarPath == "" {
+		*jarPath = userauth.DefaultJarPath(apiURL)
+	}
+	jar, err := userauth.LoadJar(*jarPath)
+	if err != nil {
+		return err
+	}
+	c := userauth.NewClient(apiURL, jar)
+	var passwords *bufio.Reader
+	if passwordStdin != nil && *passwordStdin {
+		passwords = bufio.NewReader(os.Stdin)
+	}
+
+	switch sub {
+	case "register", "login":
+		if *email == "" {
+			return fmt.Errorf("auth %s: --email is required", sub)
+		}
+		password, err := readPassword("Password: ", passwords)
+		if err != nil {
+			return err
+		}
+		if sub == "register" {
+			if err := c.Register(ctx, *email, password); err != nil {
+				return err
+			}
+			fmt.Println(ui.SuccessStyle.Render("Account created for " + *email))
+			return nil
+		}
+		res, err := c.Login(ctx, *email, password)
+		if err != nil {
+			return err
+		}
+		msg := "Logged in as " + *email
+		if res.Difficulty > 0 {
+			msg += fmt.Sprintf(" (solved PoW challenge, difficulty %d)", res.Difficulty)
+		}
+		fmt.Println(ui.SuccessStyle.Render(msg))
+	case "me":
+		uid, err := c.Me(ctx)
+		if err != nil {
+			return errors.Join(err, jar.Save())
+		}
+		fmt.Printf("%s  %d\n", ui.HeaderStyle.Render(fmt.Sprintf("%-8s", "User")), uid)
+	case "refresh":
+		if err := c.Refresh(ctx); err != nil {
+			return errors.Join(err, jar.Save())
+		}
+		fmt.Println(ui.SuccessStyle.Render("Access token refreshed"))
+	case "logout":
+		if err := c.Logout(ctx); err != nil {
+			return err
+		}
+		fmt.Println(ui.SuccessStyle.Render("Logged out"))
+		return jar.Save()
+	case "change-password":
+		current, err := readPassword("Current password: ", passwords)
+		if err != nil {
+			return err
+		}
+		next, err := readPassword("New password: ", passwords)
+		if err != nil {
+			return err
+		}
+		if err := c.ChangePassword(ctx, current, next); err != nil {
+			return err
+		}
+		fmt.Println(ui.SuccessStyle.Render("Password changed; all sessions ended, log in again"))
+		return jar.Save()
+	default:
+		return fmt.Errorf("unknown auth subcommand %q", sub)
+	}
+
+	if err := jar.Save(); err != nil {
+		return err
+	}
+	access, refresh := c.Tokens()
+	printClaims("access", access)
+	printClaims("refresh", refresh)
+	if check {
+		return checkSession(ctx, refresh)
+	}
+	return nil
+}
+
+func printClaims(name string, c *userauth.Claims) {
+	label := ui.HeaderStyle.Render(fmt.Sprintf("%-8s", name))
+	if c == nil {
+		fmt.Printf("%s  %s\n", label, ui.DimStyle.Render("none"))
+		return
+	}
+	exp := "-"
+	if t := c.Expiry(); !t.IsZero() {
+		exp = fmt.Sprintf("%s (in %s)", t.UTC().Format(time.RFC3339), time.Until(t).Round(time.Second))
+	}
+	fmt.Printf("%s  uid %d  sid %s  typ %s  exp %s\n", label, c.UID, c.SID, c.Typ, exp)
+}
+
+// checkSession confirms the token's session is active server-side using
+// the ops API.
+func checkSession(ctx context.Context, c *userauth.Claims) error {
+	if c == nil {
+		return userauth.ErrNotLoggedIn
+	}
+	state, err := lookupSession(ctx, c)
+	if err != nil {
+		return fmt.Errorf("--check: %w", err)
+	}
+	return printSessionState(c, state)
+}
+
+// lookupSession asks the ops API about the claims' session with the
+// configured agent key.
+func lookupSession(ctx context.Context, c *userauth.Claims) (*userauth.SessionState, error) {
+	client, _, err := resolveClient()
+	if err != nil {
+		return nil, err
+	}
+	ops := resolveTransport(client)
+	defer ops.Close()
+	return userauth.LookupSession(ctx, ops, c, time.Now())
+}
+
+// printSessionState reports the session's state, returning an error unless
+// it is active so scripts can test the exit code.
+func printSessionState(c *userauth.Claims, state *userauth.SessionState) error {
+	if state.Status == userauth.SessionActive {
+		fmt.Println(ui.SuccessStyle.Render(fmt.Sprintf("Session %s is active (expires %s)", c.SID, state.Session.ExpiresAt)))
+		return nil
+	}
+	return fmt.Errorf("session %s of user %d is %s: %s", c.SID, c.UID, state.Status, state.Reason)
+}
+
+// readPassword reads a line from r, or prompts without echo when r is nil.
+func readPassword(prompt string, r *bufio.Reader) (string, error) {
+	if r != nil {
+		line, err := r.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line == "" {
+			return "", fmt.Errorf("read password from stdin: %w", errors.Join(err, errors.New("empty password")))
+		}
+		return line, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("stdin is not a terminal; use --password-stdin")
+	}
+	fmt.Fprint(os.Stderr, ui.PromptStyle.Render(prompt))
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
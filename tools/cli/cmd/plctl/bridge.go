@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/bridge"
+	"github.com/private-landing/cli/internal/gateway"
+)
+
+func runBridge(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "token" {
+		if len(args) != 2 {
+			return errors.New("usage: plctl bridge token <name>")
+		}
+		// Printed in tokens-file format, ready to append.
+		fmt.Printf("%s %s\n", args[1], bridge.NewToken())
+		return nil
+	}
+
+	fs := flag.NewFlagSet("bridge", flag.ContinueOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "listen `address`")
+	tokensPath := fs.String("tokens", "plctl-bridge-tokens", "`file` of \"name token\" lines (create with 'plctl bridge token <name>')")
+	tlsCert := fs.String("tls-cert", "", "serve HTTPS with this certificate `file`")
+	tlsKey := fs.String("tls-key", "", "private key `file` for --tls-cert")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return errors.New("--tls-cert and --tls-key must be set together")
+	}
+
+	tokens, err := bridge.LoadTokens(*tokensPath)
+	if err != nil {
+		return fmt.Errorf("load tokens: %w", err)
+	}
+	client, _, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+
+	ops := resolveTransport(client)
+	defer ops.Close()
+	hub := gateway.NewHub()
+	b := &bridge.Bridge{Reader: ops, Hub: hub, Tokens: tokens, Log: os.Stderr}
+	srv := &http.Server{Handler: b.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	upstream := make(chan error, 1)
+	go func() {
+		err := followEvents(ctx, "bridge", client, nil, nil, streamHandlers{
+			Connect: func(sub *api.Subscription) {
+				hub.SetUpstream(true)
+				fmt.Fprintf(os.Stderr, "bridge: upstream connected as %s\n", sub.Granted.Agent)
+			},
+			Event:      hub.Publish,
+			Disconnect: func(error) { hub.SetUpstream(false) },
+		})
+		upstream <- err
+		cancel()
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdown, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "bridge: listening on %s (GET /events/stream, /events, /stats)\n", ln.Addr())
+	if *tlsCert != "" {
+		err = srv.ServeTLS(ln, *tlsCert, *tlsKey)
+	} else {
+		err = srv.Serve(ln)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	select {
+	case err := <-upstream:
+		return err
+	case <-time.After(time.Second):
+		return nil
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/offline"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// command is a non-interactive plctl subcommand.
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{name: "watch", run: runWatch},
+		{name: "playbook", run: runPlaybook},
+		{name: "evidence", run: runEvidence},
+		{name: "report", run: runReport},
+		{name: "mcp", run: runMCP},
+		{name: "gateway", run: runGateway},
+		{name: "bridge", run: runBridge},
+		{name: "events", run: runEvents},
+		{name: "agents", run: runAgents},
+		{name: "whoami", run: runWhoami},
+		{name: "doctor", run: runDoctor},
+		{name: "auth", run: runAuth},
+		{name: "token", run: runToken},
+		{name: "probe", run: runProbe},
+		{name: "loadtest", run: runLoadtest},
+		{name: "archive", run: runArchive},
+		{name: "replay", run: runReplay},
+	}
+}
+
+// isCommand reports whether name is a plctl subcommand.
+func isCommand(name string) bool {
+	for _, c := range commands() {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runCommand dispatches a subcommand and returns the process exit code.
+func runCommand(name string, args []string) int {
+	for _, c := range commands() {
+		if c.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := c.run(ctx, args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n", name)
+	fmt.Fprintln(os.Stderr, "Run 'plctl --help' for usage information")
+	return 1
+}
+
+// Global flags. An empty selectedContexts means the target comes from the
+// PLCTL_* environment; a non-empty offlinePath replaces the target with a
+// local database export.
+var (
+	selectedContexts []string
+	transportMode    = api.TransportAuto
+	offlinePath      string
+)
+
+// errOffline is returned by commands that need a live target under --offline.
+var errOffline = errors.New("this command needs a live target and cannot run with --offline")
+
+// parseGlobalFlags consumes leading --context/--contexts/--transport/--offline
+// flags and returns the remaining arguments.
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 {
+		name, val, hasVal := strings.Cut(args[0], "=")
+		if name != "--context" && name != "--contexts" && name != "--transport" && name != "--offline" {
+			break
+		}
+		if !hasVal {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s needs a value", name)
+			}
+			val = args[1]
+			args = args[1:]
+		}
+		args = args[1:]
+		if name == "--transport" {
+			switch val {
+			case api.TransportAuto, api.TransportREST, api.TransportWS:
+			default:
+				return nil, fmt.Errorf("unknown transport %q (want auto, rest or ws)", val)
+			}
+			transportMode = val
+			continue
+		}
+		if name == "--offline" {
+			offlinePath = val
+			continue
+		}
+		for _, c := range strings.Split(val, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				selectedContexts = append(selectedContexts, c)
+			}
+		}
+	}
+	if offlinePath != "" && len(selectedContexts) > 0 {
+		return nil, errors.New("--offline cannot be combined with --context or --contexts")
+	}
+	return args, nil
+}
+
+// resolveTargets returns the selected contexts, or a single target from the
+// PLCTL_* environment named after its host.
+func resolveTargets() ([]fleet.Target, error) {
+	if offlinePath != "" {
+		return nil, errOffline
+	}
+	if len(selectedContexts) > 0 {
+		cfg, err := fleet.LoadConfig(fleet.DefaultConfigPath())
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Targets(selectedContexts)
+	}
+	apiURL := os.Getenv("PLCTL_API_URL")
+	apiKey := os.Getenv("PLCTL_API_KEY")
+	provSecret := os.Getenv("PLCTL_PROVISIONING_SECRET")
+
+	if apiURL == "" || apiKey == "" {
+		return nil, errors.New("PLCTL_API_URL and PLCTL_API_KEY environment variables are required")
+	}
+	name := apiURL
+	if u, err := url.Parse(apiURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return []fleet.Target{{Name: name, URL: apiURL, Client: api.NewClient(apiURL, apiKey, provSecret)}}, nil
+}
+
+// resolveClient returns the client for commands that operate on a single
+// target: the one --context, or the PLCTL_* environment.
+func resolveClient() (*api.Client, string, error) {
+	targets, err := resolveTargets()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(targets) != 1 {
+		return nil, "", errors.New("this command runs against one target; use --context <name>")
+	}
+	return targets[0].Client, targets[0].URL, nil
+}
+
+// resolveURL returns the API base URL for commands that need no agent
+// key: the one --context's url, or PLCTL_API_URL.
+func resolveURL() (string, error) {
+	if offlinePath != "" {
+		return "", errOffline
+	}
+	switch len(selectedContexts) {
+	case 0:
+		apiURL := os.Getenv("PLCTL_API_URL")
+		if apiURL == "" {
+			return "", errors.New("PLCTL_API_URL environment variable is required")
+		}
+		return apiURL, nil
+	case 1:
+		cfg, err := fleet.LoadConfig(fleet.DefaultConfigPath())
+		if err != nil {
+			return "", err
+		}
+		c, ok := cfg.Contexts[selectedContexts[0]]
+		if !ok || c.URL == "" {
+			return "", fmt.Errorf("unknown context %q (configured: %s)", selectedContexts[0], strings.Join(cfg.Names(), ", "))
+		}
+		return c.URL, nil
+	}
+	return "", errors.New("this command runs against one target; use --context <name>")
+}
+
+// readCloser is a query source that holds a connection or file open.
+type readCloser interface {
+	api.Reader
+	Close() error
+}
+
+// resolveReader returns the --offline export, or the single target's
+// transport, for commands that only read.
+func resolveReader() (readCloser, error) {
+	if offlinePath != "" {
+		return offline.Open(offlinePath)
+	}
+	client, _, err := resolveClient()
+	if err != nil {
+		return nil, err
+	}
+	return resolveTransport(client), nil
+}
+
+// queryTargets runs fn against every selected target's transport, or
+// against the --offline export as the only target.
+func queryTargets[T any](ctx context.Context, fn func(context.Context, api.Reader) (T, error)) ([]fleet.Result[T], error) {
+	if offlinePath != "" {
+		db, err := offline.Open(offlinePath)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		v, err := fn(ctx, db)
+		return []fleet.Result[T]{{Target: "offline", Value: v, Err: err}}, nil
+	}
+	targets, err := resolveTargets()
+	if err != nil {
+		return nil, err
+	}
+	return doTargets(ctx, targets, fn), nil
+}
+
+// doTargets runs fn against each target through its --transport
+// selection, closing the transport when fn returns.
+func doTargets[T any](ctx context.Context, targets []fleet.Target, fn func(context.Context, api.Reader) (T, error)) []fleet.Result[T] {
+	return fleet.Do(ctx, targets, func(ctx context.Context, c *api.Client) (T, error) {
+		ops := resolveTransport(c)
+		defer ops.Close()
+		return fn(ctx, ops)
+	})
+}
+
+// resolveTransport wraps client in the --transport selection.
+func resolveTransport(client *api.Client) api.Transport {
+	t, _ := api.NewTransport(client, transportMode) // validated in parseGlobalFlags
+	return t
+}
+
+// confirmTarget asks before operating against a target that does not look
+// like a non-production environment. Returns true to proceed.
+func confirmTarget(apiURL string) bool {
+	if isSafeTarget(apiURL) {
+		return true
+	}
+	fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render("WARNING: Target does not appear to be a non-production environment."))
+	fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render("Set ENVIRONMENT to a non-production value (e.g. 'development', 'staging') to suppress."))
+	return confirm("Continue?")
+}
+
+// confirm prompts on stderr and reads a y/N answer from stdin.
+func confirm(prompt string) bool {
+	fmt.Fprint(os.Stderr, ui.PromptStyle.Render(prompt+" (y/N) "))
+
+	var answer string
+	fmt.Scanln(&answer)
+	return answer == "y" || answer == "Y"
+}
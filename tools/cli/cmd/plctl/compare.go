@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/compare"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/offline"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// statsCompare is how the TUI stats screen compares: the last day against
+// the week before it.
+var statsCompare = compare.Options{Window: 24 * time.Hour, History: 7, Z: 3, Min: 5}
+
+// readerNow is the end of the current window for r: the snapshot time for
+// an offline export, otherwise now.
+func readerNow(r api.Reader) time.Time {
+	if db, ok := r.(*offline.DB); ok {
+		return db.AsOf
+	}
+	return time.Now()
+}
+
+// runEventsCompare compares each target's current window of stats with the
+// windows before it and lists flagged types first.
+func runEventsCompare(ctx context.Context, o compare.Options, asJSON bool) error {
+	results, err := queryTargets(ctx, func(ctx context.Context, r api.Reader) (*compare.Comparison, error) {
+		o := o
+		o.Now = readerNow(r)
+		return compare.Compare(ctx, r, o)
+	})
+	if err != nil {
+		return err
+	}
+	rows, failed := mergeComparisons(results)
+
+	if asJSON {
+		type row struct {
+			Target string `json:"target"`
+			compare.Change
+			Previous int `json:"previous"`
+			Delta    int `json:"delta"`
+		}
+		out := make([]row, len(rows))
+		for i, r := range rows {
+			out[i] = row{r.Target, r.Item, r.Item.Previous(), r.Item.Delta()}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+		return reportFailures(failed, len(results))
+	}
+
+	if len(rows) > 0 {
+		fmt.Print(renderComparison(rows, len(results) > 1))
+		for _, r := range results {
+			if r.Err == nil {
+				fmt.Println(ui.DimStyle.Render(compareSummary(r.Value, o)))
+				break
+			}
+		}
+	}
+	return reportFailures(failed, len(results))
+}
+
+// mergeComparisons flattens per-target comparisons into rows, flagged
+// changes first across all targets.
+func mergeComparisons(results []fleet.Result[*compare.Comparison]) ([]fleet.Row[compare.Change], []fleet.Failure) {
+	var rows []fleet.Row[compare.Change]
+	var failed []fleet.Failure
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, fleet.Failure{Target: r.Target, Err: r.Err})
+			continue
+		}
+		for _, c := range r.Value.Changes {
+			rows = append(rows, fleet.Row[compare.Change]{Target: r.Target, Item: c})
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b fleet.Row[compare.Change]) int { return compare.Order(a.Item, b.Item) })
+	return rows, failed
+}
+
+// renderComparison renders current and previous counts with the change,
+// baseline and z-score. Spikes are red and drops highlighted.
+func renderComparison(rows []fleet.Row[compare.Change], withTargets bool) string {
+	columns := []ui.Column{
+		{Header: "Event Type", Width: 30},
+		{Header: "Now", Width: 7},
+		{Header: "Prev", Width: 7},
+		{Header: "Δ", Width: 7},
+		{Header: "Δ%", Width: 6},
+		{Header: "Baseline", Width: 12},
+		{Header: "z", Width: 6},
+		{Header: "", Width: 7},
+	}
+	table := make([][]string, len(rows))
+	var names []string
+	for i, r := range rows {
+		c := r.Item
+		flag := ""
+		switch c.Flag {
+		case compare.Spike:
+			flag = "▲ spike"
+		case compare.Drop:
+			flag = "▼ drop"
+		}
+		table[i] = []string{
+			c.Type,
+			strconv.Itoa(c.Current),
+			strconv.Itoa(c.Previous()),
+			fmt.Sprintf("%+d", c.Delta()),
+			c.Percent(),
+			fmt.Sprintf("%.1f±%.1f", c.Mean, c.StdDev),
+			fmt.Sprintf("%+.1f", math.Round(c.Z*10)/10+0), // +0 drops the sign of -0
+			flag,
+		}
+		names = append(names, r.Target)
+	}
+	if !withTargets {
+		names = nil
+	}
+	columns, table = withTarget(columns, table, names)
+
+	lines := strings.Split(ui.RenderTable(columns, table), "\n")
+	for i, r := range rows {
+		switch r.Item.Flag {
+		case compare.Spike:
+			lines[i+2] = ui.ErrorStyle.Render(lines[i+2])
+		case compare.Drop:
+			lines[i+2] = ui.PromptStyle.Render(lines[i+2])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compareSummary describes the windows and threshold behind a comparison.
+func compareSummary(c *compare.Comparison, o compare.Options) string {
+	return fmt.Sprintf("now: %s from %s; prev and baseline: the %d windows before; flagged at |z| ≥ %g with at least %d events",
+		shortDuration(c.Window), c.Since.Format(time.RFC3339), o.History, o.Z, o.Min)
+}
+
+// fetchComparison compares the last day with the week before on the
+// model's reader, or on every fleet target.
+func (m model) fetchComparison() tea.Cmd {
+	if m.targets != nil {
+		targets := m.targets
+		return func() tea.Msg {
+			results := doTargets(context.Background(), targets, func(ctx context.Context, r api.Reader) (*compare.Comparison, error) {
+				return compare.Compare(ctx, r, statsCompare)
+			})
+			return comparisonStats(results, len(targets))
+		}
+	}
+	r := m.reader
+	return func() tea.Msg {
+		o := statsCompare
+		o.Now = readerNow(r)
+		c, err := compare.Compare(context.Background(), r, o)
+		if err != nil {
+			return eventStatsMsg{err: err}
+		}
+		return eventStatsMsg{stats: currentCounts(c), since: c.Since.Format(time.RFC3339), compare: c}
+	}
+}
+
+// comparisonStats turns per-target comparisons into the fleet stats view,
+// with each type's most severe flag across targets.
+func comparisonStats(results []fleet.Result[*compare.Comparison], targets int) eventStatsMsg {
+	counts := make([]fleet.Result[*api.EventStatsResponse], len(results))
+	flags := map[string]compare.Flag{}
+	var since string
+	for i, r := range results {
+		counts[i] = fleet.Result[*api.EventStatsResponse]{Target: r.Target, Err: r.Err}
+		if r.Err != nil {
+			continue
+		}
+		since = r.Value.Since.Format(time.RFC3339)
+		counts[i].Value = &api.EventStatsResponse{Since: since, Stats: currentCounts(r.Value)}
+		for _, c := range r.Value.Flagged() {
+			if flags[c.Type] != compare.Spike {
+				flags[c.Type] = c.Flag
+			}
+		}
+	}
+	stats, failed := fleet.MergeStats(counts)
+	if err := allFailed(failed, targets); err != nil {
+		return eventStatsMsg{err: err}
+	}
+	return eventStatsMsg{fleet: stats, since: since, failed: failed, flags: flags}
+}
+
+// currentCounts is the current window's nonzero counts by type.
+func currentCounts(c *compare.Comparison) map[string]int {
+	out := map[string]int{}
+	for _, ch := range c.Changes {
+		if ch.Current > 0 {
+			out[ch.Type] = ch.Current
+		}
+	}
+	return out
+}
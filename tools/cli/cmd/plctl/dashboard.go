@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/dashboard"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// dashRefresh is how often stats and the session count are refetched.
+const dashRefresh = 15 * time.Second
+
+type dashStatsMsg struct {
+	stats    map[string]int
+	sessions int
+	err      error
+}
+
+type dashBackfillMsg struct {
+	events []api.Event
+	err    error
+}
+
+type dashSubMsg struct {
+	sub *api.Subscription
+	err error
+}
+
+type dashEventMsg struct {
+	sub   *api.Subscription
+	event api.Event
+	err   error
+}
+
+type dashTickMsg time.Time
+
+// startDashboard resets the wallboard and starts the stats refresh, the
+// last-hour backfill and the live subscription.
+func (m model) startDashboard() (model, tea.Cmd) {
+	m.dash = &dashboard.Dashboard{}
+	m.dashErr = nil
+	m.dashLiveErr = nil
+	m.dashDialing = true
+	m.state = stateDashboard
+	return m, tea.Batch(m.fetchDashStats(), m.fetchDashBackfill(), m.dashSubscribe(), dashTick())
+}
+
+func (m *model) closeDashboard() {
+	if m.dashSub != nil {
+		m.dashSub.Close()
+		m.dashSub = nil
+	}
+}
+
+func dashTick() tea.Cmd {
+	return tea.Tick(dashRefresh, func(t time.Time) tea.Msg { return dashTickMsg(t) })
+}
+
+func (m model) fetchDashStats() tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		since := time.Now().UTC().Add(-dashboard.Window).Format(time.RFC3339)
+		stats, err := m.reader.GetEventStats(ctx, since)
+		if err != nil {
+			return dashStatsMsg{err: err}
+		}
+		sessions, err := api.AllSessions(ctx, m.reader, api.SessionsParams{})
+		if err != nil {
+			return dashStatsMsg{err: err}
+		}
+		return dashStatsMsg{stats: stats.Stats, sessions: len(sessions)}
+	}
+}
+
+func (m model) fetchDashBackfill() tea.Cmd {
+	return func() tea.Msg {
+		since := time.Now().UTC().Add(-dashboard.Window).Format(time.RFC3339)
+		events, err := api.AllEvents(context.Background(), m.reader, api.EventsParams{Since: since, Limit: 2000})
+		return dashBackfillMsg{events: events, err: err}
+	}
+}
+
+func (m model) dashSubscribe() tea.Cmd {
+	return func() tea.Msg {
+		sub, err := m.client.Subscribe(context.Background(), nil)
+		return dashSubMsg{sub: sub, err: err}
+	}
+}
+
+func dashNext(sub *api.Subscription) tea.Cmd {
+	return func() tea.Msg {
+		p, err := sub.Next(context.Background())
+		if err != nil {
+			return dashEventMsg{sub: sub, err: err}
+		}
+		return dashEventMsg{sub: sub, event: p.Event()}
+	}
+}
+
+// updateDashboard handles the dashboard's messages. Messages that arrive
+// after leaving the dashboard are dropped.
+func (m model) updateDashboard(msg tea.Msg) (model, tea.Cmd) {
+	active := m.state == stateDashboard
+	switch msg := msg.(type) {
+	case dashStatsMsg:
+		if active {
+			m.dashErr = msg.err
+			if msg.err == nil {
+				m.dash.Stats = msg.stats
+				m.dash.ActiveSessions = msg.sessions
+				m.dash.Updated = time.Now()
+			}
+		}
+	case dashBackfillMsg:
+		if active && msg.err == nil {
+			now := time.Now()
+			for _, e := range msg.events {
+				m.dash.Add(e, now)
+			}
+			m.dash.Prune(now)
+		}
+	case dashSubMsg:
+		m.dashDialing = false
+		if !active {
+			if msg.sub != nil {
+				msg.sub.Close()
+			}
+			return m, nil
+		}
+		m.dashLiveErr = msg.err
+		if msg.err == nil {
+			m.dashSub = msg.sub
+			return m, dashNext(msg.sub)
+		}
+	case dashEventMsg:
+		if !active || msg.sub != m.dashSub {
+			return m, nil
+		}
+		if msg.err != nil {
+			// Reconnect on the next tick.
+			m.dashLiveErr = msg.err
+			m.closeDashboard()
+			return m, nil
+		}
+		m.dash.Add(msg.event, time.Now())
+		return m, dashNext(msg.sub)
+	case dashTickMsg:
+		if !active {
+			return m, nil
+		}
+		m.dash.Prune(time.Time(msg))
+		cmds := []tea.Cmd{m.fetchDashStats(), dashTick()}
+		if m.dashSub == nil && !m.dashDialing {
+			m.dashDialing = true
+			cmds = append(cmds, m.dashSubscribe())
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+func (m model) handleDashboard(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "esc":
+		m.closeDashboard()
+		m.state = stateMenu
+		return m, nil
+	case "q":
+		m.closeDashboard()
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+var panelStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("8")).
+	Padding(0, 1)
+
+// panel renders a titled box whose outer width is width.
+func panel(title, body string, width int) string {
+	return panelStyle.Width(width - 2).Render(ui.HeaderStyle.Render(title) + "\n" + body)
+}
+
+func (m model) viewDashboard() string {
+	d := m.dash
+	now := time.Now()
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+
+	// Wide terminals get event types on the left and the smaller panels
+	// stacked on the right; narrow ones stack everything.
+	wide := width >= 100
+	leftW, rightW := width, width
+	if wide {
+		leftW = width * 3 / 5
+		rightW = width - leftW
+	}
+
+	var types strings.Builder
+	sparkW := max(leftW-4-24-7-1, 10)
+	list := d.Types()
+	if len(list) == 0 {
+		types.WriteString(ui.DimStyle.Render("No events in the last hour."))
+	}
+	for i, t := range list[:min(len(list), 12)] {
+		if i > 0 {
+			types.WriteString("\n")
+		}
+		line := dashboard.Sparkline(d.Series(t, sparkW, now))
+		types.WriteString(fmt.Sprintf("%-24s %6d %s", truncate(t, 24), d.Stats[t], ui.ActiveStyle.Render(line)))
+	}
+	typesPanel := panel("Events (last hour)", types.String(), leftW)
+
+	gaugeW := max(rightW-4-12, 10)
+	logins := ui.DimStyle.Render("No logins.")
+	if ratio, ok := d.LoginRatio(); ok {
+		style := ui.SuccessStyle
+		if ratio < 0.5 {
+			style = ui.ErrorStyle
+		}
+		logins = style.Render(dashboard.Gauge(ratio, gaugeW)) + fmt.Sprintf(" %3.0f%% ok\n%d success, %d failure",
+			ratio*100, d.Stats["login.success"], d.Stats["login.failure"])
+	}
+	sessions := fmt.Sprintf("%d active", d.ActiveSessions)
+	challenges := fmt.Sprintf("%.1f/min issued, %d failed\n%s", d.ChallengeRate(), d.Stats["challenge.failed"],
+		ui.ActiveStyle.Render(dashboard.Sparkline(d.Series("challenge.issued", max(rightW-4, 10), now))))
+
+	var ips strings.Builder
+	top := d.TopIPs(5)
+	if len(top) == 0 {
+		ips.WriteString(ui.DimStyle.Render("No traffic."))
+	}
+	for i, r := range top {
+		if i > 0 {
+			ips.WriteString("\n")
+		}
+		ips.WriteString(fmt.Sprintf("%-*s %5d", max(rightW-4-6, 10), truncate(r.Key, max(rightW-4-6, 10)), r.Count))
+	}
+
+	side := []string{
+		panel("Logins", logins, rightW),
+		panel("Sessions", sessions, rightW),
+		panel("PoW challenges", challenges, rightW),
+		panel("Top IPs", ips.String(), rightW),
+	}
+	var body string
+	if wide {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, typesPanel, lipgloss.JoinVertical(lipgloss.Left, side...))
+	} else {
+		body = lipgloss.JoinVertical(lipgloss.Left, append([]string{typesPanel}, side...)...)
+	}
+
+	status := ui.SuccessStyle.Render("● live")
+	switch {
+	case m.dashSub == nil && m.dashLiveErr != nil:
+		status = ui.ErrorStyle.Render(fmt.Sprintf("○ live feed down: %v (retrying)", m.dashLiveErr))
+	case m.dashSub == nil:
+		status = ui.DimStyle.Render("○ connecting")
+	}
+	if m.dashErr != nil {
+		status += "  " + ui.ErrorStyle.Render(fmt.Sprintf("refresh failed: %v", m.dashErr))
+	}
+	updated := "-"
+	if !d.Updated.IsZero() {
+		updated = d.Updated.Format(time.TimeOnly)
+	}
+	return body + "\n" + status + ui.DimStyle.Render(fmt.Sprintf("  updated %s • esc back • q quit", updated))
+}
+
+// truncate shortens s to n runes with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
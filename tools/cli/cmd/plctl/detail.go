@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/jsontree"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// pivotFields are the top-level detail keys that can be pivoted on to list
+// every recent event carrying the same value.
+var pivotFields = []string{"email", "reason", "origin", "difficulty", "prefix"}
+
+// pivotWindow is how far back a pivot searches, reading at most pivotScan
+// events.
+const (
+	pivotWindow = 7 * 24 * time.Hour
+	pivotScan   = 2000
+)
+
+// openDetail shows e, returning to from on esc.
+func (m *model) openDetail(e api.Event, target string, from state) {
+	m.detail = e
+	m.detailTarget = target
+	m.detailFrom = from
+	m.detailTree = nil
+	if e.Detail != nil {
+		m.detailTree = jsontree.ParseOrText(*e.Detail)
+	}
+	m.detailCursor, m.detailTop = 0, 0
+	m.detailNote = ""
+	m.state = stateEventDetail
+}
+
+func (m model) detailLines() []jsontree.Line {
+	if m.detailTree == nil {
+		return nil
+	}
+	return m.detailTree.Lines()
+}
+
+// detailRows is the number of tree lines that fit below the event fields.
+func (m model) detailRows() int {
+	if m.height <= 0 {
+		return 12
+	}
+	return max(m.height-16, 5)
+}
+
+func (m *model) moveDetail(to int) {
+	n := len(m.detailLines())
+	m.detailCursor = max(min(to, n-1), 0)
+	rows := m.detailRows()
+	if m.detailCursor < m.detailTop {
+		m.detailTop = m.detailCursor
+	}
+	if m.detailCursor >= m.detailTop+rows {
+		m.detailTop = m.detailCursor - rows + 1
+	}
+	m.detailTop = max(min(m.detailTop, n-rows), 0)
+}
+
+// pivotable returns the field and value under the cursor when it is one of
+// pivotFields at the top level.
+func (m model) pivotable() (string, string, bool) {
+	lines := m.detailLines()
+	if m.detailCursor >= len(lines) {
+		return "", "", false
+	}
+	l := lines[m.detailCursor]
+	if l.Depth != 0 || l.Node.Container() || !slices.Contains(pivotFields, l.Node.Key) {
+		return "", "", false
+	}
+	return l.Node.Key, l.Node.Value, true
+}
+
+func (m model) handleEventDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	lines := m.detailLines()
+	var node *jsontree.Node
+	if m.detailCursor < len(lines) {
+		node = lines[m.detailCursor].Node
+	}
+	m.detailNote = ""
+
+	switch msg.String() {
+	case "esc":
+		m.state = m.detailFrom
+	case "q":
+		m.closeTail()
+		m.quitting = true
+		return m, tea.Quit
+	case "up", "k":
+		m.moveDetail(m.detailCursor - 1)
+	case "down", "j":
+		m.moveDetail(m.detailCursor + 1)
+	case "pgup", "b":
+		m.moveDetail(m.detailCursor - m.detailRows())
+	case "pgdown", "f":
+		m.moveDetail(m.detailCursor + m.detailRows())
+	case "home", "g":
+		m.moveDetail(0)
+	case "end", "G":
+		m.moveDetail(len(lines) - 1)
+	case "enter", " ":
+		if node != nil && node.Container() {
+			node.Collapsed = !node.Collapsed
+			m.moveDetail(m.detailCursor)
+		}
+	case "right", "l":
+		if node != nil && node.Container() {
+			node.Collapsed = false
+		}
+	case "left", "h":
+		if node == nil {
+			break
+		}
+		if node.Container() && !node.Collapsed {
+			node.Collapsed = true
+			m.moveDetail(m.detailCursor)
+			break
+		}
+		// Jump to the parent.
+		depth := lines[m.detailCursor].Depth
+		for i := m.detailCursor - 1; i >= 0; i-- {
+			if lines[i].Depth < depth {
+				m.moveDetail(i)
+				break
+			}
+		}
+	case "c":
+		if node != nil {
+			m.detailNote = strings.TrimSpace(node.Key+" value") + " sent to clipboard (OSC 52)"
+			return m, copyToClipboard(node.Text())
+		}
+	case "C":
+		data, _ := json.Marshal(m.detail)
+		m.detailNote = "Event sent to clipboard (OSC 52)"
+		return m, copyToClipboard(string(data))
+	case "p":
+		if key, value, ok := m.pivotable(); ok && m.detailFrom != stateTailEvents {
+			m.events = nil
+			m.state = stateEvents
+			return m, m.fetchPivot(key, value)
+		}
+	}
+	return m, nil
+}
+
+// copyToClipboard asks the terminal to set its clipboard with OSC 52, which
+// also works over SSH when the local terminal allows it. There is no reply,
+// so it can't tell whether the terminal honoured it. The sequence goes out
+// through the program's renderer rather than straight to stdout, so it can't
+// interleave with a frame being drawn.
+func copyToClipboard(s string) tea.Cmd {
+	seq := osc52.New(s)
+	if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	return tea.Printf("%s", seq)
+}
+
+// fetchPivot lists recent events from the same target whose detail has
+// key set to value, noting when the scan stopped short of pivotWindow.
+func (m model) fetchPivot(key, value string) tea.Cmd {
+	r := m.readerFor(m.detailTarget)
+	target := m.detailTarget
+	return func() tea.Msg {
+		since := time.Now().UTC().Add(-pivotWindow).Format(time.RFC3339)
+		all, err := api.AllEvents(context.Background(), r, api.EventsParams{Since: since, Limit: pivotScan})
+		pivot := fmt.Sprintf("pivot %s = %s", key, value)
+		if err != nil {
+			return eventsMsg{err: err, filter: pivot}
+		}
+		var events []api.Event
+		var targets []string
+		for _, e := range all {
+			if e.Detail == nil {
+				continue
+			}
+			if v, ok := jsontree.ParseOrText(*e.Detail).Field(key); ok && v == value {
+				events = append(events, e)
+				if target != "" {
+					targets = append(targets, target)
+				}
+			}
+		}
+		msg := eventsMsg{events: events, targets: targets, filter: pivot, scan: pivotScan}
+		if len(all) >= pivotScan {
+			msg.partial = []string{target}
+		}
+		return msg
+	}
+}
+
+// readerFor returns the reader for a fleet target, or the model's reader
+// outside fleet mode.
+func (m model) readerFor(target string) api.Reader {
+	for _, t := range m.targets {
+		if t.Name == target {
+			return t.Client
+		}
+	}
+	return m.reader
+}
+
+func (m model) viewEventDetail() string {
+	var b strings.Builder
+	b.WriteString(renderEventFields(m.detail, m.detailTarget))
+
+	lines := m.detailLines()
+	if len(lines) == 0 {
+		b.WriteString(fmt.Sprintf("  %s  -\n", ui.HeaderStyle.Render(fmt.Sprintf("%-10s", "Detail"))))
+	} else {
+		b.WriteString(fmt.Sprintf("  %s\n", ui.HeaderStyle.Render("Detail")))
+		width := 0
+		if m.width > 0 {
+			width = m.width - 6
+		}
+		end := min(m.detailTop+m.detailRows(), len(lines))
+		for i := m.detailTop; i < end; i++ {
+			marker := "  "
+			if i == m.detailCursor {
+				marker = ui.PromptStyle.Render("▸ ")
+			}
+			b.WriteString("  " + marker + lines[i].Render(width) + "\n")
+		}
+		if end < len(lines) {
+			b.WriteString(ui.DimStyle.Render(fmt.Sprintf("    ↓ %d more\n", len(lines)-end)))
+		}
+	}
+
+	if m.detailNote != "" {
+		b.WriteString("\n" + ui.SuccessStyle.Render(m.detailNote))
+	}
+	help := "\n↑/↓ move • enter fold • c copy value • C copy event"
+	if key, _, ok := m.pivotable(); ok && m.detailFrom != stateTailEvents {
+		help += " • p pivot on " + key
+	}
+	b.WriteString(ui.DimStyle.Render(help + " • esc back • q quit"))
+	return b.String()
+}
+
+// renderEventFields lays out an event's fixed fields; target is shown when
+// non-empty.
+func renderEventFields(e api.Event, target string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Event #%d\n\n", e.ID))
+
+	userID := "-"
+	if e.UserID != nil {
+		userID = fmt.Sprintf("%d", *e.UserID)
+	}
+	labels := []string{"Type", "IP", "User", "Actor", "Time"}
+	values := []string{e.Type, e.IPAddress, userID, e.ActorID, e.CreatedAt}
+	if target != "" {
+		labels = append([]string{"Target"}, labels...)
+		values = append([]string{target}, values...)
+	}
+	for i, label := range labels {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", ui.HeaderStyle.Render(fmt.Sprintf("%-10s", label)), values[i]))
+	}
+	return b.String()
+}
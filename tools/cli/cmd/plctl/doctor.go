@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/doctor"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// runWhoami negotiates every known capability and prints the identity the
+// server sees for the configured key.
+func runWhoami(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("whoami", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the identity as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	d := &doctor.Doctor{Client: client, Target: apiURL}
+	r, err := d.Identify(ctx)
+	if err != nil {
+		printChecks(r.Checks)
+		return err
+	}
+	if *asJSON {
+		return printJSON(r.Identity)
+	}
+	printIdentity(r)
+	return nil
+}
+
+// runDoctor checks the configured key end to end and exits non-zero if any
+// check fails.
+func runDoctor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	heartbeat := fs.Duration("heartbeat", 30*time.Second, "wait this long for a server heartbeat to measure clock skew (0 uses the HTTP Date header)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	if *heartbeat > 0 && !*asJSON {
+		fmt.Fprintln(os.Stderr, ui.DimStyle.Render(fmt.Sprintf("Waiting up to %s for a server heartbeat...", *heartbeat)))
+	}
+	d := &doctor.Doctor{Client: client, Target: apiURL, Heartbeat: *heartbeat}
+	r := d.Run(ctx)
+
+	if *asJSON {
+		if err := printJSON(r); err != nil {
+			return err
+		}
+	} else {
+		printChecks(r.Checks)
+		if r.Identity != nil {
+			fmt.Println()
+			printIdentity(r)
+		}
+		if r.TLS != nil {
+			fmt.Printf("  %-12s %s (issuer %s, expires %s)\n", "Certificate", r.TLS.Subject, r.TLS.Issuer, r.TLS.NotAfter.Format(time.DateOnly))
+		}
+	}
+	if r.Failed() {
+		return fmt.Errorf("%s: checks failed", apiURL)
+	}
+	return nil
+}
+
+func printChecks(checks []doctor.Check) {
+	for _, c := range checks {
+		mark := ui.SuccessStyle.Render("✓")
+		switch c.Status {
+		case doctor.StatusWarn:
+			mark = ui.PromptStyle.Render("!")
+		case doctor.StatusFail:
+			mark = ui.ErrorStyle.Render("✗")
+		case doctor.StatusSkip:
+			mark = ui.DimStyle.Render("-")
+		}
+		fmt.Printf("  %s %-13s %s\n", mark, c.Name, c.Detail)
+	}
+}
+
+func printIdentity(r *doctor.Report) {
+	id := r.Identity
+	fmt.Printf("  %-12s %s\n", "Agent", id.Agent)
+	fmt.Printf("  %-12s %s\n", "Connection", id.ConnectionID)
+	fmt.Printf("  %-12s %s\n", "Target", r.Target)
+	fmt.Printf("  %-12s %s\n", "Granted", ui.SuccessStyle.Render(strings.Join(id.Granted, ", ")))
+	for i, d := range id.Denied {
+		label := ""
+		if i == 0 {
+			label = "Denied"
+		}
+		fmt.Printf("  %-12s %s %s\n", label, ui.ErrorStyle.Render(d.Capability), ui.DimStyle.Render("("+d.Reason+")"))
+	}
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
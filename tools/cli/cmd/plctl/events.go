@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/compare"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/jsontree"
+	"github.com/private-landing/cli/internal/query"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// runEvents queries events on every selected target. With --contexts the
+// results are merged with a target column; targets that fail are reported
+// on stderr and make the command exit non-zero after printing the rest.
+func runEvents(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: plctl [--contexts a,b] events list [--type t] [--user id] [--ip addr] [--since 24h] [--json] | stats [--since 24h | --compare 24h] [--json] | get <id> [--since 30d] [--json] | query '<expr>' [--since 24h] [--json] | aggregate [--by type,ip] [--interval 1h] [--since 24h] [--csv | --json]")
+	}
+	switch args[0] {
+	case "list":
+		return runEventsList(ctx, args[1:])
+	case "stats":
+		return runEventsStats(ctx, args[1:])
+	case "get":
+		return runEventsGet(ctx, args[1:])
+	case "query":
+		return runEventsQuery(ctx, args[1:])
+	case "aggregate":
+		return runEventsAggregate(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown events subcommand %q", args[0])
+}
+
+func runEventsList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events list", flag.ContinueOnError)
+	var p api.EventsParams
+	fs.StringVar(&p.Type, "type", "", "event type")
+	fs.StringVar(&p.UserID, "user", "", "user `id`")
+	fs.StringVar(&p.IP, "ip", "", "IP `addr`")
+	fs.StringVar(&p.ActorID, "actor", "", "actor ID")
+	since := fs.String("since", "", "timestamp or lookback (7d, 24h); server default 24h")
+	fs.IntVar(&p.Limit, "limit", 50, "events per target (max 200)")
+	asJSON := fs.Bool("json", false, "print one JSON object per line")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *since != "" {
+		var err error
+		if p.Since, err = api.ParseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	results, err := queryTargets(ctx, func(ctx context.Context, r api.Reader) ([]api.Event, error) {
+		resp, err := r.ListEvents(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Events, nil
+	})
+	if err != nil {
+		return err
+	}
+	return printEvents(results, *asJSON)
+}
+
+// runEventsQuery pages events and keeps those matching a query expression,
+// evaluated locally; see internal/query for the language.
+func runEventsQuery(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events query", flag.ContinueOnError)
+	since := fs.String("since", "24h", "timestamp or lookback (7d, 24h)")
+	scan := fs.Int("scan", 10000, "stop after reading this many events per target (0 for no cap)")
+	asJSON := fs.Bool("json", false, "print one JSON object per line")
+	expr, rest := "", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		expr, rest = args[0], args[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if expr == "" && fs.NArg() == 1 {
+		expr = fs.Arg(0)
+	}
+	if expr == "" {
+		return fmt.Errorf("usage: plctl events query '<expr>' [--since 24h] [--scan 10000] [--json]\nfields: %s\nfunctions: %s",
+			strings.Join(query.Fields(), ", "), strings.Join(query.Functions(), ", "))
+	}
+	q, err := query.Parse(expr)
+	var qe *query.Error
+	if errors.As(err, &qe) {
+		return errors.New("query:\n" + qe.Caret(expr))
+	}
+	if err != nil {
+		return err
+	}
+	sinceStr, err := api.ParseSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	results, err := queryTargets(ctx, collect(q, sinceStr, *scan))
+	if err != nil {
+		return err
+	}
+	events, partial := splitCollected(results)
+	for _, t := range partial {
+		fmt.Fprintf(os.Stderr, "%s: stopped after %d events; results are partial (raise --scan)\n", t, *scan)
+	}
+	return printEvents(events, *asJSON)
+}
+
+// collected is one target's query matches, and whether its scan stopped
+// at the cap before reaching since.
+type collected struct {
+	events  []api.Event
+	partial bool
+}
+
+// collect returns a per-target func running q.Collect.
+func collect(q *query.Query, since string, scan int) func(context.Context, api.Reader) (collected, error) {
+	return func(ctx context.Context, r api.Reader) (collected, error) {
+		events, partial, err := q.Collect(ctx, r, since, scan)
+		return collected{events, partial}, err
+	}
+}
+
+// splitCollected separates per-target matches from the targets whose scan
+// was cut short.
+func splitCollected(results []fleet.Result[collected]) (events []fleet.Result[[]api.Event], partial []string) {
+	events = make([]fleet.Result[[]api.Event], len(results))
+	for i, r := range results {
+		events[i] = fleet.Result[[]api.Event]{Target: r.Target, Value: r.Value.events, Err: r.Err}
+		if r.Err == nil && r.Value.partial {
+			partial = append(partial, r.Target)
+		}
+	}
+	return events, partial
+}
+
+// printEvents merges per-target events and prints them as a table, or as
+// JSON lines with a target field.
+func printEvents(results []fleet.Result[[]api.Event], asJSON bool) error {
+	rows, failed := fleet.MergeEvents(results)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range rows {
+			if err := enc.Encode(struct {
+				Target string `json:"target"`
+				api.Event
+			}{r.Target, r.Item}); err != nil {
+				return err
+			}
+		}
+		return reportFailures(failed, len(results))
+	}
+
+	events, names := split(rows)
+	if len(results) == 1 {
+		names = nil
+	}
+	columns := []ui.Column{
+		{Header: "ID", Width: 6},
+		{Header: "Type", Width: 24},
+		{Header: "IP", Width: 16},
+		{Header: "User", Width: 8},
+		{Header: "Time", Width: 20},
+	}
+	table := make([][]string, len(events))
+	for i, e := range events {
+		userID := "-"
+		if e.UserID != nil {
+			userID = fmt.Sprintf("%d", *e.UserID)
+		}
+		table[i] = []string{fmt.Sprintf("%d", e.ID), e.Type, e.IPAddress, userID, e.CreatedAt}
+	}
+	columns, table = withTarget(columns, table, names)
+	if len(table) > 0 {
+		fmt.Print(ui.RenderTable(columns, table))
+	}
+	return reportFailures(failed, len(results))
+}
+
+func runEventsStats(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events stats", flag.ContinueOnError)
+	since := fs.String("since", "", "timestamp or lookback (7d, 24h); server default 24h")
+	window := fs.String("compare", "", "compare the last `window` (24h, 7d) with the windows before it")
+	o := compare.Options{}
+	fs.IntVar(&o.History, "history", 7, "earlier windows forming the --compare baseline")
+	fs.Float64Var(&o.Z, "z", 3, "flag --compare changes whose z-score reaches this")
+	fs.IntVar(&o.Min, "min", 5, "don't flag types with fewer events than this")
+	asJSON := fs.Bool("json", false, "print merged counts as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *window != "" {
+		if *since != "" {
+			return errors.New("--compare sets the window; drop --since")
+		}
+		var err error
+		if o.Window, err = api.ParseDuration(*window); err != nil || o.Window <= 0 {
+			return fmt.Errorf("invalid --compare %q: want a duration (24h, 7d)", *window)
+		}
+		if o.History < 1 {
+			return errors.New("--history must be at least 1")
+		}
+		return runEventsCompare(ctx, o, *asJSON)
+	}
+	var sinceParam string
+	if *since != "" {
+		var err error
+		if sinceParam, err = api.ParseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	results, err := queryTargets(ctx, func(ctx context.Context, r api.Reader) (*api.EventStatsResponse, error) {
+		return r.GetEventStats(ctx, sinceParam)
+	})
+	if err != nil {
+		return err
+	}
+	stats, failed := fleet.MergeStats(results)
+
+	if *asJSON {
+		type row struct {
+			Type    string         `json:"type"`
+			Targets map[string]int `json:"targets"`
+			Total   int            `json:"total"`
+			Spread  int            `json:"spread"`
+		}
+		out := make([]row, len(stats.Types))
+		for i, typ := range stats.Types {
+			out[i] = row{typ, stats.Counts[typ], stats.Total(typ), stats.Spread(typ)}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+		return reportFailures(failed, len(results))
+	}
+
+	if len(stats.Types) > 0 {
+		fmt.Print(renderFleetStats(stats, nil))
+	}
+	return reportFailures(failed, len(results))
+}
+
+// reportFailures prints per-target failures to stderr and returns an error
+// if any target failed.
+func reportFailures(failed []fleet.Failure, targets int) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	fmt.Fprint(os.Stderr, renderFailures(failed))
+	return fmt.Errorf("%d of %d target(s) failed", len(failed), targets)
+}
+
+// runEventsGet prints one event with its detail rendered by the same
+// pretty printer as the TUI detail pane.
+func runEventsGet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events get", flag.ContinueOnError)
+	since := fs.String("since", "30d", "how far back to search (timestamp or lookback)")
+	asJSON := fs.Bool("json", false, "print the event as JSON")
+	id, rest := "", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, rest = args[0], args[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if id == "" && fs.NArg() == 1 {
+		id = fs.Arg(0)
+	}
+	eventID, err := strconv.Atoi(id)
+	if err != nil {
+		return errors.New("usage: plctl events get <id> [--since 30d] [--json]")
+	}
+	sinceStr, err := api.ParseSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	r, err := resolveReader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	e, err := api.FindEvent(ctx, r, eventID, sinceStr)
+	if errors.Is(err, api.ErrEventNotFound) {
+		return fmt.Errorf("event %d not found since %s (widen --since)", eventID, sinceStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(e)
+	}
+	fmt.Print(renderEventFields(*e, ""))
+	if e.Detail == nil {
+		fmt.Printf("  %s  -\n", ui.HeaderStyle.Render(fmt.Sprintf("%-10s", "Detail")))
+		return nil
+	}
+	fmt.Printf("  %s\n", ui.HeaderStyle.Render("Detail"))
+	for _, line := range strings.Split(strings.TrimSuffix(jsontree.Render(jsontree.ParseOrText(*e.Detail), 0), "\n"), "\n") {
+		fmt.Println("    " + line)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/evidence"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+func runEvidence(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: plctl evidence collect [--user id] [--ip addr] [--since 7d] [-o file] [--sign-key key.pem] | verify <file> [--pub key.pem] | open <file>")
+	}
+	switch args[0] {
+	case "collect":
+		return runEvidenceCollect(ctx, args[1:])
+	case "verify":
+		return runEvidenceVerify(args[1:])
+	case "open":
+		if len(args) != 2 {
+			return errors.New("usage: plctl evidence open <file>")
+		}
+		b, err := evidence.Open(args[1])
+		if err != nil {
+			return err
+		}
+		p := tea.NewProgram(readOnlyModel(b, fmt.Sprintf("%s (%s, collected %s)", args[1], b.Manifest.Target, b.Manifest.CreatedAt)))
+		_, err = p.Run()
+		return err
+	}
+	return fmt.Errorf("unknown evidence subcommand %q", args[0])
+}
+
+func runEvidenceCollect(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("evidence collect", flag.ContinueOnError)
+	var q evidence.Query
+	fs.StringVar(&q.UserID, "user", "", "collect events and sessions for user `id`")
+	fs.StringVar(&q.IP, "ip", "", "collect events and sessions from `addr`")
+	fs.StringVar(&q.Type, "type", "", "restrict events to one type")
+	fs.StringVar(&q.ActorID, "actor", "", "restrict events to one actor ID")
+	fs.StringVar(&q.Since, "since", "24h", "timestamp or lookback (7d, 24h)")
+	out := fs.String("o", "", "output `file` (default evidence-<timestamp>.tar.gz)")
+	signKey := fs.String("sign-key", "", "sign the manifest with a PKCS#8 PEM ed25519 `key`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	var key ed25519.PrivateKey
+	if *signKey != "" {
+		var err error
+		if key, err = evidence.LoadSigningKey(*signKey); err != nil {
+			return err
+		}
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("evidence-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	c := &evidence.Collector{
+		Ops:         client,
+		Version:     version,
+		Target:      apiURL,
+		Environment: os.Getenv("ENVIRONMENT"),
+	}
+	b, err := c.Collect(ctx, q)
+	if err != nil {
+		return err
+	}
+	if err := evidence.WriteFile(*out, b, key); err != nil {
+		return err
+	}
+
+	signed := "unsigned"
+	if key != nil {
+		signed = "signed"
+	}
+	fmt.Fprintln(os.Stderr, ui.SuccessStyle.Render(fmt.Sprintf("Wrote %s (%s)", *out, signed)))
+	fmt.Fprintln(os.Stderr, ui.DimStyle.Render(fmt.Sprintf("%d event(s), %d session(s) since %s", len(b.Events), len(b.Sessions), b.Manifest.Query.Since)))
+	return nil
+}
+
+func runEvidenceVerify(args []string) error {
+	fs := flag.NewFlagSet("evidence verify", flag.ContinueOnError)
+	pubPath := fs.String("pub", "", "require a signature from this PEM ed25519 public `key`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: plctl evidence verify <file> [--pub key.pem]")
+	}
+
+	b, err := evidence.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	m := b.Manifest
+	fmt.Println(ui.TitleStyle.Render(fs.Arg(0)))
+	fmt.Printf("  Collected  %s by plctl %s\n", m.CreatedAt, m.Version)
+	fmt.Printf("  Target     %s %s\n", m.Target, ui.DimStyle.Render(m.Environment))
+	fmt.Printf("  Window     %s to %s\n", m.Query.Since, m.Query.Until)
+	for _, f := range m.Files {
+		fmt.Printf("  %-14s %s %s\n", f.Name, ui.DimStyle.Render(f.SHA256), ui.SuccessStyle.Render("ok"))
+	}
+
+	switch {
+	case *pubPath != "":
+		pub, err := evidence.LoadPublicKey(*pubPath)
+		if err != nil {
+			return err
+		}
+		if !b.SignedBy(pub) {
+			return errors.New("bundle is not signed by the given key")
+		}
+		fmt.Println(ui.SuccessStyle.Render("Signature verified against " + *pubPath))
+	case b.Signature != nil:
+		fmt.Println(ui.SuccessStyle.Render("Signature valid (key " + b.Signature.PublicKey + ")"))
+	default:
+		fmt.Println(ui.DimStyle.Render("Bundle is unsigned; digests only"))
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/compare"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// fleetModel browses several targets at once. Each view merges the targets'
+// results with a target column; mutating actions are removed as in
+// read-only mode.
+func fleetModel(targets []fleet.Target) model {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	m := readOnlyModel(nil, "fleet "+strings.Join(names, ", "))
+	m.targets = targets
+	return m
+}
+
+// split separates merged rows into items and their parallel target names.
+func split[T any](rows []fleet.Row[T]) ([]T, []string) {
+	items := make([]T, len(rows))
+	targets := make([]string, len(rows))
+	for i, r := range rows {
+		items[i], targets[i] = r.Item, r.Target
+	}
+	return items, targets
+}
+
+// allFailed reports whether every target failed, returning a combined error.
+func allFailed(failed []fleet.Failure, targets int) error {
+	if len(failed) == 0 || len(failed) < targets {
+		return nil
+	}
+	msgs := make([]string, len(failed))
+	for i, f := range failed {
+		msgs[i] = fmt.Sprintf("%s: %v", f.Target, f.Err)
+	}
+	return fmt.Errorf("all targets failed: %s", strings.Join(msgs, "; "))
+}
+
+func (m model) fetchFleetSessions(userID string) tea.Cmd {
+	return func() tea.Msg {
+		results := doTargets(context.Background(), m.targets, func(ctx context.Context, r api.Reader) ([]api.Session, error) {
+			resp, err := r.ListSessions(ctx, api.SessionsParams{UserID: userID})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Sessions, nil
+		})
+		rows, failed := fleet.Merge(results)
+		if err := allFailed(failed, len(m.targets)); err != nil {
+			return sessionsMsg{err: err}
+		}
+		sessions, targets := split(rows)
+		return sessionsMsg{sessions: sessions, targets: targets, failed: failed}
+	}
+}
+
+func (m model) fetchFleetEvents(userID string) tea.Cmd {
+	return func() tea.Msg {
+		results := doTargets(context.Background(), m.targets, func(ctx context.Context, r api.Reader) ([]api.Event, error) {
+			resp, err := r.ListEvents(ctx, api.EventsParams{UserID: userID})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Events, nil
+		})
+		rows, failed := fleet.MergeEvents(results)
+		if err := allFailed(failed, len(m.targets)); err != nil {
+			return eventsMsg{err: err}
+		}
+		events, targets := split(rows)
+		return eventsMsg{events: events, targets: targets, failed: failed}
+	}
+}
+
+func (m model) fetchFleetAgents() tea.Cmd {
+	return func() tea.Msg {
+		results := doTargets(context.Background(), m.targets, func(ctx context.Context, r api.Reader) ([]api.Agent, error) {
+			resp, err := r.ListAgents(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Agents, nil
+		})
+		rows, failed := fleet.Merge(results)
+		if err := allFailed(failed, len(m.targets)); err != nil {
+			return agentsMsg{err: err}
+		}
+		agents, targets := split(rows)
+		return agentsMsg{agents: agents, targets: targets, failed: failed}
+	}
+}
+
+// withTarget prepends a Target column when rows come from several targets.
+func withTarget(columns []ui.Column, rows [][]string, targets []string) ([]ui.Column, [][]string) {
+	if targets == nil {
+		return columns, rows
+	}
+	columns = append([]ui.Column{{Header: "Target", Width: 12}}, columns...)
+	for i := range rows {
+		rows[i] = append([]string{targets[i]}, rows[i]...)
+	}
+	return columns, rows
+}
+
+// renderFleetStats renders per-target counts with a total and the spread
+// between the highest and lowest target. Types flagged by a comparison are
+// colored: spikes red, drops highlighted.
+func renderFleetStats(s *fleet.Stats, flags map[string]compare.Flag) string {
+	columns := []ui.Column{{Header: "Event Type", Width: 30}}
+	for _, t := range s.Targets {
+		columns = append(columns, ui.Column{Header: t, Width: max(len(t), 8)})
+	}
+	columns = append(columns, ui.Column{Header: "Total", Width: 8}, ui.Column{Header: "Δ", Width: 8})
+
+	rows := make([][]string, len(s.Types))
+	for i, typ := range s.Types {
+		row := []string{typ}
+		for _, t := range s.Targets {
+			row = append(row, fmt.Sprintf("%d", s.Counts[typ][t]))
+		}
+		rows[i] = append(row, fmt.Sprintf("%d", s.Total(typ)), fmt.Sprintf("%d", s.Spread(typ)))
+	}
+	lines := strings.Split(ui.RenderTable(columns, rows), "\n")
+	for i, typ := range s.Types {
+		switch flags[typ] {
+		case compare.Spike:
+			lines[i+2] = ui.ErrorStyle.Render(lines[i+2])
+		case compare.Drop:
+			lines[i+2] = ui.PromptStyle.Render(lines[i+2])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderFailures lists targets that did not answer.
+func renderFailures(failed []fleet.Failure) string {
+	var b strings.Builder
+	for _, f := range failed {
+		b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("%s: %v", f.Target, f.Err)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
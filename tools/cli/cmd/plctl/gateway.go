@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/gateway"
+)
+
+// runGateway holds one upstream subscription for all event types and fans it
+// out to local clients, so many tails share a single server connection and
+// its rate limits.
+func runGateway(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gateway", flag.ContinueOnError)
+	listen := fs.String("listen", "unix://"+filepath.Join(os.TempDir(), "plctl.sock"), "listen `address`: unix:///path, tcp://host:port or host:port (loopback only without --allow-remote)")
+	allowRemote := fs.Bool("allow-remote", false, "allow a non-loopback TCP --listen address; the gateway has no authentication, so serve remote consumers with plctl bridge")
+	origins := fs.String("origin", "", "comma-separated browser origin `patterns` allowed to open WebSockets")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, _, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	ln, err := listenGateway(*listen, *allowRemote)
+	if err != nil {
+		return err
+	}
+
+	var originPatterns []string
+	if *origins != "" {
+		originPatterns = strings.Split(*origins, ",")
+	}
+	hub := gateway.NewHub()
+	srv := &http.Server{Handler: hub.Handler(originPatterns), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	upstream := make(chan error, 1)
+	go func() {
+		err := followEvents(ctx, "gateway", client, nil, nil, streamHandlers{
+			Connect: func(sub *api.Subscription) {
+				hub.SetUpstream(true)
+				fmt.Fprintf(os.Stderr, "gateway: upstream connected as %s\n", sub.Granted.Agent)
+			},
+			Event:      hub.Publish,
+			Disconnect: func(error) { hub.SetUpstream(false) },
+		})
+		upstream <- err
+		cancel()
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdown, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "gateway: listening on %s (GET /events?types=..., /healthz)\n", *listen)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	select {
+	case err := <-upstream:
+		return err
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+// listenGateway opens a gateway listener, allowing non-loopback TCP
+// addresses only when the user passed --allow-remote.
+func listenGateway(addr string, allowRemote bool) (net.Listener, error) {
+	if allowRemote {
+		return gateway.ListenRemote(addr)
+	}
+	return gateway.Listen(addr)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/loadtest"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// runLoadtest drives one abuse scenario against a development target and
+// reports client outcomes next to the ops events they produced. It refuses
+// any target isSafeTarget does not recognise as non-production.
+func runLoadtest(ctx context.Context, args []string) error {
+	usage := "usage: plctl loadtest <" + strings.Join(loadtest.Scenarios, "|") + "> [-n 100] [-c 10] [--ips 50] [--no-pow] [--settle 5s] [--json]"
+	if len(args) == 0 || !slices.Contains(loadtest.Scenarios, args[0]) {
+		return errors.New(usage)
+	}
+	scenario := args[0]
+	fs := flag.NewFlagSet("loadtest "+scenario, flag.ContinueOnError)
+	requests := fs.Int("n", 100, "total attempts")
+	concurrency := fs.Int("c", 10, "attempts in flight at once")
+	ips := fs.Int("ips", 50, "distinct spoofed addresses for spoofed-ips")
+	noPow := fs.Bool("no-pow", false, "leave PoW challenges unsolved")
+	settle := fs.Duration("settle", 5*time.Second, "wait before reading event stats for the report")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *requests < 1 || *concurrency < 1 {
+		return errors.New("loadtest: -n and -c must be positive")
+	}
+
+	apiURL, err := resolveURL()
+	if err != nil {
+		return err
+	}
+	if !isSafeTarget(apiURL) {
+		return fmt.Errorf("loadtest: refusing %s; it does not appear to be non-production (use localhost or set ENVIRONMENT)", apiURL)
+	}
+	// The agent key is only needed for ws-flood and the event correlation.
+	client, _, clientErr := resolveClient()
+	if scenario == loadtest.WSFlood && clientErr != nil {
+		return clientErr
+	}
+
+	cfg := loadtest.Config{
+		Scenario:    scenario,
+		BaseURL:     apiURL,
+		Client:      client,
+		Requests:    *requests,
+		Concurrency: *concurrency,
+		Email:       os.Getenv("PLCTL_PROBE_EMAIL"),
+		Password:    os.Getenv("PLCTL_PROBE_PASSWORD"),
+		IPs:         *ips,
+		NoPow:       *noPow,
+	}
+	if scenario == loadtest.SpoofedIPs {
+		fmt.Fprintln(os.Stderr, "loadtest: spoofed X-Forwarded-For/CF-Connecting-IP are only honored if the dev runtime trusts them")
+	}
+	fmt.Fprintf(os.Stderr, "loadtest: %s × %d at concurrency %d against %s\n", scenario, *requests, *concurrency, apiURL)
+
+	started := time.Now()
+	attempts, err := loadtest.Run(ctx, cfg)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	report := loadtest.Summarize(cfg, started, time.Since(started), attempts)
+
+	var corrErr error
+	if clientErr != nil {
+		corrErr = clientErr
+	} else {
+		select {
+		case <-time.After(*settle):
+		case <-ctx.Done():
+		}
+		corrErr = report.Correlate(context.WithoutCancel(ctx), client.GetEventStats)
+	}
+	if corrErr != nil {
+		fmt.Fprintf(os.Stderr, "loadtest: skipping event correlation: %v\n", corrErr)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Print(renderLoadtest(report))
+	return nil
+}
+
+// renderLoadtest lays out outcomes, the latency histogram and the event
+// correlation.
+func renderLoadtest(r *loadtest.Report) string {
+	var b strings.Builder
+	rate := float64(r.Requests) / max(r.Duration.Seconds(), 0.001)
+	fmt.Fprintf(&b, "%s\n\n", ui.DimStyle.Render(fmt.Sprintf("%s: %d attempts in %s (%.1f/s), concurrency %d, %d challenged, %d solved",
+		r.Scenario, r.Requests, r.Duration.Round(time.Millisecond), rate, r.Concurrency, r.Challenged, r.Solved)))
+
+	var rows [][]string
+	for _, o := range []loadtest.Outcome{loadtest.OK, loadtest.Rejected, loadtest.RateLimited, loadtest.Challenged, loadtest.Failed} {
+		rows = append(rows, []string{string(o), strconv.Itoa(r.Outcomes[o])})
+	}
+	b.WriteString(ui.RenderTable([]ui.Column{{Header: "Outcome", Width: 14}, {Header: "Count", Width: 7}}, rows))
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "%s\n\n", ui.DimStyle.Render(fmt.Sprintf("latency p50 %s  p95 %s  p99 %s  max %s",
+		ms(r.Latency.P50), ms(r.Latency.P95), ms(r.Latency.P99), ms(r.Latency.Max))))
+	peak := 0
+	for _, h := range r.Latency.Histogram {
+		peak = max(peak, h.Count)
+	}
+	rows = rows[:0]
+	for _, h := range r.Latency.Histogram {
+		le := "+Inf"
+		if h.Le > 0 {
+			le = "≤ " + ms(h.Le)
+		}
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("█", h.Count*30/peak)
+		}
+		rows = append(rows, []string{le, strconv.Itoa(h.Count), bar})
+	}
+	b.WriteString(ui.RenderTable([]ui.Column{{Header: "Latency", Width: 10}, {Header: "Count", Width: 7}, {Header: "", Width: 30}}, rows))
+
+	if len(r.Correlation) > 0 {
+		b.WriteString("\n")
+		rows = rows[:0]
+		for _, c := range r.Correlation {
+			server := "?"
+			if c.Checked {
+				server = strconv.Itoa(c.Server)
+			}
+			rows = append(rows, []string{c.Client, strconv.Itoa(c.Count), strings.Join(c.Events, ","), server})
+		}
+		b.WriteString(ui.RenderTable([]ui.Column{
+			{Header: "Client", Width: 20}, {Header: "Count", Width: 7}, {Header: "Events", Width: 22}, {Header: "Server", Width: 7},
+		}, rows))
+		b.WriteString(ui.DimStyle.Render("server counts include any other traffic on the target since the run started") + "\n")
+	}
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\n")
+		for _, msg := range slices.Sorted(maps.Keys(r.Errors)) {
+			fmt.Fprintf(&b, "%s %s\n", ui.ErrorStyle.Render(fmt.Sprintf("%d×", r.Errors[msg])), msg)
+		}
+	}
+	return b.String()
+}
+
+func ms(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
@@ -6,19 +6,30 @@ import (
 	"fmt"
 	"net/url"
 	"os"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/private-landing/cli/internal/agents"
+	"github.com/private-landing/cli/internal/aggregate"
 	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/compare"
+	"github.com/private-landing/cli/internal/dashboard"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/jsontree"
+	"github.com/private-landing/cli/internal/offline"
+	"github.com/private-landing/cli/internal/query"
 	"github.com/private-landing/cli/internal/session"
+	"github.com/private-landing/cli/internal/tail"
 	"github.com/private-landing/cli/internal/ui"
 	"github.com/coder/websocket"
 )
 
+// version is the plctl build, set with -ldflags "-X main.version=...".
+var version = "dev"
+
 // states
 type state int
 
@@ -31,8 +42,11 @@ const (
 	stateEvents
 	stateEventDetail
 	stateEventStats
+	stateHeatmap
 	stateAgents
+	stateAgentDetail
 	stateTailEvents
+	stateDashboard
 )
 
 type action int
@@ -47,8 +61,11 @@ const (
 	// Events
 	actionViewEvents
 	actionViewEventsForUser
+	actionQueryEvents
 	actionViewEventStats
+	actionEventHeatmap
 	actionTailEvents
+	actionDashboard
 	// Agents
 	actionListAgents
 	actionProvisionAgent
@@ -72,8 +89,11 @@ var menuItems = []menuItem{
 	{label: "EVENTS", isHeader: true},
 	{label: "View recent events", action: actionViewEvents},
 	{label: "View events for user", action: actionViewEventsForUser},
+	{label: "Query events", action: actionQueryEvents},
 	{label: "View event stats", action: actionViewEventStats},
+	{label: "Event heatmap", action: actionEventHeatmap},
 	{label: "Tail events (live)", action: actionTailEvents},
+	{label: "Live dashboard", action: actionDashboard},
 
 	{label: "AGENTS", isHeader: true},
 	{label: "List agents", action: actionListAgents},
@@ -81,6 +101,19 @@ var menuItems = []menuItem{
 	{label: "Revoke agent", action: actionRevokeAgent},
 }
 
+// readOnlyActions are the menu actions available when browsing a local
+// source such as an evidence bundle.
+var readOnlyActions = map[action]bool{
+	actionViewSessions:        true,
+	actionViewSessionsForUser: true,
+	actionViewEvents:          true,
+	actionViewEventsForUser:   true,
+	actionQueryEvents:         true,
+	actionViewEventStats:      true,
+	actionEventHeatmap:        true,
+	actionListAgents:          true,
+}
+
 // messages
 type resultMsg struct {
 	message string
@@ -89,23 +122,42 @@ type resultMsg struct {
 
 type sessionsMsg struct {
 	sessions []api.Session
+	targets  []string        // fleet: source target of each row
+	failed   []fleet.Failure // fleet: targets that did not answer
 	err      error
 }
 
 type eventsMsg struct {
-	events []api.Event
-	err    error
+	events  []api.Event
+	targets []string        // fleet: source target of each row
+	failed  []fleet.Failure // fleet: targets that did not answer
+	filter  string          // how the list was narrowed: a pivot or query
+	partial []string        // targets whose scan cap was reached
+	scan    int             // events read per target when partial
+	err     error
 }
 
 type eventStatsMsg struct {
-	stats map[string]int
-	since string
-	err   error
+	stats   map[string]int
+	fleet   *fleet.Stats
+	since   string
+	compare *compare.Comparison     // single target
+	flags   map[string]compare.Flag // fleet: worst flag per type
+	failed  []fleet.Failure
+	err     error
 }
 
 type agentsMsg struct {
-	agents []api.Agent
-	err    error
+	agents  []api.Agent
+	targets []string
+	failed  []fleet.Failure
+	err     error
+}
+
+type agentActivityMsg struct {
+	activity agents.Activity
+	events   []api.Event
+	err      error
 }
 
 type tailChallengeMsg struct {
@@ -128,6 +180,11 @@ type tailErrorMsg struct {
 
 type model struct {
 	client   *api.Client
+	ops      api.Transport // one-shot queries and revocation
+	reader   api.Reader
+	menu     []menuItem
+	source   string         // non-empty in read-only mode
+	targets  []fleet.Target // non-empty in fleet mode
 	state    state
 	cursor   int
 	action   action
@@ -145,34 +202,92 @@ type model struct {
 	resultErr     error
 
 	// data states
-	sessions    []api.Session
-	events      []api.Event
-	eventsTable table.Model
-	eventStats  map[string]int
-	eventSince  string
-	agents      []api.Agent
-	dataErr     error
+	sessions     []api.Session
+	events       []api.Event
+	eventsTable  table.Model
+	eventsFilter string   // pivot or query the list was narrowed by
+	eventsCapped []string // targets whose scan cap was reached
+	eventsScan   int      // the cap eventsCapped reached
+	eventStats   map[string]int
+	eventSince   string
+	statsCompare *compare.Comparison
+	statsFlags   map[string]compare.Flag
+	heatmap      *aggregate.Pivot
+	heatPartial  []string // targets whose scan cap was reached
+	agents       []api.Agent
+	agentsTable  table.Model
+	dataErr      error
+
+	// agent detail state
+	agentActivity *agents.Activity
+	agentEvents   []api.Event
+
+	// fleet mode: source target per row, merged stats, unreachable targets
+	rowTargets []string
+	fleetStats *fleet.Stats
+	failed     []fleet.Failure
 
 	// tail events state
-	tailEvents        []api.Event
+	tailBuf           *tail.Buffer
+	tailCursor        int  // index into tailBuf
+	tailTop           int  // first visible row
+	tailFollow        bool // keep the cursor on the newest event
+	tailSearching     bool // typing a / query
+	tailQuery         session.InputBuffer
+	tailTee           *os.File
+	tailTeePath       string
+	tailTeeErr        error
 	tailFilter        []string // type filters (e.g. "login.*")
+	tailMatch         *query.Query
 	tailErr           error
 	tailConn          *websocket.Conn
 	tailChallenge     *api.ChallengeResult
 	tailKeepaliveStop chan struct{}
+	replay            *replaySession // feeds the tail from a recording instead
+
+	// event detail state
+	detail       api.Event
+	detailTarget string
+	detailFrom   state // view to return to
+	detailTree   *jsontree.Node
+	detailCursor int
+	detailTop    int
+	detailNote   string
+
+	// dashboard state
+	dash        *dashboard.Dashboard
+	dashSub     *api.Subscription
+	dashDialing bool
+	dashErr     error // last stats refresh
+	dashLiveErr error // last subscription failure
 
 	// terminal dimensions
-	width int
+	width  int
+	height int
 }
 
-func initialModel(client *api.Client) model {
-	m := model{client: client, state: stateMenu}
-	m.cursor = firstSelectableIndex()
+func initialModel(client *api.Client, ops api.Transport) model {
+	m := model{client: client, ops: ops, reader: ops, menu: menuItems, state: stateMenu}
+	m.cursor = firstSelectableIndex(m.menu)
 	return m
 }
 
-func firstSelectableIndex() int {
-	for i, item := range menuItems {
+// readOnlyModel browses r without a live client. Mutating actions and the
+// live tail are removed from the menu.
+func readOnlyModel(r api.Reader, source string) model {
+	var menu []menuItem
+	for _, item := range menuItems {
+		if item.isHeader || readOnlyActions[item.action] {
+			menu = append(menu, item)
+		}
+	}
+	m := model{reader: r, menu: menu, source: source, state: stateMenu}
+	m.cursor = firstSelectableIndex(m.menu)
+	return m
+}
+
+func firstSelectableIndex(menu []menuItem) int {
+	for i, item := range menu {
 		if !item.isHeader {
 			return i
 		}
@@ -181,6 +296,9 @@ func firstSelectableIndex() int {
 }
 
 func (m model) Init() tea.Cmd {
+	if m.replay != nil {
+		return m.replay.restart()
+	}
 	return nil
 }
 
@@ -188,9 +306,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
+		m.height = msg.Height
 		return m, nil
 	case tea.KeyMsg:
 		return m.handleKey(msg)
+	case dashStatsMsg, dashBackfillMsg, dashSubMsg, dashEventMsg, dashTickMsg:
+		return m.updateDashboard(msg)
+	case replayTickMsg, replayActionMsg:
+		return m.updateReplay(msg)
 	case resultMsg:
 		m.resultMessage = msg.message
 		m.resultErr = msg.err
@@ -198,27 +321,50 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case sessionsMsg:
 		m.sessions = msg.sessions
+		m.rowTargets, m.failed = msg.targets, msg.failed
 		m.dataErr = msg.err
 		m.state = stateSessions
 		return m, nil
 	case eventsMsg:
 		m.events = msg.events
+		m.eventsFilter = msg.filter
+		m.eventsCapped, m.eventsScan = msg.partial, msg.scan
+		m.rowTargets, m.failed = msg.targets, msg.failed
 		m.dataErr = msg.err
 		m.state = stateEvents
 		if msg.err == nil && len(msg.events) > 0 {
-			m.eventsTable = buildEventsTable(msg.events)
+			m.eventsTable = buildEventsTable(msg.events, msg.targets)
 		}
 		return m, nil
 	case eventStatsMsg:
 		m.eventStats = msg.stats
+		m.statsCompare, m.statsFlags = msg.compare, msg.flags
+		m.fleetStats, m.failed = msg.fleet, msg.failed
 		m.eventSince = msg.since
 		m.dataErr = msg.err
 		m.state = stateEventStats
 		return m, nil
+	case heatmapMsg:
+		m.heatmap = msg.pivot
+		m.heatPartial, m.failed = msg.partial, msg.failed
+		m.eventSince = msg.since
+		m.dataErr = msg.err
+		m.state = stateHeatmap
+		return m, nil
 	case agentsMsg:
 		m.agents = msg.agents
+		m.rowTargets, m.failed = msg.targets, msg.failed
 		m.dataErr = msg.err
 		m.state = stateAgents
+		if msg.err == nil && len(msg.agents) > 0 {
+			m.agentsTable = buildAgentsTable(msg.agents, msg.targets)
+		}
+		return m, nil
+	case agentActivityMsg:
+		m.agentActivity = &msg.activity
+		m.agentEvents = msg.events
+		m.dataErr = msg.err
+		m.state = stateAgentDetail
 		return m, nil
 	case tailChallengeMsg:
 		if msg.err != nil {
@@ -236,15 +382,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.tailConn = msg.conn
 		m.tailKeepaliveStop = make(chan struct{})
-		go keepAlive(m.tailConn, m.tailKeepaliveStop)
+		go api.KeepAlive(m.tailConn, m.tailKeepaliveStop)
 		m.state = stateTailEvents
 		return m, m.readNextEvent()
 	case tailEventMsg:
-		if msg.event.Type != "" {
-			m.tailEvents = append(m.tailEvents, msg.event)
-			if len(m.tailEvents) > 100 {
-				m.tailEvents = m.tailEvents[len(m.tailEvents)-100:]
-			}
+		if msg.event.Type != "" && (m.tailMatch == nil || m.tailMatch.Match(msg.event)) {
+			m.pushTail(msg.event)
 		}
 		return m, m.readNextEvent()
 	case tailErrorMsg:
@@ -274,8 +417,14 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case stateEventDetail:
 		return m.handleEventDetail(msg)
 	case stateTailEvents:
-		return m.handleTailView(key)
-	case stateResult, stateSessions, stateEventStats, stateAgents:
+		return m.handleTailView(msg)
+	case stateDashboard:
+		return m.handleDashboard(key)
+	case stateAgents:
+		return m.handleAgentsView(msg)
+	case stateAgentDetail:
+		return m.handleAgentDetail(key)
+	case stateResult, stateSessions, stateEventStats, stateHeatmap:
 		return m.handleDataView(key)
 	}
 	return m, nil
@@ -288,7 +437,7 @@ func (m model) handleMenu(key string) (tea.Model, tea.Cmd) {
 	case "down", "j":
 		m.cursor = m.nextSelectable(m.cursor)
 	case "enter":
-		item := menuItems[m.cursor]
+		item := m.menu[m.cursor]
 		if item.isHeader {
 			return m, nil
 		}
@@ -303,7 +452,7 @@ func (m model) handleMenu(key string) (tea.Model, tea.Cmd) {
 
 func (m model) prevSelectable(from int) int {
 	for i := from - 1; i >= 0; i-- {
-		if !menuItems[i].isHeader {
+		if !m.menu[i].isHeader {
 			return i
 		}
 	}
@@ -311,8 +460,8 @@ func (m model) prevSelectable(from int) int {
 }
 
 func (m model) nextSelectable(from int) int {
-	for i := from + 1; i < len(menuItems); i++ {
-		if !menuItems[i].isHeader {
+	for i := from + 1; i < len(m.menu); i++ {
+		if !m.menu[i].isHeader {
 			return i
 		}
 	}
@@ -330,20 +479,26 @@ func (m model) dispatchAction() (model, tea.Cmd) {
 		return m, m.fetchEvents("")
 	case actionViewEventStats:
 		m.eventStats = nil
-		return m, m.fetchEventStats()
+		return m, m.fetchComparison()
 	case actionTailEvents:
-		m.tailEvents = nil
+		m.tailBuf = nil
 		m.tailFilter = nil
+		m.tailMatch = nil
 		m.tailErr = nil
 		m.tailConn = nil
 		m.tailChallenge = nil
-		m.startInput([]string{"Type filter (optional)"})
+		m.tailTeePath = ""
+		m.startInput([]string{"Type filter (optional)", "Tee to file (optional)", "Query (optional)"})
 		m.inputHint = "  Examples:  login.*, session.revoke, ws.*\n" +
 			"  Available: login.*, password.*, session.*, agent.*, challenge.*, ws.*, registration.*, rate_limit.*\n" +
-			"  Combine:   login.*,session.revoke"
+			"  Combine:   login.*,session.revoke\n" +
+			"  Tee:       events are appended to the file as JSON lines\n" +
+			"  Query:     evaluated locally on each event, e.g. detail.email endswith \"@corp.com\""
 	case actionListAgents:
 		m.agents = nil
 		return m, m.fetchAgents()
+	case actionDashboard:
+		return m.startDashboard()
 
 	// Single input
 	case actionViewSessionsForUser:
@@ -356,6 +511,12 @@ func (m model) dispatchAction() (model, tea.Cmd) {
 		m.startInput([]string{"Session ID"})
 	case actionViewEventsForUser:
 		m.startInput([]string{"User ID"})
+	case actionEventHeatmap:
+		m.startInput([]string{"Since (optional)"})
+		m.inputHint = "  Timestamp or lookback (default 7d); counts event types by UTC hour of day"
+	case actionQueryEvents:
+		m.startInput([]string{"Query", "Since (optional)"})
+		m.inputHint = queryHint + "\n  Since:     timestamp or lookback (default 24h)"
 	case actionRevokeAgent:
 		m.startInput([]string{"Agent name"})
 
@@ -418,7 +579,44 @@ func (m model) afterInputComplete() (model, tea.Cmd) {
 		m.state = stateEvents
 		m.events = nil
 		return m, m.fetchEvents(m.inputs[0])
+	case actionQueryEvents:
+		q, ok := m.parseQueryInput(0)
+		if !ok {
+			return m, nil
+		}
+		since := m.inputs[1]
+		if since == "" {
+			since = "24h"
+		}
+		sinceStr, err := api.ParseSince(since, time.Now())
+		if err != nil {
+			m.state = stateEvents
+			m.events, m.dataErr = nil, err
+			return m, nil
+		}
+		m.state = stateEvents
+		m.events, m.dataErr = nil, nil
+		return m, m.fetchQuery(q, sinceStr)
+	case actionEventHeatmap:
+		since := m.inputs[0]
+		if since == "" {
+			since = "7d"
+		}
+		m.state = stateHeatmap
+		m.heatmap, m.failed = nil, nil
+		sinceStr, err := api.ParseSince(since, time.Now())
+		if err != nil {
+			m.dataErr = err
+			return m, nil
+		}
+		m.dataErr = nil
+		return m, m.fetchHeatmap(sinceStr)
 	case actionTailEvents:
+		q, ok := m.parseQueryInput(2)
+		if !ok {
+			return m, nil
+		}
+		m.tailMatch = q
 		filter := strings.TrimSpace(m.inputs[0])
 		if filter != "" {
 			m.tailFilter = strings.Split(filter, ",")
@@ -427,6 +625,10 @@ func (m model) afterInputComplete() (model, tea.Cmd) {
 			}
 		}
 		m.state = stateTailEvents
+		if err := m.startTail(m.inputs[1]); err != nil {
+			m.tailErr = err
+			return m, nil
+		}
 		return m, m.probeChallenge()
 	case actionProvisionAgent:
 		m.state = stateConfirm
@@ -465,8 +667,12 @@ func (m model) handleEventsView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 	switch key {
 	case "enter":
-		if len(m.events) > 0 && m.eventsTable.SelectedRow() != nil {
-			m.state = stateEventDetail
+		if idx := m.eventsTable.Cursor(); len(m.events) > 0 && m.eventsTable.SelectedRow() != nil {
+			target := ""
+			if m.rowTargets != nil {
+				target = m.rowTargets[idx]
+			}
+			m.openDetail(m.events[idx], target, stateEvents)
 			return m, nil
 		}
 		m.state = stateMenu
@@ -485,10 +691,36 @@ func (m model) handleEventsView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m model) handleEventDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+func (m model) handleAgentsView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "enter":
+		if len(m.agents) > 0 && m.dataErr == nil {
+			m.agentActivity = nil
+			m.agentEvents = nil
+			m.state = stateAgentDetail
+			return m, m.fetchAgentActivity(m.agentsTable.Cursor())
+		}
+		m.state = stateMenu
+		m.dataErr = nil
+		return m, nil
+	case "esc":
+		m.state = stateMenu
+		m.dataErr = nil
+		return m, nil
+	case "q":
+		m.quitting = true
+		return m, tea.Quit
+	}
+	var cmd tea.Cmd
+	m.agentsTable, cmd = m.agentsTable.Update(msg)
+	return m, cmd
+}
+
+func (m model) handleAgentDetail(key string) (tea.Model, tea.Cmd) {
+	switch key {
 	case "esc", "enter":
-		m.state = stateEvents
+		m.state = stateAgents
+		m.dataErr = nil
 	case "q":
 		m.quitting = true
 		return m, tea.Quit
@@ -496,7 +728,59 @@ func (m model) handleEventDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func buildEventsTable(events []api.Event) table.Model {
+// newTable builds a focused bubbles table in the plctl style.
+func newTable(columns []table.Column, rows []table.Row) table.Model {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("8")).
+		BorderBottom(true).
+		Bold(true).
+		Foreground(lipgloss.Color("5"))
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("2")).
+		Bold(false)
+
+	return table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithHeight(15),
+		table.WithFocused(true),
+		table.WithStyles(s),
+	)
+}
+
+// buildAgentsTable lays out agents; targets, when set, adds the source
+// target of each agent as the first column.
+func buildAgentsTable(list []api.Agent, targets []string) table.Model {
+	columns := []table.Column{
+		{Title: "Name", Width: 20},
+		{Title: "Trust", Width: 8},
+		{Title: "Description", Width: 30},
+		{Title: "Created", Width: 20},
+	}
+	if targets != nil {
+		columns = append([]table.Column{{Title: "Target", Width: 12}}, columns...)
+	}
+
+	rows := make([]table.Row, len(list))
+	for i, a := range list {
+		desc := "-"
+		if a.Description != nil {
+			desc = *a.Description
+		}
+		rows[i] = table.Row{a.Name, a.TrustLevel, desc, a.CreatedAt}
+		if targets != nil {
+			rows[i] = append(table.Row{targets[i]}, rows[i]...)
+		}
+	}
+	return newTable(columns, rows)
+}
+
+// buildEventsTable lays out events; targets, when set, adds the source
+// target of each event as the first column.
+func buildEventsTable(events []api.Event, targets []string) table.Model {
 	columns := []table.Column{
 		{Title: "ID", Width: 6},
 		{Title: "Type", Width: 24},
@@ -505,6 +789,9 @@ func buildEventsTable(events []api.Event) table.Model {
 		{Title: "Actor", Width: 28},
 		{Title: "Time", Width: 20},
 	}
+	if targets != nil {
+		columns = append([]table.Column{{Title: "Target", Width: 12}}, columns...)
+	}
 
 	rows := make([]table.Row, len(events))
 	for i, e := range events {
@@ -520,36 +807,22 @@ func buildEventsTable(events []api.Event) table.Model {
 			e.ActorID,
 			e.CreatedAt,
 		}
+		if targets != nil {
+			rows[i] = append(table.Row{targets[i]}, rows[i]...)
+		}
 	}
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("8")).
-		BorderBottom(true).
-		Bold(true).
-		Foreground(lipgloss.Color("5"))
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("0")).
-		Background(lipgloss.Color("2")).
-		Bold(false)
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithHeight(15),
-		table.WithFocused(true),
-		table.WithStyles(s),
-	)
-
-	return t
+	return newTable(columns, rows)
 }
 
 // --- Commands ---
 
 func (m model) fetchSessions(userID string) tea.Cmd {
+	if m.targets != nil {
+		return m.fetchFleetSessions(userID)
+	}
 	return func() tea.Msg {
-		resp, err := m.client.ListSessions(context.Background(), api.SessionsParams{UserID: userID})
+		resp, err := m.reader.ListSessions(context.Background(), api.SessionsParams{UserID: userID})
 		if err != nil {
 			return sessionsMsg{err: err}
 		}
@@ -558,8 +831,11 @@ func (m model) fetchSessions(userID string) tea.Cmd {
 }
 
 func (m model) fetchEvents(userID string) tea.Cmd {
+	if m.targets != nil {
+		return m.fetchFleetEvents(userID)
+	}
 	return func() tea.Msg {
-		resp, err := m.client.ListEvents(context.Background(), api.EventsParams{UserID: userID})
+		resp, err := m.reader.ListEvents(context.Background(), api.EventsParams{UserID: userID})
 		if err != nil {
 			return eventsMsg{err: err}
 		}
@@ -567,19 +843,34 @@ func (m model) fetchEvents(userID string) tea.Cmd {
 	}
 }
 
-func (m model) fetchEventStats() tea.Cmd {
+// fetchAgentActivity summarizes the selected agent's last 7 days of events.
+func (m model) fetchAgentActivity(idx int) tea.Cmd {
+	name := m.agents[idx].Name
+	r := m.reader
+	if m.targets != nil {
+		r = m.readerFor(m.rowTargets[idx])
+	}
 	return func() tea.Msg {
-		resp, err := m.client.GetEventStats(context.Background(), "")
+		since := time.Now().UTC().Add(-7 * 24 * time.Hour).Format(time.RFC3339)
+		activity, events, err := agents.CollectActivity(context.Background(), r, since, agents.ActorID(name))
 		if err != nil {
-			return eventStatsMsg{err: err}
+			return agentActivityMsg{err: err}
+		}
+		for _, a := range activity {
+			if a.Name == name {
+				return agentActivityMsg{activity: a, events: events}
+			}
 		}
-		return eventStatsMsg{stats: resp.Stats, since: resp.Since}
+		return agentActivityMsg{activity: agents.Activity{Actor: agents.ActorID(name), Name: name}, events: events}
 	}
 }
 
 func (m model) fetchAgents() tea.Cmd {
+	if m.targets != nil {
+		return m.fetchFleetAgents()
+	}
 	return func() tea.Msg {
-		resp, err := m.client.ListAgents(context.Background())
+		resp, err := m.reader.ListAgents(context.Background())
 		if err != nil {
 			return agentsMsg{err: err}
 		}
@@ -604,62 +895,19 @@ func (m model) dialAndSubscribe() tea.Cmd {
 			return tailConnectedMsg{err: err}
 		}
 
-		// Send capability.request
-		capReq := api.WSCapabilitiesRequest{
-			Type:         "capability.request",
-			Capabilities: []string{"subscribe_events"},
-		}
-		data, _ := json.Marshal(capReq)
-		if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
-			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("write capabilities: %w", err)}
-		}
-
-		// Read capability.granted
-		_, capResp, err := conn.Read(ctx)
+		granted, err := api.Negotiate(ctx, conn, []string{"subscribe_events"})
 		if err != nil {
 			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("read capabilities: %w", err)}
-		}
-		var granted api.WSCapabilitiesGranted
-		if err := json.Unmarshal(capResp, &granted); err != nil {
-			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("decode capabilities: %w", err)}
-		}
-		hasSubscribe := false
-		for _, c := range granted.Granted {
-			if c == "subscribe_events" {
-				hasSubscribe = true
-				break
-			}
-		}
-		if !hasSubscribe {
-			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("subscribe_events capability denied")}
-		}
-
-		// Send subscribe_events
-		subReq := api.WSSubscribeRequest{
-			Type:    "subscribe_events",
-			ID:      "tail-1",
-			Payload: api.WSSubscribePayload{Types: m.tailFilter},
+			return tailConnectedMsg{err: err}
 		}
-		data, _ = json.Marshal(subReq)
-		if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+		if err := granted.Require("subscribe_events"); err != nil {
 			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("write subscribe: %w", err)}
+			return tailConnectedMsg{err: fmt.Errorf("%w (run 'plctl doctor' to inspect the key)", err)}
 		}
 
-		// Read subscribe ack
-		_, subResp, err := conn.Read(ctx)
-		if err != nil {
+		if _, err := api.SubscribeEvents(ctx, conn, "tail-1", m.tailFilter); err != nil {
 			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("read subscribe ack: %w", err)}
-		}
-		var envelope api.WSEnvelope
-		if err := json.Unmarshal(subResp, &envelope); err != nil || (envelope.OK != nil && !*envelope.OK) {
-			conn.Close(websocket.StatusNormalClosure, "")
-			return tailConnectedMsg{err: fmt.Errorf("subscribe rejected")}
+			return tailConnectedMsg{err: err}
 		}
 
 		return tailConnectedMsg{conn: conn}
@@ -671,41 +919,15 @@ func (m model) readNextEvent() tea.Cmd {
 		if m.tailConn == nil {
 			return tailErrorMsg{err: fmt.Errorf("no connection")}
 		}
-		_, data, err := m.tailConn.Read(context.Background())
+		p, err := api.ReadEvent(context.Background(), m.tailConn)
 		if err != nil {
 			return tailErrorMsg{err: err}
 		}
-		var envelope api.WSEnvelope
-		if err := json.Unmarshal(data, &envelope); err != nil {
-			return tailErrorMsg{err: fmt.Errorf("decode message: %w", err)}
-		}
-		switch envelope.Type {
-		case "credential.revoked":
-			return tailErrorMsg{err: fmt.Errorf("agent credential revoked")}
-		case "event":
-			var wsEvt api.WSEvent
-			if err := json.Unmarshal(data, &wsEvt); err != nil {
-				return tailErrorMsg{err: fmt.Errorf("decode event: %w", err)}
-			}
-			p := wsEvt.Payload
-			var detail *string
-			if p.Detail != nil {
-				s := string(*p.Detail)
-				detail = &s
-			}
-			return tailEventMsg{event: api.Event{
-				ID:        p.EventID,
-				Type:      p.EventType,
-				IPAddress: p.IPAddress,
-				UserID:    p.UserID,
-				Detail:    detail,
-				CreatedAt: p.CreatedAt,
-				ActorID:   p.ActorID,
-			}}
-		default:
+		if p == nil {
 			// Protocol messages (heartbeat, pong) — skip and read next
 			return tailEventMsg{event: api.Event{Type: ""}}
 		}
+		return tailEventMsg{event: p.Event()}
 	}
 }
 
@@ -722,70 +944,33 @@ func (m *model) closeTail() {
 		m.tailConn.Close(websocket.StatusNormalClosure, "client disconnected")
 		m.tailConn = nil
 	}
-}
-
-// keepAlive sends application-level ping messages to prevent ping timeout.
-// Runs as a goroutine alongside the read loop; github.com/coder/websocket supports
-// one concurrent reader + one concurrent writer.
-func keepAlive(conn *websocket.Conn, stop <-chan struct{}) {
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
-	seq := 0
-	for {
-		select {
-		case <-stop:
-			return
-		case <-ticker.C:
-			seq++
-			ping := api.WSPingRequest{
-				Type: "ping",
-				ID:   fmt.Sprintf("keepalive-%d", seq),
-			}
-			data, _ := json.Marshal(ping)
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			_ = conn.Write(ctx, websocket.MessageText, data)
-			cancel()
-		}
+	if m.tailTee != nil {
+		m.tailTee.Close()
+		m.tailTee = nil
 	}
 }
 
-func (m model) handleTailView(key string) (tea.Model, tea.Cmd) {
-	switch key {
-	case "esc":
-		m.closeTail()
-		m.state = stateMenu
-		m.tailEvents = nil
-		m.tailErr = nil
-		return m, nil
-	case "q":
-		m.closeTail()
-		m.quitting = true
-		return m, tea.Quit
-	}
-	return m, nil
-}
-
 func (m model) executeAction() tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
 		switch m.action {
 		case actionRevokeAll:
-			resp, err := m.client.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "all"})
+			resp, err := m.ops.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "all"})
 			if err != nil {
 				return resultMsg{err: err}
 			}
 			return resultMsg{message: fmt.Sprintf("Done. %d session(s) revoked.", resp.Revoked)}
 
 		case actionRevokeUser:
-			resp, err := m.client.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "user", ID: m.inputs[0]})
+			resp, err := m.ops.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "user", ID: m.inputs[0]})
 			if err != nil {
 				return resultMsg{err: err}
 			}
 			return resultMsg{message: fmt.Sprintf("Done. %d session(s) revoked for user %s.", resp.Revoked, m.inputs[0])}
 
 		case actionRevokeSession:
-			resp, err := m.client.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "session", ID: m.inputs[0]})
+			resp, err := m.ops.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: "session", ID: m.inputs[0]})
 			if err != nil {
 				return resultMsg{err: err}
 			}
@@ -838,10 +1023,16 @@ func (m model) View() string {
 		b.WriteString(m.viewEventDetail())
 	case stateEventStats:
 		b.WriteString(m.viewEventStats())
+	case stateHeatmap:
+		b.WriteString(m.viewHeatmap())
 	case stateAgents:
 		b.WriteString(m.viewAgents())
+	case stateAgentDetail:
+		b.WriteString(m.viewAgentDetail())
 	case stateTailEvents:
 		b.WriteString(m.viewTailEvents())
+	case stateDashboard:
+		b.WriteString(m.viewDashboard())
 	}
 
 	b.WriteString("\n")
@@ -851,7 +1042,11 @@ func (m model) View() string {
 func (m model) viewMenu() string {
 	var b strings.Builder
 
-	for i, item := range menuItems {
+	if m.source != "" {
+		b.WriteString(ui.PromptStyle.Render("  READ-ONLY: " + m.source))
+		b.WriteString("\n\n")
+	}
+	for i, item := range m.menu {
 		if item.isHeader {
 			if i > 0 {
 				b.WriteString("\n")
@@ -960,6 +1155,7 @@ func (m model) viewSessions() string {
 	}
 
 	b.WriteString(fmt.Sprintf("Active Sessions (%d)\n\n", len(m.sessions)))
+	b.WriteString(renderFailures(m.failed))
 
 	columns := []ui.Column{
 		{Header: "ID", Width: 24},
@@ -979,6 +1175,7 @@ func (m model) viewSessions() string {
 			s.ExpiresAt,
 		}
 	}
+	columns, rows = withTarget(columns, rows, m.rowTargets)
 
 	b.WriteString(ui.RenderTable(columns, rows))
 	b.WriteString(ui.DimStyle.Render("\nenter continue • q quit"))
@@ -1000,68 +1197,24 @@ func (m model) viewEvents() string {
 	}
 
 	if len(m.events) == 0 {
+		b.WriteString(renderPartial(m.eventsCapped, m.eventsScan))
 		b.WriteString(ui.DimStyle.Render("No events found."))
 		b.WriteString(ui.DimStyle.Render("\n\nenter continue • q quit"))
 		return b.String()
 	}
 
-	b.WriteString(fmt.Sprintf("Security Events (%d)\n\n", len(m.events)))
+	b.WriteString(fmt.Sprintf("Security Events (%d)", len(m.events)))
+	if m.eventsFilter != "" {
+		b.WriteString(ui.DimStyle.Render("  " + m.eventsFilter))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(renderFailures(m.failed))
+	b.WriteString(renderPartial(m.eventsCapped, m.eventsScan))
 	b.WriteString(m.eventsTable.View())
 	b.WriteString(ui.DimStyle.Render("\n↑/↓ navigate • enter detail • esc back • q quit"))
 	return b.String()
 }
 
-func (m model) viewEventDetail() string {
-	var b strings.Builder
-
-	idx := m.eventsTable.Cursor()
-	if idx < 0 || idx >= len(m.events) {
-		b.WriteString(ui.DimStyle.Render("No event selected."))
-		b.WriteString(ui.DimStyle.Render("\n\nesc back • q quit"))
-		return b.String()
-	}
-
-	e := m.events[idx]
-
-	b.WriteString(fmt.Sprintf("Event #%d\n\n", e.ID))
-
-	userID := "-"
-	if e.UserID != nil {
-		userID = fmt.Sprintf("%d", *e.UserID)
-	}
-	labels := []string{"Type", "IP", "User", "Actor", "Time"}
-	values := []string{e.Type, e.IPAddress, userID, e.ActorID, e.CreatedAt}
-
-	for i, label := range labels {
-		b.WriteString(fmt.Sprintf("  %s  %s\n", ui.HeaderStyle.Render(fmt.Sprintf("%-10s", label)), values[i]))
-	}
-
-	detail := "-"
-	if e.Detail != nil {
-		detail = *e.Detail
-	}
-	const labelWidth = 15 // "  " + 10-char label + "  "
-	maxDetail := m.width - labelWidth
-	if maxDetail < 20 {
-		maxDetail = 80
-	}
-	prefix := fmt.Sprintf("  %s  ", ui.HeaderStyle.Render(fmt.Sprintf("%-10s", "Detail")))
-	pad := strings.Repeat(" ", labelWidth)
-	for i := 0; i < len(detail); i += maxDetail {
-		end := min(i+maxDetail, len(detail))
-		if i == 0 {
-			b.WriteString(prefix)
-		} else {
-			b.WriteString(pad)
-		}
-		b.WriteString(detail[i:end])
-		b.WriteString("\n")
-	}
-
-	b.WriteString(ui.DimStyle.Render("\nesc back • q quit"))
-	return b.String()
-}
-
 func (m model) viewEventStats() string {
 	var b strings.Builder
 
@@ -1071,38 +1224,37 @@ func (m model) viewEventStats() string {
 		return b.String()
 	}
 
+	if m.fleetStats != nil {
+		b.WriteString(fmt.Sprintf("Event Stats (since %s)\n\n", m.eventSince))
+		b.WriteString(renderFailures(m.failed))
+		if len(m.fleetStats.Types) == 0 {
+			b.WriteString(ui.DimStyle.Render("No events in time window."))
+			b.WriteString(ui.DimStyle.Render("\n\nenter continue • q quit"))
+			return b.String()
+		}
+		b.WriteString(renderFleetStats(m.fleetStats, m.statsFlags))
+		b.WriteString(ui.DimStyle.Render("\nenter continue • q quit"))
+		return b.String()
+	}
+
 	if m.eventStats == nil {
 		b.WriteString(ui.DimStyle.Render("Loading..."))
 		return b.String()
 	}
 
-	if len(m.eventStats) == 0 {
+	if m.statsCompare == nil || len(m.statsCompare.Changes) == 0 {
 		b.WriteString(ui.DimStyle.Render("No events in time window."))
 		b.WriteString(ui.DimStyle.Render("\n\nenter continue • q quit"))
 		return b.String()
 	}
 
-	b.WriteString(fmt.Sprintf("Event Stats (since %s)\n\n", m.eventSince))
-
-	// Sort keys for stable output
-	keys := make([]string, 0, len(m.eventStats))
-	for k := range m.eventStats {
-		keys = append(keys, k)
+	b.WriteString(fmt.Sprintf("Event Stats (since %s, against the %d days before)\n\n", m.eventSince, statsCompare.History))
+	rows := make([]fleet.Row[compare.Change], len(m.statsCompare.Changes))
+	for i, c := range m.statsCompare.Changes {
+		rows[i].Item = c
 	}
-	sort.Strings(keys)
-
-	columns := []ui.Column{
-		{Header: "Event Type", Width: 30},
-		{Header: "Count", Width: 8},
-	}
-
-	rows := make([][]string, len(keys))
-	for i, k := range keys {
-		rows[i] = []string{k, fmt.Sprintf("%d", m.eventStats[k])}
-	}
-
-	b.WriteString(ui.RenderTable(columns, rows))
-	b.WriteString(ui.DimStyle.Render("\nenter continue • q quit"))
+	b.WriteString(renderComparison(rows, false))
+	b.WriteString(ui.DimStyle.Render(fmt.Sprintf("\nred: spike at |z| ≥ %g against the daily baseline • enter continue • q quit", statsCompare.Z)))
 	return b.String()
 }
 
@@ -1127,88 +1279,58 @@ func (m model) viewAgents() string {
 	}
 
 	b.WriteString(fmt.Sprintf("Active Agents (%d)\n\n", len(m.agents)))
+	b.WriteString(renderFailures(m.failed))
 
-	columns := []ui.Column{
-		{Header: "Name", Width: 20},
-		{Header: "Trust", Width: 8},
-		{Header: "Description", Width: 30},
-		{Header: "Created", Width: 20},
-	}
-
-	rows := make([][]string, len(m.agents))
-	for i, a := range m.agents {
-		desc := "-"
-		if a.Description != nil {
-			desc = *a.Description
-		}
-		rows[i] = []string{a.Name, a.TrustLevel, desc, a.CreatedAt}
-	}
-
-	b.WriteString(ui.RenderTable(columns, rows))
-	b.WriteString(ui.DimStyle.Render("\nenter continue • q quit"))
+	b.WriteString(m.agentsTable.View())
+	b.WriteString(ui.DimStyle.Render("\n↑/↓ navigate • enter activity • esc back • q quit"))
 	return b.String()
 }
 
-func (m model) viewTailEvents() string {
+func (m model) viewAgentDetail() string {
 	var b strings.Builder
 
-	if m.tailErr != nil {
-		b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.tailErr)))
+	if m.dataErr != nil {
+		b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.dataErr)))
 		b.WriteString(ui.DimStyle.Render("\n\nesc back • q quit"))
 		return b.String()
 	}
 
-	if m.tailConn == nil {
-		if m.tailChallenge != nil && m.tailChallenge.Required {
-			b.WriteString(ui.DimStyle.Render(
-				fmt.Sprintf("Solved PoW challenge (difficulty %d), connecting...", m.tailChallenge.Difficulty)))
-		} else if m.tailChallenge != nil {
-			b.WriteString(ui.DimStyle.Render("Connecting..."))
-		} else {
-			b.WriteString(ui.DimStyle.Render("Probing for PoW challenge..."))
-		}
+	a := m.agentActivity
+	if a == nil {
+		b.WriteString(ui.DimStyle.Render("Loading..."))
 		return b.String()
 	}
 
-	header := "Tailing events (live)"
-	if len(m.tailFilter) > 0 {
-		header += fmt.Sprintf("  [%s]", strings.Join(m.tailFilter, ", "))
+	b.WriteString(fmt.Sprintf("Agent %s (last 7 days)\n\n", a.Name))
+	lastSeen := a.LastSeen
+	if lastSeen == "" {
+		lastSeen = "-"
 	}
-	b.WriteString(ui.HeaderStyle.Render(header))
-	if len(m.tailEvents) > 0 {
-		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("  %d event(s)", len(m.tailEvents))))
+	labels := []string{"Status", "Last seen", "Events", "Connects", "Disconnects", "Granted", "Denied", "Unauthorized", "Rate limited", "Revocations"}
+	values := []string{a.Status(), lastSeen}
+	for _, n := range []int{a.Events, a.Connects, a.Disconnects, a.Granted, a.Denied, a.Unauthorized, a.RateLimited, a.Revocations} {
+		values = append(values, fmt.Sprintf("%d", n))
+	}
+	for i, label := range labels {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", ui.HeaderStyle.Render(fmt.Sprintf("%-12s", label)), values[i]))
 	}
-	b.WriteString("\n\n")
 
-	if len(m.tailEvents) == 0 {
-		b.WriteString(ui.DimStyle.Render("Waiting for events..."))
-	} else {
-		// Show last 20 events that fit on screen
-		start := 0
-		if len(m.tailEvents) > 20 {
-			start = len(m.tailEvents) - 20
+	if len(m.agentEvents) > 0 {
+		b.WriteString("\nRecent events\n\n")
+		columns := []ui.Column{
+			{Header: "Type", Width: 24},
+			{Header: "IP", Width: 16},
+			{Header: "Time", Width: 24},
 		}
-		for _, e := range m.tailEvents[start:] {
-			// Extract just the time portion from ISO timestamp
-			ts := e.CreatedAt
-			if len(ts) >= 19 {
-				ts = ts[11:19]
-			}
-			userID := "-"
-			if e.UserID != nil {
-				userID = fmt.Sprintf("user:%d", *e.UserID)
-			}
-			line := fmt.Sprintf("  %s  %-24s  %-16s  %-10s  %s",
-				ts, e.Type, e.IPAddress, userID, e.ActorID)
-			if m.width > 0 && len(line) > m.width {
-				line = line[:m.width]
-			}
-			b.WriteString(line)
-			b.WriteString("\n")
+		recent := m.agentEvents[:min(len(m.agentEvents), 10)]
+		rows := make([][]string, len(recent))
+		for i, e := range recent {
+			rows[i] = []string{e.Type, e.IPAddress, e.CreatedAt}
 		}
+		b.WriteString(ui.RenderTable(columns, rows))
 	}
 
-	b.WriteString(ui.DimStyle.Render("\nesc stop • q quit"))
+	b.WriteString(ui.DimStyle.Render("\nesc back • q quit"))
 	return b.String()
 }
 
@@ -1217,8 +1339,7 @@ func isSafeTarget(rawURL string) bool {
 	if err != nil {
 		return false
 	}
-	host := u.Hostname()
-	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+	if api.IsLoopback(u.Hostname()) {
 		return true
 	}
 	env := os.Getenv("ENVIRONMENT")
@@ -1233,17 +1354,45 @@ func printUsage() {
 	fmt.Println(heading("plctl") + dim(" - Private Landing control"))
 	fmt.Println()
 	fmt.Println(heading("Usage:"))
-	fmt.Println("  plctl [flags]")
+	fmt.Println("  plctl [--contexts a,b | --offline backup.db] [flags]")
+	fmt.Println("  plctl [--context name | --contexts a,b] <command> [flags]")
 	fmt.Println()
 	fmt.Println("  Launches an interactive TUI for managing Private Landing operations.")
+	fmt.Println("  Run 'plctl <command> -h' for command flags.")
 	fmt.Println()
 	fmt.Println(heading("Flags:"))
 	fmt.Println("  " + label("-h, --help") + "    Show this help message")
+	fmt.Println("  " + label("--context") + "     Use a named target from the contexts file instead of PLCTL_*")
+	fmt.Println("  " + label("--contexts") + "    Query several targets concurrently (events command, TUI fleet mode)")
+	fmt.Println("  " + label("--transport") + "   auto (default), rest or ws; auto switches to /ops/ws when REST is rate limited or down")
+	fmt.Println("  " + label("--offline") + "     Read a local export (SQLite file, .sql dump or backup.sh .tar.gz) instead of the API; TUI, events and agents audit/activity")
+	fmt.Println()
+	fmt.Println(heading("Commands:"))
+	fmt.Println("  " + label("watch") + "         " + dim("Evaluate YAML alert rules against the live event stream (--rules, --dry-run, --replay [--run-actions])"))
+	fmt.Println("  " + label("playbook") + "      " + dim("List, show or run incident response playbooks (run <name> --user 42)"))
+	fmt.Println("  " + label("evidence") + "      " + dim("Collect, verify or open (read-only TUI) a signed incident evidence bundle"))
+	fmt.Println("  " + label("report") + "        " + dim("Security digest with trends and SVG charts (--since 7d, --format markdown|html, -o file)"))
+	fmt.Println("  " + label("mcp") + "           " + dim("Model Context Protocol server on stdio; --allow-write exposes revoke_session"))
+	fmt.Println("  " + label("gateway") + "       " + dim("Share one ops WebSocket with local WS/SSE/NDJSON clients (--listen unix:///run/plctl.sock)"))
+	fmt.Println("  " + label("bridge") + "        " + dim("HTTP/SSE bridge with local bearer tokens (--listen :8080 --tokens file; 'bridge token <name>')"))
+	fmt.Println("  " + label("events") + "        " + dim("List, query or summarize events; merged per target with --contexts (list --type t, query '<expr>', stats --since 7d | --compare 24h, aggregate --by type,ip --interval 1h, get <id>)"))
+	fmt.Println("  " + label("agents") + "        " + dim("Rotate a credential (rotate <name>), audit the inventory (audit) or summarize per-agent activity (activity --since 7d)"))
+	fmt.Println("  " + label("whoami") + "        " + dim("Show the agent, connection ID and granted/denied capabilities for the configured key"))
+	fmt.Println("  " + label("doctor") + "        " + dim("Check REST, TLS, PoW, capability negotiation, ping round trip and clock skew in one step"))
+	fmt.Println("  " + label("auth") + "          " + dim("Act as an end user: register, login (solves PoW), me [--check], refresh, logout, change-password"))
+	fmt.Println("  " + label("token") + "         " + dim("Decode or HS256-verify a pasted JWT locally; --check reports its session as active, expired, revoked or inactive"))
+	fmt.Println("  " + label("probe") + "         " + dim("Synthetic login/refresh/logout cycle every --interval, checked via the ops API (JSON lines, --listen metrics)"))
+	fmt.Println("  " + label("loadtest") + "      " + dim("Abuse scenarios (login, wrong-password, spoofed-ips, register, ws-flood) against dev targets only"))
+	fmt.Println("  " + label("archive") + "       " + dim("Append-only local event store: sync [--follow] | status | verify | export; read it back with --offline"))
+	fmt.Println("  " + label("replay") + "        " + dim("Play recorded NDJSON or an export through the tail view, rules and a gateway (--speed 10x, seek, --headless)"))
 	fmt.Println()
 	fmt.Println(heading("Environment:"))
 	fmt.Println("  " + label("PLCTL_API_URL") + "              API base URL (required)")
 	fmt.Println("  " + label("PLCTL_API_KEY") + "              Agent API key for Bearer auth (required)")
 	fmt.Println("  " + label("PLCTL_PROVISIONING_SECRET") + "  Infrastructure secret for agent provisioning (optional)")
+	fmt.Println("  " + label("PLCTL_CONFIG") + "               Contexts file (default <user config dir>/plctl/contexts.yaml)")
+	fmt.Println("  " + label("PLCTL_PROBE_EMAIL") + "          Test account for 'plctl probe' (with PLCTL_PROBE_PASSWORD)")
+	fmt.Println("  " + label("PLCTL_TAIL_BUFFER") + "          Events kept in the live tail scrollback (default 1000)")
 	fmt.Println("  " + label("ENVIRONMENT") + "                Set to any non-production value to suppress safety prompt")
 	fmt.Println()
 	fmt.Println(heading("Commands (interactive):"))
@@ -1256,51 +1405,84 @@ func printUsage() {
 	fmt.Println("    Revoke specific session       " + dim("Expire a single session by ID"))
 	fmt.Println()
 	fmt.Println("  " + label("Events"))
-	fmt.Println("    View recent events            " + dim("List security events (last 24h)"))
+	fmt.Println("    View recent events            " + dim("List security events (last 24h); enter opens a foldable detail tree"))
 	fmt.Println("    View events for user          " + dim("List events filtered by user ID"))
-	fmt.Println("    View event stats              " + dim("Aggregate event counts by type"))
-	fmt.Println("    Tail events (live)            " + dim("Stream security events in real time (supports filters: login.*, session.revoke)"))
+	fmt.Println("    Query events                  " + dim("Filter events with an expression, e.g. type ~ \"login.*\" and hour(created_at) in 0..5"))
+	fmt.Println("    View event stats              " + dim("Counts by type for the last day against the week before; spikes in red"))
+	fmt.Println("    Event heatmap                 " + dim("Event types by UTC hour of day, shaded by volume (default last 7d)"))
+	fmt.Println("    Tail events (live)            " + dim("Stream security events with scrollback, pause, / search and tee (filters: login.*, session.revoke)"))
+	fmt.Println("    Live dashboard                " + dim("Wallboard: per-type sparklines, login ratio, sessions, PoW rate, top IPs"))
 	fmt.Println()
 	fmt.Println("  " + label("Agents"))
-	fmt.Println("    List agents                   " + dim("Show active agent credentials"))
+	fmt.Println("    List agents                   " + dim("Show active agent credentials; enter shows 7-day activity"))
 	fmt.Println("    Provision agent               " + dim("Create a new agent credential"))
 	fmt.Println("    Revoke agent                  " + dim("Revoke an agent credential"))
 }
 
 func main() {
-	for _, arg := range os.Args[1:] {
-		if arg == "-h" || arg == "--help" {
-			printUsage()
-			os.Exit(0)
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// -h or --help anywhere shows the usage, except after a command name,
+	// where the command's own flags answer it.
+	if len(args) == 0 || !isCommand(args[0]) {
+		for _, arg := range args {
+			if arg == "-h" || arg == "--help" {
+				printUsage()
+				os.Exit(0)
+			}
 		}
 	}
+	if len(args) > 0 {
+		os.Exit(runCommand(args[0], args[1:]))
+	}
 
-	apiURL := os.Getenv("PLCTL_API_URL")
-	apiKey := os.Getenv("PLCTL_API_KEY")
-	provSecret := os.Getenv("PLCTL_PROVISIONING_SECRET")
+	if offlinePath != "" {
+		db, err := offline.Open(offlinePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		source := fmt.Sprintf("%s (as of %s)", offlinePath, db.AsOf.Format(time.RFC3339))
+		_, err = tea.NewProgram(readOnlyModel(db, source)).Run()
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(selectedContexts) > 1 {
+		targets, err := resolveTargets()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if _, err := tea.NewProgram(fleetModel(targets)).Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if apiURL == "" || apiKey == "" {
-		fmt.Fprintln(os.Stderr, "PLCTL_API_URL and PLCTL_API_KEY environment variables are required")
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, "Run 'plctl --help' for usage information")
 		os.Exit(1)
 	}
 
-	if !isSafeTarget(apiURL) {
-		fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render("WARNING: Target does not appear to be a non-production environment."))
-		fmt.Fprintln(os.Stderr, ui.ErrorStyle.Render("Set ENVIRONMENT to a non-production value (e.g. 'development', 'staging') to suppress."))
-		fmt.Fprint(os.Stderr, ui.PromptStyle.Render("Continue? (y/N) "))
-
-		var answer string
-		fmt.Scanln(&answer)
-		if answer != "y" && answer != "Y" {
-			os.Exit(0)
-		}
+	if !confirmTarget(apiURL) {
+		os.Exit(0)
 	}
 
-	client := api.NewClient(apiURL, apiKey, provSecret)
-
-	p := tea.NewProgram(initialModel(client))
-	if _, err := p.Run(); err != nil {
+	ops := resolveTransport(client)
+	_, err = tea.NewProgram(initialModel(client, ops)).Run()
+	ops.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -1,8 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/private-landing/cli/internal/aggregate"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/compare"
+	"github.com/private-landing/cli/internal/dashboard"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/replay"
+	"github.com/private-landing/cli/internal/rules"
 )
 
 func TestIsSafeTarget(t *testing.T) {
@@ -49,3 +66,413 @@ func TestIsSafeTarget(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []rules.Rule
+		want  []string
+	}{
+		{"union of patterns", []rules.Rule{
+			{Match: rules.Match{Type: rules.StringList{"login.*"}}},
+			{Match: rules.Match{Type: rules.StringList{"login.*", "session.revoke"}}},
+		}, []string{"login.*", "session.revoke"}},
+		{"unfiltered rule subscribes to all", []rules.Rule{
+			{Match: rules.Match{Type: rules.StringList{"login.*"}}},
+			{Match: rules.Match{}},
+		}, nil},
+		{"server-invalid pattern subscribes to all", []rules.Rule{
+			{Match: rules.Match{Type: rules.StringList{"login.fail*"}}},
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subscriptionTypes(tt.rules)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+				t.Errorf("subscriptionTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaybookArgs(t *testing.T) {
+	name, params, yes, dryRun, out, err := parsePlaybookArgs([]string{
+		"compromised-account", "--user", "42", "--since=3d", "--yes", "-o", "case-1", "--dry-run",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "compromised-account" || !yes || !dryRun || out != "case-1" {
+		t.Fatalf("unexpected parse: name=%q yes=%v dryRun=%v out=%q", name, yes, dryRun, out)
+	}
+	if params["user"] != "42" || params["since"] != "3d" || len(params) != 2 {
+		t.Fatalf("unexpected params: %v", params)
+	}
+
+	if _, _, _, _, _, err := parsePlaybookArgs([]string{"x", "--user"}); err == nil {
+		t.Fatal("expected missing value error, got nil")
+	}
+
+	_, _, yes, dryRun, _, err = parsePlaybookArgs([]string{"x", "--yes=false", "--dry-run=false"})
+	if err != nil || yes || dryRun {
+		t.Fatalf("--yes=false --dry-run=false: yes=%v dryRun=%v err=%v", yes, dryRun, err)
+	}
+	if _, _, _, _, _, err := parsePlaybookArgs([]string{"x", "--dry-run=maybe"}); err == nil {
+		t.Fatal("expected invalid boolean error, got nil")
+	}
+}
+
+func TestReadOnlyModelMenu(t *testing.T) {
+	m := readOnlyModel(nil, "case.tar.gz")
+	for _, item := range m.menu {
+		if !item.isHeader && !readOnlyActions[item.action] {
+			t.Errorf("read-only menu contains %q", item.label)
+		}
+	}
+	if m.menu[m.cursor].isHeader {
+		t.Error("cursor starts on a header")
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	t.Cleanup(func() { selectedContexts = nil })
+
+	rest, err := parseGlobalFlags([]string{"--contexts", "prod-eu, prod-us", "--context=staging", "events", "stats", "--since", "7d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(rest, " ") != "events stats --since 7d" {
+		t.Errorf("rest = %v", rest)
+	}
+	if strings.Join(selectedContexts, ",") != "prod-eu,prod-us,staging" {
+		t.Errorf("contexts = %v", selectedContexts)
+	}
+
+	if _, err := parseGlobalFlags([]string{"--contexts"}); err == nil {
+		t.Error("expected missing value error, got nil")
+	}
+}
+
+func TestParseGlobalTransport(t *testing.T) {
+	t.Cleanup(func() { transportMode = api.TransportAuto })
+
+	rest, err := parseGlobalFlags([]string{"--transport", "ws", "doctor"})
+	if err != nil || transportMode != api.TransportWS || len(rest) != 1 {
+		t.Fatalf("rest = %v, mode = %q, err = %v", rest, transportMode, err)
+	}
+	if _, err := parseGlobalFlags([]string{"--transport=grpc"}); err == nil {
+		t.Error("expected unknown transport error, got nil")
+	}
+}
+
+func TestViewDashboardWidth(t *testing.T) {
+	d := &dashboard.Dashboard{Stats: map[string]int{"login.success": 3, "login.failure": 1}}
+	now := time.Now()
+	for i := range 4 {
+		d.Add(api.Event{ID: i + 1, Type: "login.success", IPAddress: "203.0.113.7", CreatedAt: now.UTC().Format(time.RFC3339)}, now)
+	}
+	for _, width := range []int{60, 120} {
+		m := model{dash: d, width: width, state: stateDashboard}
+		for _, line := range strings.Split(m.viewDashboard(), "\n") {
+			if w := lipgloss.Width(line); w > width && !strings.Contains(line, "updated") {
+				t.Errorf("width %d: line is %d wide: %q", width, w, line)
+			}
+		}
+	}
+}
+
+func TestTailScrollback(t *testing.T) {
+	t.Setenv("PLCTL_TAIL_BUFFER", "5")
+	m := model{height: 40}
+	if err := m.startTail(""); err != nil {
+		t.Fatal(err)
+	}
+	for id := 1; id <= 3; id++ {
+		m.pushTail(api.Event{ID: id, Type: "login.success"})
+	}
+	if m.tailCursor != 2 {
+		t.Fatalf("following cursor = %d, want 2", m.tailCursor)
+	}
+
+	// Scrolled back, evictions keep the cursor on the same event.
+	m.moveTail(1)
+	for id := 4; id <= 6; id++ {
+		m.pushTail(api.Event{ID: id, Type: "login.failure"})
+	}
+	if got := m.tailBuf.At(m.tailCursor).ID; got != 2 {
+		t.Errorf("cursor on event %d, want 2", got)
+	}
+
+	m.tailQuery.Append([]rune("FAIL"))
+	m.findTail(m.tailBuf.Len()-1, -1)
+	if got := m.tailBuf.At(m.tailCursor).ID; got != 6 {
+		t.Errorf("search landed on event %d, want 6", got)
+	}
+}
+
+func TestTailBufferSizeEnv(t *testing.T) {
+	t.Setenv("PLCTL_TAIL_BUFFER", "0")
+	if _, err := tailBufferSize(); err == nil {
+		t.Error("expected error for PLCTL_TAIL_BUFFER=0")
+	}
+}
+
+func TestQueryInputReprompt(t *testing.T) {
+	m := model{action: actionQueryEvents}
+	m.startInput([]string{"Query", "Since (optional)"})
+	m.inputs = []string{`tpye = "x"`, ""}
+	m.inputField = 2
+
+	next, cmd := m.afterInputComplete()
+	if cmd != nil || next.state != stateInput || next.inputField != 0 || next.input.Value != `tpye = "x"` {
+		t.Fatalf("state = %v, field = %d, input = %q; want the query re-prompted", next.state, next.inputField, next.input.Value)
+	}
+	if !strings.Contains(next.inputHint, "^ unknown field tpye; did you mean type?") {
+		t.Errorf("hint = %q", next.inputHint)
+	}
+
+	next.inputs = []string{`type ~ "login.*"`, "7d"}
+	next, cmd = next.afterInputComplete()
+	if cmd == nil || next.state != stateEvents {
+		t.Errorf("state = %v, cmd = %v; want a fetch", next.state, cmd)
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	a, _ := aggregate.New([]string{"type", "hour(created_at)"}, 0)
+	for i, typ := range []string{"login.failure", "login.failure", "login.failure", "login.success", "a.very.long.event.type.name"} {
+		a.Add("", api.Event{Type: typ, CreatedAt: time.Date(2025, 3, 1, i*5, 0, 0, 0, time.UTC).Format(time.DateTime)})
+	}
+	p, err := a.Result(0).Pivot(hours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := renderHeatmap(p, 2)
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[0], " 0") || !strings.Contains(lines[0], "23") {
+		t.Errorf("header = %q, want hours 0 to 23", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "login.failure") || !strings.HasSuffix(lines[1], "  3") {
+		t.Errorf("first row = %q, want login.failure with total 3", lines[1])
+	}
+	if w := lipgloss.Width(lines[1]); w != lipgloss.Width(lines[2]) {
+		t.Errorf("rows are %d and %d wide", w, lipgloss.Width(lines[2]))
+	}
+	if !strings.Contains(out, "+1 more types") {
+		t.Errorf("heatmap does not note the hidden row:\n%s", out)
+	}
+}
+
+func TestWriteAggregateCSV(t *testing.T) {
+	a, _ := aggregate.New([]string{"type"}, time.Hour)
+	for i, typ := range []string{"login.failure", "login.failure", "login.success"} {
+		a.Add("", api.Event{Type: typ, IPAddress: "198.51.100.7", CreatedAt: time.Date(2025, 3, 1, i*2, 0, 0, 0, time.UTC).Format(time.DateTime)})
+	}
+	var b bytes.Buffer
+	if err := writeAggregateCSV(&b, a.Result(1)); err != nil {
+		t.Fatal(err)
+	}
+	want := "type,count,users,ips,2025-03-01T00:00:00Z,2025-03-01T01:00:00Z,2025-03-01T02:00:00Z,2025-03-01T03:00:00Z,2025-03-01T04:00:00Z\n" +
+		"login.failure,2,0,1,1,0,1,0,0\n" +
+		"(1 others),1,0,1,0,0,0,0,1\n"
+	if b.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestComparisonStats(t *testing.T) {
+	change := func(typ string, cur, prev int, flag compare.Flag) compare.Change {
+		return compare.Change{Type: typ, Current: cur, History: []int{prev}, Flag: flag}
+	}
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	results := []fleet.Result[*compare.Comparison]{
+		{Target: "prod", Value: &compare.Comparison{Since: since, Changes: []compare.Change{
+			change("registration.failure", 40, 2, compare.Spike),
+			change("login.success", 0, 30, compare.Drop),
+		}}},
+		{Target: "staging", Value: &compare.Comparison{Since: since, Changes: []compare.Change{
+			change("registration.failure", 1, 1, compare.None),
+			change("login.success", 3, 3, compare.None),
+		}}},
+		{Target: "dev", Err: errors.New("unreachable")},
+	}
+	msg := comparisonStats(results, 3)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if msg.flags["registration.failure"] != compare.Spike || msg.flags["login.success"] != compare.Drop {
+		t.Errorf("flags = %v", msg.flags)
+	}
+	if got := msg.fleet.Counts["registration.failure"]; got["prod"] != 40 || got["staging"] != 1 {
+		t.Errorf("registration.failure counts = %v", got)
+	}
+	if _, ok := msg.fleet.Counts["login.success"]["prod"]; ok {
+		t.Error("prod has a login.success count for a window with none")
+	}
+	if len(msg.failed) != 1 || msg.since != "2025-03-01T00:00:00Z" {
+		t.Errorf("failed = %v, since = %q", msg.failed, msg.since)
+	}
+}
+
+func replayRecording() []api.Event {
+	var events []api.Event
+	for i := range 12 {
+		events = append(events, api.Event{
+			ID:        i + 1,
+			Type:      "login.failure",
+			IPAddress: "198.51.100.7",
+			CreatedAt: time.Date(2025, 3, 1, 12, i, 0, 0, time.UTC).Format(time.RFC3339),
+		})
+	}
+	return events
+}
+
+func replayRules(t *testing.T) []rules.Rule {
+	t.Helper()
+	f, err := rules.Parse([]byte(`
+rules:
+  - name: stuffing
+    match: {type: login.failure}
+    threshold: 3
+    window: 5m
+    group_by: [ip]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Rules
+}
+
+func TestReplaySeek(t *testing.T) {
+	s := &replaySession{name: "rec.ndjson", player: replay.NewPlayer(replayRecording(), replay.Max, 0), rules: replayRules(t)}
+	m, err := replayModel(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for !s.player.Done() {
+		m.playReplay(s.player.Advance(0))
+	}
+	if len(s.alerts) != 4 || m.tailBuf.Len() != 12 {
+		t.Fatalf("played through: %d alerts, %d events shown", len(s.alerts), m.tailBuf.Len())
+	}
+
+	// Seeking back rebuilds the view and the rules from the recording.
+	next, _, ok := m.handleReplayKey("5")
+	if !ok {
+		t.Fatal("5 not handled")
+	}
+	m = next.(model)
+	if s.player.Pos() != 6 || m.tailBuf.Len() != 6 || len(s.alerts) != 2 {
+		t.Errorf("after seek to 50%%: pos %d, %d events shown, %d alerts", s.player.Pos(), m.tailBuf.Len(), len(s.alerts))
+	}
+	if !strings.Contains(m.viewReplayBar(), "6/12") {
+		t.Errorf("scrubber = %q, want position 6/12", m.viewReplayBar())
+	}
+}
+
+func TestReplayHeadlessDeterministic(t *testing.T) {
+	run := func(speed float64) string {
+		s := &replaySession{player: replay.NewPlayer(replayRecording(), speed, time.Nanosecond), rules: replayRules(t)}
+		s.rebuild()
+		var b bytes.Buffer
+		if err := s.runHeadless(context.Background(), &b, true, nil); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	out := run(replay.Max)
+	if n := strings.Count(out, "\n"); n != 4 {
+		t.Errorf("%d alerts, want 4:\n%s", n, out)
+	}
+	if again := run(1000); again != out {
+		t.Errorf("output differs by speed:\n%s\nvs\n%s", out, again)
+	}
+}
+
+func TestWatchReplayDoesNotRunActions(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rulesPath := filepath.Join(dir, "rules.yaml")
+	os.WriteFile(rulesPath, []byte(`
+rules:
+  - name: any-failure
+    match: {type: login.failure}
+    actions:
+      - {type: webhook, url: "`+srv.URL+`"}
+`), 0o600)
+	recording := filepath.Join(dir, "events.ndjson")
+	os.WriteFile(recording, []byte(`{"id":1,"type":"login.failure","ip_address":"198.51.100.7","created_at":"2026-03-01T12:00:00Z"}`+"\n"), 0o600)
+
+	if err := runWatch(context.Background(), []string{"--rules", rulesPath, "--replay", recording}); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 0 {
+		t.Errorf("webhook called %d times during a replay without --run-actions", hits)
+	}
+
+	if err := runWatch(context.Background(), []string{"--rules", rulesPath, "--replay", recording, "--run-actions"}); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 1 {
+		t.Errorf("webhook called %d times with --run-actions, want 1", hits)
+	}
+}
+
+func TestReplayDoesNotRunActions(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rulesPath := filepath.Join(dir, "rules.yaml")
+	os.WriteFile(rulesPath, []byte(`
+rules:
+  - name: any-failure
+    match: {type: login.failure}
+    actions:
+      - {type: webhook, url: "`+srv.URL+`"}
+`), 0o600)
+	recording := filepath.Join(dir, "events.ndjson")
+	os.WriteFile(recording, []byte(`{"id":1,"type":"login.failure","ip_address":"198.51.100.7","created_at":"2026-03-01T12:00:00Z"}`+"\n"), 0o600)
+
+	args := []string{recording, "--rules", rulesPath, "--speed", "max", "--headless"}
+	if err := runReplay(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 0 {
+		t.Errorf("webhook called %d times without --run-actions", hits)
+	}
+	if err := runReplay(context.Background(), append(args, "--run-actions")); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 1 {
+		t.Errorf("webhook called %d times with --run-actions, want 1", hits)
+	}
+}
+
+func TestReplayShowsDryRunActions(t *testing.T) {
+	f, err := rules.Parse([]byte(`
+rules:
+  - name: any-failure
+    match: {type: login.failure}
+    actions:
+      - {type: file, path: "` + filepath.Join(t.TempDir(), "alerts.ndjson") + `"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &replaySession{player: replay.NewPlayer(replayRecording(), replay.Max, 0), rules: f.Rules, dryRun: true}
+	m, err := replayModel(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range m.playReplay(s.player.Step()) {
+		cmd()
+	}
+	if bar := m.viewReplayBar(); !strings.Contains(bar, "action=file dry-run") {
+		t.Errorf("scrubber = %q, want the dry-run action shown", bar)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/mcp"
+)
+
+// runMCP serves ops tools over stdio. stdout carries the protocol, so all
+// diagnostics and the call log go to stderr or --log.
+func runMCP(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	allowWrite := fs.Bool("allow-write", false, "expose revoke_session (also requires a write-trust agent key)")
+	logPath := fs.String("log", "", "append the tool call log to `file` (default stderr)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	var logW io.Writer = os.Stderr
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		logW = f
+	}
+	if *allowWrite && !isSafeTarget(apiURL) {
+		// stdin is the protocol stream, so there is no way to prompt here.
+		fmt.Fprintln(os.Stderr, "mcp: WARNING: --allow-write against a target that does not appear to be non-production")
+	}
+
+	ops := resolveTransport(client)
+	defer ops.Close()
+	srv := &mcp.Server{Ops: ops, AllowWrite: *allowWrite, Log: logW, Version: version}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go streamLiveEvents(ctx, client, srv, *allowWrite)
+
+	return srv.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// streamLiveEvents feeds the live-events resource and keeps the write gate in
+// sync with the capabilities granted on each connection.
+func streamLiveEvents(ctx context.Context, client *api.Client, srv *mcp.Server, allowWrite bool) {
+	var extra []string
+	if allowWrite {
+		extra = append(extra, "revoke_session")
+	}
+	onConnect := func(sub *api.Subscription) {
+		srv.SetWritable(sub.Granted.Has("revoke_session"))
+		if allowWrite && !sub.Granted.Has("revoke_session") {
+			fmt.Fprintln(os.Stderr, "mcp: agent lacks write trust; revoke_session disabled")
+		}
+	}
+	if err := followEvents(ctx, "mcp", client, nil, extra, streamHandlers{Connect: onConnect, Event: srv.PushEvent}); err != nil {
+		srv.SetWritable(false)
+		fmt.Fprintf(os.Stderr, "mcp: %v; live events stopped\n", err)
+	}
+}
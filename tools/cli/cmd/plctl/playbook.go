@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/playbook"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+func runPlaybook(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: plctl playbook list | show <name> | run <name|file> [--yes] [--dry-run] [-o dir] [--<param> value ...]")
+	}
+	switch args[0] {
+	case "list":
+		for _, name := range playbook.Builtins() {
+			pb, err := playbook.Load(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-22s %s\n", ui.PromptStyle.Render(pb.Name), ui.DimStyle.Render(pb.Description))
+		}
+		return nil
+	case "show":
+		if len(args) < 2 {
+			return errors.New("usage: plctl playbook show <name|file>")
+		}
+		pb, err := playbook.Load(args[1])
+		if err != nil {
+			return err
+		}
+		printPlaybook(pb)
+		return nil
+	case "run":
+		return runPlaybookRun(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown playbook subcommand %q", args[0])
+}
+
+func printPlaybook(pb *playbook.Playbook) {
+	fmt.Println(ui.TitleStyle.Render(fmt.Sprintf("%s v%s", pb.Name, pb.Version)))
+	fmt.Println(pb.Description)
+	fmt.Println()
+	fmt.Println(ui.HeaderStyle.Render("Params"))
+	for _, p := range pb.Params {
+		extra := ""
+		if p.Required {
+			extra = " (required)"
+		} else if p.Default != "" {
+			extra = fmt.Sprintf(" (default %s)", p.Default)
+		}
+		fmt.Printf("  --%-10s %s%s\n", p.Name, p.Description, ui.DimStyle.Render(extra))
+	}
+	fmt.Println()
+	fmt.Println(ui.HeaderStyle.Render("Steps"))
+	for i, s := range pb.Steps {
+		when := ""
+		if s.When != "" {
+			when = ui.DimStyle.Render(fmt.Sprintf(" (when --%s)", s.When))
+		}
+		fmt.Printf("  %d. %-18s %s%s\n", i+1, s.Name, s.Action, when)
+	}
+}
+
+// parsePlaybookArgs separates runner flags from --<param> values.
+func parsePlaybookArgs(args []string) (name string, params map[string]string, yes, dryRun bool, out string, err error) {
+	params = make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			if name != "" {
+				return "", nil, false, false, "", fmt.Errorf("unexpected argument %q", a)
+			}
+			name = a
+			continue
+		}
+		key, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		switch key {
+		case "y", "yes", "dry-run":
+			// Boolean flags take no separate value but accept --yes=false.
+			on := true
+			if hasVal {
+				if on, err = strconv.ParseBool(val); err != nil {
+					return "", nil, false, false, "", fmt.Errorf("invalid value %q for flag --%s", val, key)
+				}
+			}
+			if key == "dry-run" {
+				dryRun = on
+			} else {
+				yes = on
+			}
+			continue
+		}
+		if !hasVal {
+			if i+1 >= len(args) {
+				return "", nil, false, false, "", fmt.Errorf("flag --%s needs a value", key)
+			}
+			i++
+			val = args[i]
+		}
+		if key == "o" || key == "out" {
+			out = val
+			continue
+		}
+		params[key] = val
+	}
+	if name == "" {
+		return "", nil, false, false, "", errors.New("playbook name is required")
+	}
+	return name, params, yes, dryRun, out, nil
+}
+
+func runPlaybookRun(ctx context.Context, args []string) error {
+	name, given, yes, dryRun, outDir, err := parsePlaybookArgs(args)
+	if err != nil {
+		return err
+	}
+	pb, err := playbook.Load(name)
+	if err != nil {
+		return err
+	}
+	params, err := pb.ResolveParams(given)
+	if err != nil {
+		return err
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	if !dryRun && !confirmTarget(apiURL) {
+		return nil
+	}
+
+	if outDir == "" {
+		outDir = fmt.Sprintf("incident-%s-%s", pb.Name, time.Now().UTC().Format("20060102T150405Z"))
+	}
+	if err := os.MkdirAll(outDir, 0o700); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(outDir, "playbook.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	fmt.Fprintln(os.Stderr, ui.TitleStyle.Render(fmt.Sprintf("Running %s v%s", pb.Name, pb.Version)))
+	r := &playbook.Runner{
+		Ops:         client,
+		OutDir:      outDir,
+		Log:         logFile,
+		DryRun:      dryRun,
+		Version:     version,
+		Target:      apiURL,
+		Environment: os.Getenv("ENVIRONMENT"),
+	}
+	if !yes {
+		r.Confirm = func(step playbook.Step, args map[string]string) bool {
+			return confirm(fmt.Sprintf("Run step %s (%s%s)?", step.Name, step.Action, formatArgs(args)))
+		}
+	}
+
+	results, runErr := r.Run(ctx, pb, params)
+
+	columns := []ui.Column{
+		{Header: "Step", Width: 18},
+		{Header: "Action", Width: 16},
+		{Header: "Status", Width: 9},
+		{Header: "Summary", Width: 50},
+	}
+	rows := make([][]string, len(results))
+	for i, res := range results {
+		summary := res.Summary
+		if res.Error != "" {
+			summary = res.Error
+		}
+		rows[i] = []string{res.Step, res.Action, res.Status, summary}
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprint(os.Stderr, ui.RenderTable(columns, rows))
+	fmt.Fprintln(os.Stderr, ui.DimStyle.Render("\nOutput: "+outDir))
+	return runErr
+}
+
+func formatArgs(args map[string]string) string {
+	keys := make([]string, 0, len(args))
+	for k, v := range args {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%s", k, args[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/private-landing/cli/internal/probe"
+	"github.com/private-landing/cli/internal/userauth"
+)
+
+// runProbe logs a test account in and out on an interval and confirms the
+// login event and session revocation through the ops API. Each cycle is
+// printed as a JSON line; --listen also serves Prometheus metrics.
+func runProbe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Minute, "time between cycles")
+	once := fs.Bool("once", false, "run one cycle and exit non-zero if it failed")
+	email := fs.String("email", os.Getenv("PLCTL_PROBE_EMAIL"), "test account email (default $PLCTL_PROBE_EMAIL)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of $PLCTL_PROBE_PASSWORD")
+	listen := fs.String("listen", "", "serve Prometheus metrics at /metrics on `address` (host:port or unix:///path, loopback only without --allow-remote)")
+	allowRemote := fs.Bool("allow-remote", false, "allow a non-loopback TCP --listen address; metrics are served without authentication")
+	wait := fs.Duration("wait", 30*time.Second, "how long to wait for the event and session checks to settle")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("probe: --email or PLCTL_PROBE_EMAIL is required")
+	}
+	password := os.Getenv("PLCTL_PROBE_PASSWORD")
+	if *passwordStdin {
+		var err error
+		if password, err = readPassword("", bufio.NewReader(os.Stdin)); err != nil {
+			return err
+		}
+	}
+	if password == "" {
+		return errors.New("probe: set PLCTL_PROBE_PASSWORD or use --password-stdin")
+	}
+	if *interval < time.Minute && !*once {
+		// The login route allows 5 attempts per 5 minutes per IP.
+		fmt.Fprintln(os.Stderr, "probe: WARNING: intervals under 1m will trip the login rate limit")
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	ops := resolveTransport(client)
+	defer ops.Close()
+	p := &probe.Prober{
+		NewAuth:  func() probe.Auth { return userauth.NewClient(apiURL, userauth.NewJar()) },
+		Ops:      ops,
+		Email:    *email,
+		Password: password,
+		Wait:     *wait,
+	}
+
+	metrics := &probe.Metrics{}
+	if *listen != "" {
+		ln, err := listenGateway(*listen, *allowRemote)
+		if err != nil {
+			return err
+		}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics)
+		srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go srv.Serve(ln)
+		defer srv.Close()
+		fmt.Fprintf(os.Stderr, "probe: metrics on %s/metrics\n", *listen)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		r := p.Run(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		metrics.Record(r)
+		if err := out.Encode(r); err != nil {
+			return err
+		}
+		if *once {
+			if !r.OK {
+				return errors.New("probe failed")
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
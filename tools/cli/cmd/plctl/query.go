@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/fleet"
+	"github.com/private-landing/cli/internal/query"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// queryScan caps the events read per target by the TUI query screen.
+const queryScan = 10000
+
+// queryHint is shown under query input fields.
+var queryHint = "  Example:   type ~ \"login.*\" and detail.email endswith \"@corp.com\" and hour(created_at) in 0..6\n" +
+	"  Operators: = != < <= > >= ~ !~ (glob) matches (regexp) contains startswith endswith in [a, b] in 0..6, and or not\n" +
+	"  Fields:    " + strings.Join(query.Fields(), ", ") + "\n" +
+	"  Functions: " + strings.Join(query.Functions(), ", ")
+
+// parseQueryInput parses an optional query typed into field i. On error it
+// puts the input flow back on that field with the bad expression restored
+// and the error pointed at, and returns ok false.
+func (m *model) parseQueryInput(i int) (q *query.Query, ok bool) {
+	src := m.inputs[i]
+	if src == "" {
+		return nil, true
+	}
+	q, err := query.Parse(src)
+	if err == nil {
+		return q, true
+	}
+	msg := err.Error()
+	var qe *query.Error
+	if errors.As(err, &qe) {
+		msg = qe.Caret(src)
+	}
+	m.state = stateInput
+	m.inputs = m.inputs[:i]
+	m.inputField = i
+	m.input.Clear()
+	m.input.Append([]rune(src))
+	m.inputHint = indent(msg) + "\n\n" + queryHint
+	return nil, false
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
+
+// fetchQuery pages events since since and keeps those matching q, on every
+// fleet target or on the model's reader.
+func (m model) fetchQuery(q *query.Query, since string) tea.Cmd {
+	filter := "query " + q.String()
+	targets, r := m.targets, m.reader
+	return func() tea.Msg {
+		if targets == nil {
+			c, err := collect(q, since, queryScan)(context.Background(), r)
+			msg := eventsMsg{events: c.events, err: err, filter: filter, scan: queryScan}
+			if c.partial {
+				msg.partial = []string{""}
+			}
+			return msg
+		}
+		results, partial := splitCollected(doTargets(context.Background(), targets, collect(q, since, queryScan)))
+		rows, failed := fleet.MergeEvents(results)
+		if err := allFailed(failed, len(targets)); err != nil {
+			return eventsMsg{err: err, filter: filter}
+		}
+		events, names := split(rows)
+		return eventsMsg{events: events, targets: names, failed: failed, filter: filter, partial: partial, scan: queryScan}
+	}
+}
+
+// renderPartial notes the targets whose events list stopped at the scan
+// cap, so older matches may be missing.
+func renderPartial(targets []string, scan int) string {
+	var b strings.Builder
+	for _, t := range targets {
+		if t == "" {
+			t = "source"
+		}
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%s: only the newest %d events searched\n", t, scan)))
+	}
+	return b.String()
+}
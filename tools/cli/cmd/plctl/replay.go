@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/gateway"
+	"github.com/private-landing/cli/internal/offline"
+	"github.com/private-landing/cli/internal/query"
+	"github.com/private-landing/cli/internal/replay"
+	"github.com/private-landing/cli/internal/rules"
+	"github.com/private-landing/cli/internal/tail"
+	"github.com/private-landing/cli/internal/ui"
+	"golang.org/x/term"
+)
+
+const (
+	// replayFrame is how often the TUI advances the replay clock.
+	replayFrame = 50 * time.Millisecond
+	// replayBatch caps the events played per frame at max speed.
+	replayBatch = 500
+	// replaySeekStep is the fraction of the recording ←/→ move by.
+	replaySeekStep = 0.05
+)
+
+const replayUsage = "usage: plctl replay [<events.ndjson | export.db | ->] [--speed 10x|max] [--max-gap 1m] [--from ts] [--rules file [--run-actions]] [--where expr] [--listen addr [--allow-remote]] [--headless] [--print events|alerts]"
+
+// runReplay plays recorded events back through the tail view, alert rules
+// and a local gateway as if they were arriving live.
+func runReplay(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speedFlag := fs.String("speed", "1x", "playback speed, e.g. 10x, 0.5x, or max to play without waiting")
+	maxGap := fs.Duration("max-gap", 0, "cut quiet stretches longer than this, in recording time (0 keeps them)")
+	from := fs.String("from", "", "start at a timestamp, or a lookback from the end of the recording (1h); earlier events still warm up the rules")
+	rulesPath := fs.String("rules", "", "evaluate alert rules from this YAML `file`")
+	runActions := fs.Bool("run-actions", false, "perform webhook, file and command rule actions instead of logging them (revoke actions always fail without a live agent)")
+	where := fs.String("where", "", "only show events matching this query expression; rules and the gateway still see every event")
+	listen := fs.String("listen", "", "also publish events to a gateway on this `address` (unix:///path, tcp://host:port or host:port, loopback only without --allow-remote)")
+	allowRemote := fs.Bool("allow-remote", false, "allow a non-loopback TCP --listen address; the gateway has no authentication")
+	headless := fs.Bool("headless", false, "print NDJSON instead of opening the TUI (the default when stdout is not a terminal)")
+	printKind := fs.String("print", "", "headless output: events or alerts (default alerts with --rules, otherwise events)")
+	// Accept the source before or after the flags.
+	var src string
+	if len(args) > 0 && (args[0] == "-" || !strings.HasPrefix(args[0], "-")) {
+		src, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case src == "" && fs.NArg() == 1:
+		src = fs.Arg(0)
+	case src == "" && fs.NArg() == 0 && offlinePath != "":
+		src = offlinePath
+	case src == "" || fs.NArg() > 0:
+		return errors.New(replayUsage)
+	}
+
+	speed, err := replay.ParseSpeed(*speedFlag)
+	if err != nil {
+		return err
+	}
+	var match *query.Query
+	if *where != "" {
+		match, err = query.Parse(*where)
+		var qe *query.Error
+		if errors.As(err, &qe) {
+			return errors.New("--where:\n" + qe.Caret(*where))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	events, err := loadReplay(ctx, src)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("%s: no events to replay", src)
+	}
+
+	// Like watch --replay, a replay only logs rule actions unless asked, so
+	// trying rules against a recording never fires real webhooks.
+	s := &replaySession{name: src, player: replay.NewPlayer(events, speed, *maxGap), dryRun: !*runActions}
+	if *rulesPath != "" {
+		f, err := rules.Load(*rulesPath)
+		if err != nil {
+			return err
+		}
+		s.rules = f.Rules
+	}
+	if *from != "" {
+		start, err := api.ParseSince(*from, s.player.End())
+		if err != nil {
+			return err
+		}
+		t, _ := api.ParseTimestamp(start)
+		s.player.Seek(t)
+	}
+
+	if *listen != "" {
+		ln, err := listenGateway(*listen, *allowRemote)
+		if err != nil {
+			return err
+		}
+		s.hub = gateway.NewHub()
+		s.hub.SetUpstream(true)
+		srv := &http.Server{Handler: s.hub.Handler(nil), ReadHeaderTimeout: 10 * time.Second}
+		go srv.Serve(ln)
+		defer srv.Close()
+		fmt.Fprintf(os.Stderr, "replay: gateway listening on %s (GET /events?types=..., /healthz)\n", *listen)
+	}
+
+	if *headless || !term.IsTerminal(int(os.Stdout.Fd())) {
+		switch *printKind {
+		case "":
+			*printKind = "events"
+			if s.rules != nil {
+				*printKind = "alerts"
+			}
+		case "events", "alerts":
+		default:
+			return fmt.Errorf("--print must be events or alerts, got %q", *printKind)
+		}
+		s.log = os.Stderr
+		s.rebuild()
+		return s.runHeadless(ctx, os.Stdout, *printKind == "alerts", match)
+	}
+	m, err := replayModel(s, match)
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(m).Run()
+	return err
+}
+
+// loadReplay reads NDJSON from a file or stdin ("-"), or every event from
+// a database export or archive, oldest first.
+func loadReplay(ctx context.Context, path string) ([]api.Event, error) {
+	if path == "-" {
+		return replay.Load(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(1); len(head) == 1 && head[0] == '{' {
+		events, err := replay.Load(br)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return events, nil
+	}
+	db, err := offline.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither NDJSON events nor a database export: %w", path, err)
+	}
+	defer db.Close()
+	events, err := api.AllEvents(ctx, db, api.EventsParams{Since: "1970-01-01T00:00:00Z"})
+	slices.Reverse(events)
+	return events, err
+}
+
+// replaySession is a recording being played into the tail view, the alert
+// rules and, optionally, a gateway hub.
+type replaySession struct {
+	name   string
+	player *replay.Player
+	rules  []rules.Rule
+	dryRun bool
+	hub    *gateway.Hub
+	log    io.Writer // action log: stderr headless, last in the TUI
+	last   *lastLine // the TUI's action log, shown under the scrubber
+
+	engine   *rules.Engine
+	dispatch *rules.Dispatcher
+	alerts   []rules.Alert // fired up to the player's position
+	lastErr  error         // most recent action failure
+	seq      int           // invalidates ticks scheduled before a pause or seek
+	lastTick time.Time
+}
+
+// lastLine is an io.Writer keeping only the latest line written, for the
+// rule action outcomes the TUI shows in place of a terminal log.
+type lastLine struct {
+	mu   sync.Mutex
+	line string
+}
+
+func (l *lastLine) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.line = strings.TrimSpace(string(p))
+	return len(p), nil
+}
+
+func (l *lastLine) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.line
+}
+
+// rebuild resets the rules and replays the events already played through
+// them silently, so seeking never re-runs actions or republishes events.
+func (s *replaySession) rebuild() {
+	s.alerts, s.lastErr = nil, nil
+	if s.rules == nil {
+		return
+	}
+	s.engine = rules.NewEngine(s.rules)
+	s.dispatch = rules.NewDispatcher(nil, s.dryRun, s.log)
+	for _, e := range s.player.Played() {
+		s.alerts = append(s.alerts, s.engine.Evaluate(e)...)
+	}
+}
+
+// feed passes a newly played event to the gateway and the rules, returning
+// the alerts it fires.
+func (s *replaySession) feed(e api.Event) []rules.Alert {
+	if s.hub != nil {
+		s.hub.Publish(e)
+	}
+	if s.engine == nil {
+		return nil
+	}
+	alerts := s.engine.Evaluate(e)
+	s.alerts = append(s.alerts, alerts...)
+	return alerts
+}
+
+// runActions runs the actions of the rule that fired a.
+func (s *replaySession) runActions(ctx context.Context, a rules.Alert) error {
+	return s.actions()(ctx, a)
+}
+
+// actions returns runActions bound to the current rules and dispatcher, so
+// it can run on a tea.Cmd goroutine while a seek rebuilds the session.
+func (s *replaySession) actions() func(context.Context, rules.Alert) error {
+	rs, d := s.rules, s.dispatch
+	return func(ctx context.Context, a rules.Alert) error {
+		for _, r := range rs {
+			if r.Name == a.Rule {
+				return d.Dispatch(ctx, r, a)
+			}
+		}
+		return nil
+	}
+}
+
+// runHeadless plays the recording in real time at the player's speed and
+// writes each event, or each alert, as a JSON line. The output depends only
+// on the recording and the rules, so it is the same at any speed.
+func (s *replaySession) runHeadless(ctx context.Context, w io.Writer, alertsOnly bool, match *query.Query) error {
+	out := json.NewEncoder(w)
+	p := s.player
+	for !p.Done() {
+		if wait := p.Wait(); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+		}
+		for _, e := range p.Step() {
+			alerts := s.feed(e)
+			if !alertsOnly && (match == nil || match.Match(e)) {
+				if err := out.Encode(e); err != nil {
+					return err
+				}
+			}
+			for _, a := range alerts {
+				if alertsOnly {
+					if err := out.Encode(a); err != nil {
+						return err
+					}
+				}
+				if err := s.runActions(ctx, a); err != nil {
+					fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type replayTickMsg struct {
+	seq int
+	at  time.Time
+}
+
+type replayActionMsg struct {
+	err error
+}
+
+// replayModel opens the tail view on a replay instead of a live stream.
+func replayModel(s *replaySession, match *query.Query) (model, error) {
+	// Action outcomes, dry runs included, go under the scrubber since the
+	// TUI owns the terminal.
+	s.last = &lastLine{}
+	s.log = s.last
+	m := model{state: stateTailEvents, replay: s, tailMatch: match}
+	if err := m.startTail(""); err != nil {
+		return m, err
+	}
+	m.seekReplay(s.player.Clock())
+	return m, nil
+}
+
+// tick schedules the next frame.
+func (s *replaySession) tick() tea.Cmd {
+	seq := s.seq
+	return tea.Tick(replayFrame, func(t time.Time) tea.Msg { return replayTickMsg{seq: seq, at: t} })
+}
+
+// restart drops any scheduled frame and, unless paused or done, schedules a
+// new one timed from now.
+func (s *replaySession) restart() tea.Cmd {
+	s.seq++
+	s.lastTick = time.Now()
+	if s.player.Paused() || s.player.Done() {
+		return nil
+	}
+	return s.tick()
+}
+
+func (m model) updateReplay(msg tea.Msg) (tea.Model, tea.Cmd) {
+	s := m.replay
+	switch msg := msg.(type) {
+	case replayActionMsg:
+		s.lastErr = msg.err
+		return m, nil
+	case replayTickMsg:
+		if msg.seq != s.seq {
+			return m, nil
+		}
+		p := s.player
+		var events []api.Event
+		if p.Speed() == replay.Max {
+			for len(events) < replayBatch && !p.Done() {
+				events = append(events, p.Advance(0)...)
+			}
+		} else {
+			events = p.Advance(msg.at.Sub(s.lastTick))
+		}
+		s.lastTick = msg.at
+		cmds := m.playReplay(events)
+		if !p.Done() {
+			cmds = append(cmds, s.tick())
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+// playReplay shows newly played events and runs the actions of any alerts
+// they fire.
+func (m *model) playReplay(events []api.Event) []tea.Cmd {
+	s := m.replay
+	run := s.actions()
+	var cmds []tea.Cmd
+	for _, e := range events {
+		for _, a := range s.feed(e) {
+			cmds = append(cmds, func() tea.Msg {
+				return replayActionMsg{err: run(context.Background(), a)}
+			})
+		}
+		if m.tailMatch == nil || m.tailMatch.Match(e) {
+			m.pushTail(e)
+		}
+	}
+	return cmds
+}
+
+// seekReplay moves the replay to t and rebuilds the scrollback and rules
+// from the events before it.
+func (m *model) seekReplay(t time.Time) {
+	s := m.replay
+	s.player.Seek(t)
+	s.rebuild()
+	m.tailBuf = tail.NewBuffer(m.tailBuf.Cap())
+	m.tailCursor, m.tailTop, m.tailFollow = 0, 0, true
+	for _, e := range s.player.Played() {
+		if m.tailMatch == nil || m.tailMatch.Match(e) {
+			m.pushTail(e)
+		}
+	}
+}
+
+// handleReplayKey handles playback keys, reporting false for keys the tail
+// view handles itself.
+func (m model) handleReplayKey(key string) (tea.Model, tea.Cmd, bool) {
+	s := m.replay
+	p := s.player
+	span := p.End().Sub(p.Start())
+	seekBy := func(f float64) {
+		m.seekReplay(p.Clock().Add(time.Duration(f * float64(span))))
+	}
+	switch key {
+	case " ", "p":
+		if p.Paused() {
+			p.Resume()
+		} else {
+			p.Pause()
+		}
+	case "left", "h":
+		seekBy(-replaySeekStep)
+	case "right", "l":
+		seekBy(replaySeekStep)
+	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		m.seekReplay(p.Start().Add(time.Duration(float64(key[0]-'0') / 10 * float64(span))))
+	case "+", "=":
+		if p.Speed() != replay.Max {
+			p.SetSpeed(p.Speed() * 2)
+		}
+	case "-":
+		if p.Speed() == replay.Max {
+			p.SetSpeed(100)
+		} else {
+			p.SetSpeed(p.Speed() / 2)
+		}
+	case ".":
+		if !p.Paused() {
+			p.Pause()
+		}
+		return m, tea.Batch(append(m.playReplay(p.Step()), s.restart())...), true
+	default:
+		return m, nil, false
+	}
+	return m, s.restart(), true
+}
+
+// viewReplayBar renders the timeline scrubber: played and remaining time,
+// alerts fired so far as red marks, the replay clock, position and speed.
+func (m model) viewReplayBar() string {
+	s := m.replay
+	p := s.player
+	width := 40
+	if m.width > 0 {
+		width = min(max(m.width-60, 10), 80)
+	}
+	cells := make([]string, width)
+	head := int(p.Progress() * float64(width-1))
+	for i := range cells {
+		switch {
+		case i < head:
+			cells[i] = ui.PromptStyle.Render("━")
+		case i == head:
+			cells[i] = ui.PromptStyle.Render("●")
+		default:
+			cells[i] = ui.DimStyle.Render("─")
+		}
+	}
+	if span := p.End().Sub(p.Start()); span > 0 {
+		for _, a := range s.alerts {
+			i := int(float64(a.FiredAt.Sub(p.Start())) / float64(span) * float64(width-1))
+			if i >= 0 && i < width && i != head {
+				cells[i] = ui.ErrorStyle.Render("▲")
+			}
+		}
+	}
+	state := "▶"
+	switch {
+	case p.Done():
+		state = "■"
+	case p.Paused():
+		state = "❚❚"
+	}
+	line := fmt.Sprintf("%s %s %s  %d/%d  %s", state, strings.Join(cells, ""),
+		p.Clock().UTC().Format(time.DateTime), p.Pos(), p.Len(), replay.FormatSpeed(p.Speed()))
+	if n := len(s.alerts); n > 0 {
+		a := s.alerts[n-1]
+		group := a.GroupKey()
+		if group != "" {
+			group = " " + group
+		}
+		line += "\n" + ui.ErrorStyle.Render(fmt.Sprintf("%d alert(s); last: %s%s (%d events) at %s",
+			n, a.Rule, group, a.Count, a.FiredAt.UTC().Format(time.TimeOnly)))
+	}
+	if s.lastErr != nil {
+		line += "\n" + ui.ErrorStyle.Render(fmt.Sprintf("action failed: %v", s.lastErr))
+	} else if s.last != nil && s.last.String() != "" {
+		line += "\n" + ui.DimStyle.Render("last action: "+s.last.String())
+	}
+	return line
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/private-landing/cli/internal/report"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// runReport builds a security digest. It is read-only and non-interactive so
+// it can run from cron, e.g. weekly:
+//
+//	0 7 * * 1  plctl report --since 7d --format html -o weekly.html
+func runReport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.String("since", "7d", "report period: timestamp or lookback (7d, 24h)")
+	format := fs.String("format", "markdown", "output format: markdown or html")
+	out := fs.String("o", "", "write to `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	b := &report.Builder{Ops: client, Target: apiURL}
+	r, err := b.Build(ctx, *since)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := report.Render(w, r, *format); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintln(os.Stderr, ui.DimStyle.Render("Wrote "+*out))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// streamHandlers are the followEvents callbacks. Connect and Disconnect are
+// optional.
+type streamHandlers struct {
+	Connect    func(*api.Subscription)
+	Event      func(api.Event)
+	Disconnect func(error)
+}
+
+// followEvents keeps an /ops/ws subscription open across disconnects,
+// reconnecting with exponential backoff (1s doubling to 1m, reset after a
+// successful connect). It returns nil when ctx is cancelled and
+// api.ErrCredentialRevoked when the server revokes the agent key.
+func followEvents(ctx context.Context, name string, client *api.Client, types, extraCaps []string, h streamHandlers) error {
+	backoff := time.Second
+	for {
+		connected, err := followOnce(ctx, client, types, extraCaps, h)
+		if connected && h.Disconnect != nil {
+			h.Disconnect(err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, api.ErrCredentialRevoked) {
+			return err
+		}
+		if connected {
+			backoff = time.Second
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v (reconnecting in %s)\n", name, err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func followOnce(ctx context.Context, client *api.Client, types, extraCaps []string, h streamHandlers) (bool, error) {
+	sub, err := client.Subscribe(ctx, types, extraCaps...)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Close()
+	if h.Connect != nil {
+		h.Connect(sub)
+	}
+	for {
+		p, err := sub.Next(ctx)
+		if err != nil {
+			return true, err
+		}
+		h.Event(p.Event())
+	}
+}
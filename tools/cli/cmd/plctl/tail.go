@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/tail"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+var matchStyle = lipgloss.NewStyle().Reverse(true)
+
+// tailBufferSize reads PLCTL_TAIL_BUFFER, defaulting to tail.DefaultSize.
+func tailBufferSize() (int, error) {
+	v := os.Getenv("PLCTL_TAIL_BUFFER")
+	if v == "" {
+		return tail.DefaultSize, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("PLCTL_TAIL_BUFFER must be a positive integer, got %q", v)
+	}
+	return n, nil
+}
+
+// startTail resets the scrollback and opens the tee file, if any.
+func (m *model) startTail(teePath string) error {
+	size, err := tailBufferSize()
+	if err != nil {
+		return err
+	}
+	m.tailBuf = tail.NewBuffer(size)
+	m.tailCursor, m.tailTop = 0, 0
+	m.tailFollow = true
+	m.tailSearching = false
+	m.tailQuery.Clear()
+	m.tailTeeErr = nil
+	if teePath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(teePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("tee: %w", err)
+	}
+	m.tailTee = f
+	m.tailTeePath = teePath
+	return nil
+}
+
+// pushTail records an event, teeing it first so the file has everything
+// even while the view is paused.
+func (m *model) pushTail(e api.Event) {
+	if m.tailTee != nil {
+		if err := json.NewEncoder(m.tailTee).Encode(e); err != nil {
+			m.tailTeeErr = err
+			m.tailTee.Close()
+			m.tailTee = nil
+		}
+	}
+	m.shiftTail(m.tailBuf.Push(e))
+}
+
+// shiftTail keeps the cursor on the same event after evicted events drop
+// off the front, or on the newest event when following.
+func (m *model) shiftTail(evicted int) {
+	if m.tailFollow {
+		m.tailCursor = max(m.tailBuf.Len()-1, 0)
+	} else {
+		m.tailCursor = max(m.tailCursor-evicted, 0)
+		m.tailTop = max(m.tailTop-evicted, 0)
+	}
+	m.scrollTail()
+}
+
+// tailRows is the number of event rows that fit on screen, less the
+// replay scrubber's lines.
+func (m model) tailRows() int {
+	if m.height <= 0 {
+		return 20
+	}
+	chrome := 8
+	if m.replay != nil {
+		chrome += 3
+	}
+	return max(m.height-chrome, 5)
+}
+
+// scrollTail moves the viewport so the cursor is visible.
+func (m *model) scrollTail() {
+	rows := m.tailRows()
+	if m.tailCursor < m.tailTop {
+		m.tailTop = m.tailCursor
+	}
+	if m.tailCursor >= m.tailTop+rows {
+		m.tailTop = m.tailCursor - rows + 1
+	}
+	m.tailTop = max(min(m.tailTop, m.tailBuf.Len()-rows), 0)
+}
+
+func (m *model) moveTail(to int) {
+	last := m.tailBuf.Len() - 1
+	m.tailCursor = max(min(to, last), 0)
+	m.tailFollow = m.tailCursor == last && !m.tailBuf.Paused()
+	m.scrollTail()
+}
+
+// findTail moves the cursor to the next match of the current query.
+func (m *model) findTail(from, dir int) {
+	if i, ok := m.tailBuf.Find(m.tailQuery.Value, from, dir); ok {
+		m.moveTail(i)
+	}
+}
+
+func (m model) handleTailView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if m.tailSearching {
+		return m.handleTailSearch(key, msg)
+	}
+	if m.replay != nil {
+		if m2, cmd, ok := m.handleReplayKey(key); ok {
+			return m2, cmd
+		}
+	} else if m.tailBuf == nil || m.tailConn == nil && m.tailBuf.Len() == 0 {
+		// Still connecting, or failed before any event arrived.
+		switch key {
+		case "esc":
+			m.closeTail()
+			m.state = stateMenu
+			m.tailErr = nil
+		case "q":
+			m.closeTail()
+			m.quitting = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "esc":
+		if m.tailQuery.Value != "" {
+			m.tailQuery.Clear()
+			return m, nil
+		}
+		if m.replay != nil {
+			// A replay opens straight into the tail; there is no menu.
+			m.quitting = true
+			return m, tea.Quit
+		}
+		m.closeTail()
+		m.state = stateMenu
+		m.tailBuf = nil
+		m.tailErr = nil
+	case "q":
+		m.closeTail()
+		m.quitting = true
+		return m, tea.Quit
+	case "up", "k":
+		m.moveTail(m.tailCursor - 1)
+	case "down", "j":
+		m.moveTail(m.tailCursor + 1)
+	case "pgup", "b":
+		m.moveTail(m.tailCursor - m.tailRows())
+	case "pgdown", "f":
+		m.moveTail(m.tailCursor + m.tailRows())
+	case "home", "g":
+		m.moveTail(0)
+	case "end", "G":
+		m.moveTail(m.tailBuf.Len() - 1)
+	case " ", "p":
+		if m.tailBuf.Paused() {
+			m.tailFollow = m.tailCursor == m.tailBuf.Len()-1
+			m.shiftTail(m.tailBuf.Resume())
+		} else {
+			m.tailBuf.Pause()
+			m.tailFollow = false
+		}
+	case "/":
+		m.tailSearching = true
+		m.tailQuery.Clear()
+	case "n":
+		m.findTail(m.tailCursor-1, -1)
+	case "N":
+		m.findTail(m.tailCursor+1, 1)
+	case "enter":
+		if m.tailBuf.Len() > 0 {
+			m.openDetail(m.tailBuf.At(m.tailCursor), "", stateTailEvents)
+		}
+	}
+	return m, nil
+}
+
+// handleTailSearch edits the / query, jumping to the newest match as the
+// query changes.
+func (m model) handleTailSearch(key string, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter":
+		m.tailSearching = false
+		return m, nil
+	case "esc":
+		m.tailSearching = false
+		m.tailQuery.Clear()
+		return m, nil
+	case "backspace":
+		m.tailQuery.Backspace()
+	default:
+		m.tailQuery.Append(msg.Runes)
+	}
+	m.findTail(m.tailBuf.Len()-1, -1)
+	return m, nil
+}
+
+func (m model) viewTailEvents() string {
+	var b strings.Builder
+
+	if m.tailErr != nil && (m.tailBuf == nil || m.tailBuf.Len() == 0) {
+		b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.tailErr)))
+		b.WriteString(ui.DimStyle.Render("\n\nesc back • q quit"))
+		return b.String()
+	}
+
+	if m.tailConn == nil && m.tailErr == nil && m.replay == nil {
+		if m.tailChallenge != nil && m.tailChallenge.Required {
+			b.WriteString(ui.DimStyle.Render(
+				fmt.Sprintf("Solved PoW challenge (difficulty %d), connecting...", m.tailChallenge.Difficulty)))
+		} else if m.tailChallenge != nil {
+			b.WriteString(ui.DimStyle.Render("Connecting..."))
+		} else {
+			b.WriteString(ui.DimStyle.Render("Probing for PoW challenge..."))
+		}
+		return b.String()
+	}
+
+	buf := m.tailBuf
+	header := "Tailing events (live)"
+	if m.replay != nil {
+		header = "Replaying " + m.replay.name
+	}
+	if len(m.tailFilter) > 0 {
+		header += fmt.Sprintf("  [%s]", strings.Join(m.tailFilter, ", "))
+	}
+	if m.tailMatch != nil {
+		header += "  query " + m.tailMatch.String()
+	}
+	b.WriteString(ui.HeaderStyle.Render(header))
+	if buf.Len() > 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("  %d/%d", m.tailCursor+1, buf.Len())))
+	}
+	if buf.Paused() {
+		b.WriteString("  " + matchStyle.Render(fmt.Sprintf(" PAUSED +%d ", buf.Pending())))
+	}
+	if m.tailTee != nil {
+		b.WriteString(ui.DimStyle.Render("  tee " + m.tailTeePath))
+	}
+	if m.tailTeeErr != nil {
+		b.WriteString("  " + ui.ErrorStyle.Render(fmt.Sprintf("tee stopped: %v", m.tailTeeErr)))
+	}
+	if m.tailErr != nil {
+		b.WriteString("  " + ui.ErrorStyle.Render(fmt.Sprintf("disconnected: %v", m.tailErr)))
+	}
+	if m.replay != nil {
+		b.WriteString("\n" + m.viewReplayBar())
+	}
+	b.WriteString("\n\n")
+
+	if buf.Len() == 0 {
+		b.WriteString(ui.DimStyle.Render("Waiting for events..."))
+		b.WriteString("\n")
+	}
+	end := min(m.tailTop+m.tailRows(), buf.Len())
+	for i := m.tailTop; i < end; i++ {
+		b.WriteString(m.renderTailLine(buf.At(i), i == m.tailCursor))
+		b.WriteString("\n")
+	}
+
+	if m.tailSearching {
+		b.WriteString(ui.PromptStyle.Render("\n/") + m.tailQuery.Value + "█")
+		b.WriteString(ui.DimStyle.Render("  enter keep • esc clear"))
+		return b.String()
+	}
+	if m.tailQuery.Value != "" {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("\n/%s  n older • N newer", m.tailQuery.Value)))
+	}
+	if m.replay != nil {
+		b.WriteString(ui.DimStyle.Render("\n↑/↓ scroll • space pause • ←/→ seek • 0-9 jump • +/- speed • . step • / search • enter detail • q quit"))
+		return b.String()
+	}
+	b.WriteString(ui.DimStyle.Render("\n↑/↓ scroll • G follow • space pause • / search • enter detail • esc stop • q quit"))
+	return b.String()
+}
+
+// renderTailLine formats one event row, coloring the type and highlighting
+// search matches. The plain text is cut to the terminal width first so
+// styling never splits an escape sequence.
+func (m model) renderTailLine(e api.Event, selected bool) string {
+	// Extract just the time portion from ISO timestamp
+	ts := e.CreatedAt
+	if len(ts) >= 19 {
+		ts = ts[11:19]
+	}
+	userID := "-"
+	if e.UserID != nil {
+		userID = fmt.Sprintf("user:%d", *e.UserID)
+	}
+	typ := fmt.Sprintf("%-24s", e.Type)
+	rest := fmt.Sprintf("%-16s  %-10s  %s", e.IPAddress, userID, e.ActorID)
+	if m.width > 0 {
+		used := 2 + len(ts) + 2 + len(typ) + 2
+		rest = rest[:max(min(len(rest), m.width-used), 0)]
+	}
+
+	marker := "  "
+	if selected {
+		marker = ui.PromptStyle.Render("▸ ")
+	}
+	q := m.tailQuery.Value
+	return marker + ts + "  " + highlight(typ, q, eventTypeStyle(e.Type)) + "  " + highlight(rest, q, lipgloss.NewStyle())
+}
+
+// highlight renders s in base, with case-insensitive occurrences of q
+// reversed.
+func highlight(s, q string, base lipgloss.Style) string {
+	if q == "" {
+		return base.Render(s)
+	}
+	var b strings.Builder
+	lower, lq := strings.ToLower(s), strings.ToLower(q)
+	for {
+		i := strings.Index(lower, lq)
+		if i < 0 {
+			break
+		}
+		b.WriteString(base.Render(s[:i]))
+		b.WriteString(matchStyle.Render(s[i : i+len(lq)]))
+		s, lower = s[i+len(lq):], lower[i+len(lq):]
+	}
+	b.WriteString(base.Render(s))
+	return b.String()
+}
+
+// eventTypeStyle colors failures red, successes green and session and agent
+// lifecycle events in the active color.
+func eventTypeStyle(t string) lipgloss.Style {
+	switch {
+	case strings.HasSuffix(t, ".failure"), strings.HasSuffix(t, ".failed"), strings.HasSuffix(t, "_failure"),
+		strings.HasSuffix(t, ".denied"), strings.HasSuffix(t, ".unauthorized"), strings.HasSuffix(t, ".reject"):
+		return ui.ErrorStyle
+	case strings.HasSuffix(t, ".success"), strings.HasSuffix(t, ".granted"):
+		return ui.SuccessStyle
+	case strings.HasPrefix(t, "session."), strings.HasPrefix(t, "agent."):
+		return ui.PromptStyle
+	}
+	return lipgloss.NewStyle()
+}
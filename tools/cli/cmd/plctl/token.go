@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/jsontree"
+	"github.com/private-landing/cli/internal/ui"
+	"github.com/private-landing/cli/internal/userauth"
+)
+
+const tokenUsage = "usage: plctl token decode|verify <jwt|-> [--secret-file file] [--check] [--json]"
+
+// runToken inspects a pasted access or refresh token locally. The token is
+// never sent anywhere; --check sends only its uid and sid to the ops API.
+func runToken(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "decode" && args[0] != "verify") {
+		return errors.New(tokenUsage)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("token "+sub, flag.ContinueOnError)
+	var secretFile *string
+	if sub == "verify" {
+		secretFile = fs.String("secret-file", "", "`file` holding JWT_ACCESS_SECRET or JWT_REFRESH_SECRET, matching the token's typ")
+	}
+	check := fs.Bool("check", false, "look the sid up with the agent key: active, expired, revoked or inactive")
+	asJSON := fs.Bool("json", false, "print the decoded token as JSON")
+	// Accept the token before or after the flags.
+	var raw string
+	rest := args[1:]
+	if len(rest) > 0 && (rest[0] == "-" || !strings.HasPrefix(rest[0], "-")) {
+		raw, rest = rest[0], rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if raw == "" && fs.NArg() > 0 {
+		raw = fs.Arg(0)
+	}
+	if raw == "" {
+		return errors.New(tokenUsage)
+	}
+	if raw == "-" {
+		// Reading from stdin keeps the token out of shell history.
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if raw = strings.TrimSpace(line); raw == "" {
+			return fmt.Errorf("read token from stdin: %w", errors.Join(err, errors.New("empty input")))
+		}
+	}
+
+	tok, err := userauth.ParseToken(raw)
+	if err != nil {
+		return err
+	}
+	out := tokenReport{Header: tok.Header, Claims: tok.Payload}
+	if sub == "verify" {
+		if *secretFile == "" {
+			return errors.New("token verify: --secret-file is required")
+		}
+		secret, err := os.ReadFile(*secretFile)
+		if err != nil {
+			return err
+		}
+		verr := tok.Verify([]byte(strings.TrimRight(string(secret), "\r\n")))
+		out.Verified = verr == nil
+		if errors.Is(verr, userauth.ErrBadSignature) && tok.Claims.Typ != "" {
+			verr = fmt.Errorf("%w (a %s token is signed with JWT_%s_SECRET)", verr, tok.Claims.Typ, strings.ToUpper(tok.Claims.Typ))
+		}
+		if verr != nil {
+			out.VerifyError = verr.Error()
+		}
+	}
+	if *check {
+		if out.Session, err = lookupSession(ctx, &tok.Claims); err != nil {
+			return fmt.Errorf("--check: %w", err)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		printToken(tok, sub == "verify", out)
+	}
+	switch {
+	case sub == "verify" && !out.Verified:
+		return fmt.Errorf("token verify: %s", out.VerifyError)
+	case out.Session != nil && out.Session.Status != userauth.SessionActive:
+		return fmt.Errorf("session %s is %s", tok.Claims.SID, out.Session.Status)
+	}
+	return nil
+}
+
+type tokenReport struct {
+	Header      map[string]any         `json:"header"`
+	Claims      map[string]any         `json:"claims"`
+	Verified    bool                   `json:"verified,omitempty"`
+	VerifyError string                 `json:"verify_error,omitempty"`
+	Session     *userauth.SessionState `json:"session,omitempty"`
+}
+
+func printToken(tok *userauth.Token, verify bool, out tokenReport) {
+	section := func(name string, m map[string]any) {
+		fmt.Println(ui.HeaderStyle.Render(name))
+		b, _ := json.Marshal(m)
+		fmt.Print(jsontree.Render(jsontree.ParseOrText(string(b)), 0))
+	}
+	section("Header", tok.Header)
+	section("Claims", tok.Payload)
+
+	now := time.Now()
+	var times []string
+	for _, t := range []struct {
+		name string
+		at   time.Time
+	}{{"issued", tok.Claims.IssuedAt()}, {"expires", tok.Claims.Expiry()}} {
+		if !t.at.IsZero() {
+			times = append(times, fmt.Sprintf("%s %s (%s)", t.name, t.at.UTC().Format(time.RFC3339), relative(t.at, now)))
+		}
+	}
+	if len(times) > 0 {
+		fmt.Println(ui.DimStyle.Render(strings.Join(times, "  ")))
+	}
+	if exp := tok.Claims.Expiry(); !exp.IsZero() && !exp.After(now) {
+		fmt.Println(ui.ErrorStyle.Render("Token has expired"))
+	}
+
+	if verify {
+		if out.Verified {
+			fmt.Println(ui.SuccessStyle.Render("Signature valid (HS256)"))
+		} else {
+			fmt.Println(ui.ErrorStyle.Render("Signature invalid: " + out.VerifyError))
+		}
+	}
+	if s := out.Session; s != nil {
+		switch s.Status {
+		case userauth.SessionActive:
+			fmt.Println(ui.SuccessStyle.Render(fmt.Sprintf("Session %s is active (expires %s)", tok.Claims.SID, s.Session.ExpiresAt)))
+		default:
+			fmt.Println(ui.ErrorStyle.Render(fmt.Sprintf("Session %s is %s: %s", tok.Claims.SID, s.Status, s.Reason)))
+		}
+	}
+}
+
+// relative formats t against now as "in 14m" or "3h ago".
+func relative(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	if d >= 0 {
+		return "in " + d.String()
+	}
+	return (-d).String() + " ago"
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/rules"
+)
+
+// runWatch evaluates alert rules against the live event stream, or against
+// a recorded one with --replay, and dispatches each alert's actions.
+func runWatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	rulesPath := fs.String("rules", "plctl-rules.yaml", "alert rules file (YAML)")
+	dryRun := fs.Bool("dry-run", false, "log actions without performing them")
+	replay := fs.String("replay", "", "evaluate recorded NDJSON events from `file` instead of the live stream; actions are only logged")
+	runActions := fs.Bool("run-actions", false, "with --replay, perform webhook, file and command actions instead of logging them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := rules.Load(*rulesPath)
+	if err != nil {
+		return err
+	}
+	engine := rules.NewEngine(f.Rules)
+	out := json.NewEncoder(os.Stdout)
+
+	if *replay != "" {
+		in, err := os.Open(*replay)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		// Replays are dry runs unless --run-actions is given, so testing a
+		// rule set against a recording never posts webhooks, runs commands
+		// or writes files. With it, revoke actions fail with ErrNoRevoker.
+		d := rules.NewDispatcher(nil, *dryRun || !*runActions, os.Stderr)
+		alerts, err := rules.Replay(ctx, in, engine, d)
+		for _, a := range alerts {
+			out.Encode(a)
+		}
+		return err
+	}
+
+	if *runActions {
+		return errors.New("--run-actions only applies to --replay")
+	}
+
+	client, apiURL, err := resolveClient()
+	if err != nil {
+		return err
+	}
+	if !*dryRun && hasRevokeAction(f.Rules) && !confirmTarget(apiURL) {
+		return nil
+	}
+
+	d := rules.NewDispatcher(nil, *dryRun, os.Stderr)
+	byName := make(map[string]rules.Rule, len(f.Rules))
+	for _, r := range f.Rules {
+		byName[r.Name] = r
+	}
+
+	onConnect := func(sub *api.Subscription) {
+		// Revocation follows the agent's trust level: only write agents are
+		// granted revoke_session.
+		if sub.Granted.Has("revoke_session") {
+			d.Revoker = client
+		} else {
+			d.Revoker = nil
+			if hasRevokeAction(f.Rules) {
+				fmt.Fprintln(os.Stderr, "watch: agent lacks write trust; revoke actions disabled")
+			}
+		}
+		fmt.Fprintf(os.Stderr, "watch: connected as %s (%d rules)\n", sub.Granted.Agent, len(f.Rules))
+	}
+	onEvent := func(e api.Event) {
+		for _, a := range engine.Evaluate(e) {
+			out.Encode(a)
+			if err := d.Dispatch(ctx, byName[a.Rule], a); err != nil {
+				fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+			}
+		}
+	}
+	return followEvents(ctx, "watch", client, subscriptionTypes(f.Rules), []string{"revoke_session"}, streamHandlers{
+		Connect: onConnect,
+		Event:   onEvent,
+	})
+}
+
+func hasRevokeAction(rs []rules.Rule) bool {
+	for _, r := range rs {
+		for _, a := range r.Actions {
+			if a.Type == "revoke" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subscriptionTypes returns the union of rule type patterns to push down to
+// subscribe_events, or nil (all events) when any rule is unfiltered or uses
+// a pattern the server would reject.
+func subscriptionTypes(rs []rules.Rule) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, r := range rs {
+		if len(r.Match.Type) == 0 {
+			return nil
+		}
+		for _, t := range r.Match.Type {
+			if !api.ValidTypePattern(t) {
+				return nil
+			}
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+	}
+	return types
+}
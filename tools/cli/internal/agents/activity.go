@@ -0,0 +1,126 @@
+package agents
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Activity is one actor's ops footprint over a window, derived from the
+// actor_id of its events.
+type Activity struct {
+	Actor string `json:"actor"`
+	// Name is the agent name for agent:<name> actors.
+	Name       string `json:"name,omitempty"`
+	TrustLevel string `json:"trust_level,omitempty"`
+	// Active is false for agents that were revoked or are otherwise
+	// missing from the current inventory.
+	Active bool `json:"active"`
+
+	Events       int    `json:"events"`
+	Connects     int    `json:"connects"`
+	Disconnects  int    `json:"disconnects"`
+	Granted      int    `json:"capabilities_granted"`
+	Denied       int    `json:"capabilities_denied"`
+	Unauthorized int    `json:"unauthorized"`
+	RateLimited  int    `json:"rate_limited"`
+	Revocations  int    `json:"sessions_revoked"`
+	AuthFailures int    `json:"auth_failures"`
+	LastSeen     string `json:"last_seen,omitempty"`
+}
+
+// Activity statuses.
+const (
+	StatusOK        = "ok"
+	StatusIdle      = "idle"
+	StatusAttention = "attention"
+)
+
+// Status is idle for an agent with no events in the window, attention for
+// any denial, unauthorized message, rate limit or auth failure, else ok.
+func (a Activity) Status() string {
+	switch {
+	case a.Denied+a.Unauthorized+a.RateLimited+a.AuthFailures > 0:
+		return StatusAttention
+	case a.Events == 0:
+		return StatusIdle
+	}
+	return StatusOK
+}
+
+// Summarize aggregates events by actor. Every agent in inventory gets an
+// entry, so agents without events show up as idle. Agents come first in
+// name order, followed by other actors such as the app itself.
+//
+// agent.auth_failure is recorded against the app actor because a rejected
+// key names no agent; it is counted there.
+func Summarize(inventory []api.Agent, events []api.Event) []Activity {
+	byActor := make(map[string]*Activity)
+	for _, ag := range inventory {
+		byActor[ActorID(ag.Name)] = &Activity{Actor: ActorID(ag.Name), Name: ag.Name, TrustLevel: ag.TrustLevel, Active: true}
+	}
+	for _, e := range events {
+		if e.ActorID == "" {
+			continue
+		}
+		a := byActor[e.ActorID]
+		if a == nil {
+			a = &Activity{Actor: e.ActorID}
+			if name, ok := strings.CutPrefix(e.ActorID, "agent:"); ok {
+				a.Name = name
+			}
+			byActor[e.ActorID] = a
+		}
+		a.Events++
+		if api.CompareTimestamps(e.CreatedAt, a.LastSeen) > 0 {
+			a.LastSeen = e.CreatedAt
+		}
+		switch e.Type {
+		case "ws.connect":
+			a.Connects++
+		case "ws.disconnect":
+			a.Disconnects++
+		case "capability.granted":
+			a.Granted++
+		case "capability.denied":
+			a.Denied++
+		case "ws.unauthorized":
+			a.Unauthorized++
+		case "rate_limit.reject":
+			a.RateLimited++
+		case "session.ops_revoke":
+			a.Revocations++
+		case "agent.auth_failure":
+			a.AuthFailures++
+		}
+	}
+
+	out := make([]Activity, 0, len(byActor))
+	for _, a := range byActor {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Name != "") != (out[j].Name != "") {
+			return out[i].Name != ""
+		}
+		return out[i].Actor < out[j].Actor
+	})
+	return out
+}
+
+// CollectActivity reads the inventory and every event since the given
+// RFC 3339 time from r and summarizes them. A non-empty actor restricts the
+// events to that actor.
+func CollectActivity(ctx context.Context, r api.Reader, since, actor string) ([]Activity, []api.Event, error) {
+	resp, err := r.ListAgents(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	events, err := api.AllEvents(ctx, r, api.EventsParams{Since: since, ActorID: actor})
+	if err != nil {
+		return nil, nil, err
+	}
+	return Summarize(resp.Agents, events), events, nil
+}
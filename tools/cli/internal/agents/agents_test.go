@@ -0,0 +1,179 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+type fakeOps struct {
+	agents  []api.Agent
+	events  map[string]int // actor_id -> event count
+	created []api.CreateAgentRequest
+	deleted []string
+}
+
+func (f *fakeOps) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{Agents: f.agents}, nil
+}
+
+func (f *fakeOps) CreateAgent(_ context.Context, req api.CreateAgentRequest) (*api.CreateAgentResponse, error) {
+	f.created = append(f.created, req)
+	return &api.CreateAgentResponse{Name: req.Name, TrustLevel: req.TrustLevel, APIKey: "key-" + req.Name}, nil
+}
+
+func (f *fakeOps) DeleteAgent(_ context.Context, name string) (*api.DeleteAgentResponse, error) {
+	f.deleted = append(f.deleted, name)
+	return &api.DeleteAgentResponse{Success: true}, nil
+}
+
+func (f *fakeOps) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	return &api.ListEventsResponse{Events: make([]api.Event, min(f.events[p.ActorID], p.Limit))}, nil
+}
+
+func TestNextName(t *testing.T) {
+	for in, want := range map[string]string{
+		"siem":      "siem-v2",
+		"siem-v2":   "siem-v3",
+		"ops-v9":    "ops-v10",
+		"ops-vnext": "ops-vnext-v2",
+	} {
+		if got := NextName(in); got != want {
+			t.Errorf("NextName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	desc := "SIEM export"
+	ops := &fakeOps{agents: []api.Agent{{Name: "siem", TrustLevel: "write", Description: &desc}}}
+	var stored string
+	r := &Rotator{
+		Ops:    ops,
+		Verify: func(_ context.Context, key string) (string, error) { return strings.TrimPrefix(key, "key-"), nil },
+		Store:  func(name, key string) error { stored = name + "=" + key; return nil },
+	}
+
+	rot, err := r.Rotate(context.Background(), "siem")
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	want := api.CreateAgentRequest{Name: "siem-v2", TrustLevel: "write", Description: desc}
+	if !reflect.DeepEqual(ops.created, []api.CreateAgentRequest{want}) {
+		t.Errorf("created = %+v", ops.created)
+	}
+	if stored != "siem-v2=key-siem-v2" {
+		t.Errorf("stored = %q", stored)
+	}
+	if !reflect.DeepEqual(ops.deleted, []string{"siem"}) {
+		t.Errorf("deleted = %v, want old agent only", ops.deleted)
+	}
+	if rot.New.Name != "siem-v2" {
+		t.Errorf("new = %q", rot.New.Name)
+	}
+}
+
+func TestRotateRollsBackOnFailedVerify(t *testing.T) {
+	ops := &fakeOps{agents: []api.Agent{{Name: "siem", TrustLevel: "read"}}}
+	r := &Rotator{
+		Ops:    ops,
+		Verify: func(context.Context, string) (string, error) { return "", errors.New("handshake refused") },
+		Store:  func(string, string) error { t.Fatal("Store called after failed verify"); return nil },
+	}
+	if _, err := r.Rotate(context.Background(), "siem"); err == nil {
+		t.Fatal("expected error")
+	}
+	if !reflect.DeepEqual(ops.deleted, []string{"siem-v2"}) {
+		t.Errorf("deleted = %v, want only the new credential", ops.deleted)
+	}
+}
+
+func TestRotateRefusesExistingSuccessor(t *testing.T) {
+	ops := &fakeOps{agents: []api.Agent{{Name: "siem"}, {Name: "siem-v2"}}}
+	r := &Rotator{Ops: ops}
+	if _, err := r.Rotate(context.Background(), "siem"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := r.Rotate(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if len(ops.created) != 0 {
+		t.Errorf("created = %v", ops.created)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ops := &fakeOps{
+		agents: []api.Agent{
+			{Name: "siem-export", TrustLevel: "write", CreatedAt: "2026-05-20 00:00:00"},
+			{Name: "old-reader", TrustLevel: "read", CreatedAt: "2025-12-01 00:00:00"},
+			{Name: "Ops_Bot", TrustLevel: "write", CreatedAt: "2026-05-30T00:00:00Z"},
+		},
+		events: map[string]int{"agent:siem-export": 3},
+	}
+	a := &Auditor{Ops: ops, MaxAge: 90 * 24 * time.Hour, Idle: 30 * 24 * time.Hour, now: func() time.Time { return now }}
+	entries, err := a.Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := func(e Entry) []string {
+		var k []string
+		for _, f := range e.Findings {
+			k = append(k, f.Kind)
+		}
+		return k
+	}
+	if got := kinds(entries[0]); got != nil {
+		t.Errorf("siem-export findings = %v, want none", got)
+	}
+	if got := kinds(entries[1]); !reflect.DeepEqual(got, []string{FindingAge}) {
+		t.Errorf("old-reader findings = %v", got)
+	}
+	if got := kinds(entries[2]); !reflect.DeepEqual(got, []string{FindingName, FindingIdle}) {
+		t.Errorf("Ops_Bot findings = %v", got)
+	}
+	if entries[1].AgeDays != 182 {
+		t.Errorf("age = %d days", entries[1].AgeDays)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	inventory := []api.Agent{{Name: "siem", TrustLevel: "read"}, {Name: "bot", TrustLevel: "write"}}
+	events := []api.Event{
+		{Type: "ws.connect", ActorID: "agent:siem", CreatedAt: "2026-06-01T10:00:00Z"},
+		{Type: "capability.granted", ActorID: "agent:siem", CreatedAt: "2026-06-01T10:00:00Z"},
+		{Type: "ws.disconnect", ActorID: "agent:siem", CreatedAt: "2026-06-01 11:00:00"}, // datetime() format
+		{Type: "capability.denied", ActorID: "agent:old", CreatedAt: "2026-05-01T00:00:00Z"},
+		{Type: "agent.auth_failure", ActorID: "app:private-landing", CreatedAt: "2026-06-01T09:00:00Z"},
+	}
+	got := Summarize(inventory, events)
+
+	var actors []string
+	for _, a := range got {
+		actors = append(actors, a.Actor)
+	}
+	if want := []string{"agent:bot", "agent:old", "agent:siem", "app:private-landing"}; !reflect.DeepEqual(actors, want) {
+		t.Fatalf("actors = %v, want %v", actors, want)
+	}
+
+	bot, old, siem, app := got[0], got[1], got[2], got[3]
+	if bot.Status() != StatusIdle || !bot.Active {
+		t.Errorf("bot = %+v, want active and idle", bot)
+	}
+	if old.Status() != StatusAttention || old.Active || old.Name != "old" {
+		t.Errorf("old = %+v, want revoked agent needing attention", old)
+	}
+	if siem.Connects != 1 || siem.Disconnects != 1 || siem.Granted != 1 || siem.LastSeen != "2026-06-01 11:00:00" || siem.Status() != StatusOK {
+		t.Errorf("siem = %+v", siem)
+	}
+	if app.AuthFailures != 1 || app.Name != "" {
+		t.Errorf("app = %+v", app)
+	}
+}
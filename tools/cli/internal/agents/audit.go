@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// NamePattern is the naming convention: lowercase kebab-case, starting with
+// a letter, e.g. siem-export or ops-bot-v2.
+var NamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// Finding kinds.
+const (
+	FindingAge  = "age"
+	FindingIdle = "idle-write"
+	FindingName = "name"
+)
+
+// AuditOps is the subset of api.Client operations an audit reads.
+type AuditOps interface {
+	ListAgents(ctx context.Context) (*api.ListAgentsResponse, error)
+	ListEvents(ctx context.Context, params api.EventsParams) (*api.ListEventsResponse, error)
+}
+
+// ActorID is the actor_id the server records for an agent's actions.
+func ActorID(name string) string {
+	return "agent:" + name
+}
+
+// Finding is one problem with an agent.
+type Finding struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Entry is one agent's audit result.
+type Entry struct {
+	api.Agent
+	Age      time.Duration `json:"-"`
+	AgeDays  int           `json:"age_days"`
+	Findings []Finding     `json:"findings"`
+}
+
+// Auditor checks the agent inventory against age, activity and naming rules.
+type Auditor struct {
+	Ops AuditOps
+	// MaxAge flags agents created longer ago than this.
+	MaxAge time.Duration
+	// Idle flags write-trust agents with no events in this window.
+	Idle time.Duration
+	// Names is the naming convention; defaults to NamePattern.
+	Names *regexp.Regexp
+
+	now func() time.Time
+}
+
+// Audit returns every agent with its findings, in server order.
+func (a *Auditor) Audit(ctx context.Context) ([]Entry, error) {
+	if a.now == nil {
+		a.now = time.Now
+	}
+	names := a.Names
+	if names == nil {
+		names = NamePattern
+	}
+	now := a.now().UTC()
+
+	resp, err := a.Ops.ListAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(resp.Agents))
+	for i, ag := range resp.Agents {
+		e := Entry{Agent: ag}
+		if created, err := api.ParseTimestamp(ag.CreatedAt); err == nil {
+			e.Age = now.Sub(created)
+			e.AgeDays = int(e.Age.Hours() / 24)
+			if a.MaxAge > 0 && e.Age > a.MaxAge {
+				e.Findings = append(e.Findings, Finding{FindingAge, fmt.Sprintf("created %d days ago", e.AgeDays)})
+			}
+		}
+		if !names.MatchString(ag.Name) {
+			e.Findings = append(e.Findings, Finding{FindingName, "does not match " + names.String()})
+		}
+		if ag.TrustLevel == "write" && a.Idle > 0 {
+			events, err := a.Ops.ListEvents(ctx, api.EventsParams{
+				ActorID: ActorID(ag.Name),
+				Since:   now.Add(-a.Idle).Format(time.RFC3339),
+				Limit:   1,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("events for %s: %w", ag.Name, err)
+			}
+			if len(events.Events) == 0 {
+				e.Findings = append(e.Findings, Finding{FindingIdle, fmt.Sprintf("write trust but no events in %s", formatWindow(a.Idle))})
+			}
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// formatWindow renders whole days as 30d and anything else as a duration.
+func formatWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
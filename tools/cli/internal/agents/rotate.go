@@ -0,0 +1,120 @@
+// Package agents manages the agent credential lifecycle: key rotation and
+// an inventory audit of stale, idle or misnamed credentials.
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// maxNameLen is the server's limit on agent names.
+const maxNameLen = 64
+
+// Ops is the subset of api.Client operations rotation needs. CreateAgent and
+// DeleteAgent require the provisioning secret.
+type Ops interface {
+	ListAgents(ctx context.Context) (*api.ListAgentsResponse, error)
+	CreateAgent(ctx context.Context, req api.CreateAgentRequest) (*api.CreateAgentResponse, error)
+	DeleteAgent(ctx context.Context, name string) (*api.DeleteAgentResponse, error)
+}
+
+// ErrNotFound is returned when the agent to rotate does not exist.
+var ErrNotFound = errors.New("agent not found")
+
+// Rotation is a completed rotation.
+type Rotation struct {
+	Old api.Agent
+	New *api.CreateAgentResponse
+}
+
+// Rotator replaces an agent credential with a successor of the same trust
+// level and description.
+type Rotator struct {
+	Ops Ops
+	// Verify proves the new key works and returns the agent name the
+	// server authenticated it as.
+	Verify func(ctx context.Context, apiKey string) (string, error)
+	// Store persists the new key. It runs before the old credential is
+	// deleted so a failure never leaves the caller without a working key.
+	Store func(name, apiKey string) error
+}
+
+var versionSuffix = regexp.MustCompile(`^(.*)-v(\d+)$`)
+
+// NextName returns the successor name: web-v2 becomes web-v3, anything else
+// gains a -v2 suffix.
+func NextName(name string) string {
+	if m := versionSuffix.FindStringSubmatch(name); m != nil {
+		if n, err := strconv.Atoi(m[2]); err == nil {
+			return fmt.Sprintf("%s-v%d", m[1], n+1)
+		}
+	}
+	return name + "-v2"
+}
+
+// Rotate provisions NextName(name), verifies and stores its key, and then
+// deletes name. If verification or storage fails the new credential is
+// deleted again and the old one is left untouched.
+func (r *Rotator) Rotate(ctx context.Context, name string) (*Rotation, error) {
+	resp, err := r.Ops.ListAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var old *api.Agent
+	existing := make(map[string]bool, len(resp.Agents))
+	for i, a := range resp.Agents {
+		existing[a.Name] = true
+		if a.Name == name {
+			old = &resp.Agents[i]
+		}
+	}
+	if old == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	next := NextName(name)
+	if len(next) > maxNameLen {
+		return nil, fmt.Errorf("successor name %q exceeds %d characters", next, maxNameLen)
+	}
+	if existing[next] {
+		return nil, fmt.Errorf("successor %q already exists; rotate or delete it first", next)
+	}
+
+	req := api.CreateAgentRequest{Name: next, TrustLevel: old.TrustLevel}
+	if old.Description != nil {
+		req.Description = *old.Description
+	}
+	created, err := r.Ops.CreateAgent(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("provision %s: %w", next, err)
+	}
+
+	agent, err := r.Verify(ctx, created.APIKey)
+	if err == nil && agent != next {
+		err = fmt.Errorf("server authenticated the key as %q", agent)
+	}
+	if err != nil {
+		return nil, r.rollback(ctx, next, fmt.Errorf("verify %s: %w", next, err))
+	}
+	if err := r.Store(next, created.APIKey); err != nil {
+		return nil, r.rollback(ctx, next, fmt.Errorf("store key for %s: %w", next, err))
+	}
+
+	rot := &Rotation{Old: *old, New: created}
+	if _, err := r.Ops.DeleteAgent(ctx, name); err != nil {
+		return rot, fmt.Errorf("new key stored, but deleting %s failed: %w", name, err)
+	}
+	return rot, nil
+}
+
+// rollback deletes a successor that failed verification or storage.
+func (r *Rotator) rollback(ctx context.Context, name string, cause error) error {
+	if _, err := r.Ops.DeleteAgent(ctx, name); err != nil {
+		return fmt.Errorf("%w; rollback of %s also failed: %v", cause, name, err)
+	}
+	return fmt.Errorf("%w (%s deleted, original kept)", cause, name)
+}
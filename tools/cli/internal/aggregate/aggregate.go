@@ -0,0 +1,353 @@
+// Package aggregate groups security events by arbitrary keys, with distinct
+// user and IP counts and time-bucket histograms, to answer questions the
+// server's per-type stats cannot.
+package aggregate
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/query"
+)
+
+// Target is the key naming each event's source target in fleet mode.
+const Target = "target"
+
+// MaxBuckets caps the number of time buckets in a histogram.
+const MaxBuckets = 1000
+
+// KeyError is a group-by key that does not parse. Err is a *query.Error
+// positioned within Key.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string { return "group by " + e.Key + ": " + e.Err.Error() }
+
+func (e *KeyError) Unwrap() error { return e.Err }
+
+// SplitKeys splits a comma-separated key list, ignoring commas inside
+// parentheses and quotes.
+func SplitKeys(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			out = append(out, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" || len(out) > 0 {
+		out = append(out, rest)
+	}
+	return out
+}
+
+// Group is the events sharing one key. Histogram is aligned with
+// Result.Buckets.
+type Group struct {
+	Key       []string `json:"key"`
+	Count     int      `json:"count"`
+	Users     int      `json:"users"`
+	IPs       int      `json:"ips"`
+	Histogram []int    `json:"histogram,omitempty"`
+}
+
+// Result is an aggregation. Groups are ordered by descending count; those
+// past the top N are folded into Other.
+type Result struct {
+	By       []string      `json:"by"`
+	Interval time.Duration `json:"interval,omitempty"`
+	Buckets  []time.Time   `json:"buckets,omitempty"`
+	Groups   []Group       `json:"groups"`
+	Other    *Group        `json:"other,omitempty"`
+	Total    Group         `json:"total"`
+}
+
+type key struct {
+	src  string
+	expr *query.Expr // nil for Target
+}
+
+type group struct {
+	key   []string
+	count int
+	users map[int]struct{}
+	ips   map[string]struct{}
+	hist  map[int64]int // bucket start (Unix seconds) to count
+}
+
+func newGroup(k []string) *group {
+	return &group{key: k, users: map[int]struct{}{}, ips: map[string]struct{}{}, hist: map[int64]int{}}
+}
+
+func (g *group) merge(o *group) {
+	g.count += o.count
+	for u := range o.users {
+		g.users[u] = struct{}{}
+	}
+	for ip := range o.ips {
+		g.ips[ip] = struct{}{}
+	}
+	for b, n := range o.hist {
+		g.hist[b] += n
+	}
+}
+
+// Aggregator accumulates events. It is not safe for concurrent use.
+type Aggregator struct {
+	by          []key
+	interval    time.Duration
+	groups      map[string]*group
+	total       *group
+	first, last int64
+}
+
+// New returns an aggregator grouping by the given keys: query fields and
+// functions (type, ip, hour(created_at), detail.reason) or Target. No keys
+// counts all events as one group. A positive interval adds a histogram of
+// created_at truncated to that interval, in UTC.
+func New(by []string, interval time.Duration) (*Aggregator, error) {
+	if interval < 0 || interval%time.Second != 0 {
+		return nil, fmt.Errorf("interval %s is not a positive whole number of seconds", interval)
+	}
+	a := &Aggregator{interval: interval, groups: map[string]*group{}, total: newGroup(nil)}
+	for _, src := range by {
+		if src == Target {
+			a.by = append(a.by, key{src: src})
+			continue
+		}
+		x, err := query.ParseExpr(src)
+		if err != nil {
+			return nil, &KeyError{Key: src, Err: err}
+		}
+		a.by = append(a.by, key{src: src, expr: x})
+	}
+	return a, nil
+}
+
+// Add counts e, from target.
+func (a *Aggregator) Add(target string, e api.Event) {
+	k := make([]string, len(a.by))
+	for i, b := range a.by {
+		if b.expr == nil {
+			k[i] = target
+		} else {
+			k[i] = Format(b.expr.Eval(e))
+		}
+	}
+	id := strings.Join(k, "\x00")
+	g := a.groups[id]
+	if g == nil {
+		g = newGroup(k)
+		a.groups[id] = g
+	}
+
+	bucket, timed := int64(0), false
+	if a.interval > 0 {
+		if t, err := api.ParseTimestamp(e.CreatedAt); err == nil {
+			bucket, timed = t.UTC().Truncate(a.interval).Unix(), true
+			if len(a.total.hist) == 0 {
+				a.first, a.last = bucket, bucket
+			}
+			a.first, a.last = min(a.first, bucket), max(a.last, bucket)
+		}
+	}
+	for _, g := range []*group{g, a.total} {
+		g.count++
+		if e.UserID != nil {
+			g.users[*e.UserID] = struct{}{}
+		}
+		if e.IPAddress != "" {
+			g.ips[e.IPAddress] = struct{}{}
+		}
+		if timed {
+			g.hist[bucket]++
+		}
+	}
+}
+
+// Result returns the groups so far, keeping the top groups by count and
+// folding the rest into Other (top 0 keeps all). Buckets run from the
+// earliest to the latest event seen, including empty ones, up to
+// MaxBuckets ending at the latest.
+func (a *Aggregator) Result(top int) *Result {
+	r := &Result{Interval: a.interval}
+	for _, b := range a.by {
+		r.By = append(r.By, b.src)
+	}
+	if a.interval > 0 && len(a.total.hist) > 0 {
+		step := int64(a.interval / time.Second)
+		first := a.first
+		if n := (a.last-first)/step + 1; n > MaxBuckets {
+			first = a.last - (MaxBuckets-1)*step
+		}
+		for b := first; b <= a.last; b += step {
+			r.Buckets = append(r.Buckets, time.Unix(b, 0).UTC())
+		}
+	}
+
+	groups := make([]*group, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(x, y *group) int {
+		if x.count != y.count {
+			return y.count - x.count
+		}
+		return slices.Compare(x.key, y.key)
+	})
+	if top > 0 && len(groups) > top {
+		other := newGroup(nil)
+		for _, g := range groups[top:] {
+			other.merge(g)
+		}
+		o := r.summary(other)
+		o.Key = []string{fmt.Sprintf("(%d others)", len(groups)-top)}
+		r.Other = &o
+		groups = groups[:top]
+	}
+	r.Groups = make([]Group, len(groups))
+	for i, g := range groups {
+		r.Groups[i] = r.summary(g)
+	}
+	r.Total = r.summary(a.total)
+	r.Total.Key = nil
+	return r
+}
+
+func (r *Result) summary(g *group) Group {
+	s := Group{Key: g.key, Count: g.count, Users: len(g.users), IPs: len(g.ips)}
+	if len(r.Buckets) > 0 {
+		s.Histogram = make([]int, len(r.Buckets))
+		for i, b := range r.Buckets {
+			s.Histogram[i] = g.hist[b.Unix()]
+		}
+	}
+	return s
+}
+
+// Pivot is a two-key result laid out as a grid.
+type Pivot struct {
+	Rows      []string
+	Cols      []string
+	Cells     [][]int // [row][col]
+	RowTotals []int
+	Max       int // largest cell
+}
+
+// Pivot lays out a result grouped by two keys: rows are values of the first
+// key by descending total, columns values of the second, in cols order if
+// given (other values are dropped) or else sorted, numerically where they
+// are numbers. Other is not included.
+func (r *Result) Pivot(cols []string) (*Pivot, error) {
+	if len(r.By) != 2 {
+		return nil, fmt.Errorf("pivot needs two group-by keys, have %d", len(r.By))
+	}
+	if cols == nil {
+		seen := map[string]bool{}
+		for _, g := range r.Groups {
+			if !seen[g.Key[1]] {
+				seen[g.Key[1]] = true
+				cols = append(cols, g.Key[1])
+			}
+		}
+		slices.SortFunc(cols, compareValues)
+	}
+	colIndex := make(map[string]int, len(cols))
+	for i, c := range cols {
+		colIndex[c] = i
+	}
+
+	p := &Pivot{Cols: cols}
+	rowIndex := map[string]int{}
+	for _, g := range r.Groups {
+		c, ok := colIndex[g.Key[1]]
+		if !ok {
+			continue
+		}
+		i, ok := rowIndex[g.Key[0]]
+		if !ok {
+			i = len(p.Rows)
+			rowIndex[g.Key[0]] = i
+			p.Rows = append(p.Rows, g.Key[0])
+			p.Cells = append(p.Cells, make([]int, len(cols)))
+			p.RowTotals = append(p.RowTotals, 0)
+		}
+		p.Cells[i][c] += g.Count
+		p.RowTotals[i] += g.Count
+		p.Max = max(p.Max, p.Cells[i][c])
+	}
+
+	order := make([]int, len(p.Rows))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(x, y int) int { return p.RowTotals[y] - p.RowTotals[x] })
+	sorted := &Pivot{Cols: p.Cols, Max: p.Max}
+	for _, i := range order {
+		sorted.Rows = append(sorted.Rows, p.Rows[i])
+		sorted.Cells = append(sorted.Cells, p.Cells[i])
+		sorted.RowTotals = append(sorted.RowTotals, p.RowTotals[i])
+	}
+	return sorted, nil
+}
+
+// compareValues orders numbers numerically and before other strings.
+func compareValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Format renders a key value: "-" for null, timestamps as
+// YYYY-MM-DD HH:MM:SS and JSON objects and arrays compactly.
+func Format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.DateTime)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
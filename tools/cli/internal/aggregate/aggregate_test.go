@@ -0,0 +1,157 @@
+package aggregate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/query"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+var events = []api.Event{
+	{ID: 1, Type: "login.failure", IPAddress: "198.51.100.7", CreatedAt: "2025-03-01 03:15:00"},
+	{ID: 2, Type: "login.failure", IPAddress: "198.51.100.8", CreatedAt: "2025-03-01 03:45:00"},
+	{ID: 3, Type: "login.failure", IPAddress: "198.51.100.7", CreatedAt: "2025-03-01 06:10:00"},
+	{ID: 4, Type: "login.success", IPAddress: "203.0.113.1", UserID: ptr(4), CreatedAt: "2025-03-01 06:20:00"},
+	{ID: 5, Type: "session.revoke", IPAddress: "203.0.113.1", UserID: ptr(4), CreatedAt: "2025-03-01 06:30:00"},
+	{ID: 6, Type: "login.success", IPAddress: "203.0.113.2", UserID: ptr(5), CreatedAt: "2025-03-01 03:05:00"},
+}
+
+func aggregate(t *testing.T, by []string, interval time.Duration, top int) *Result {
+	t.Helper()
+	a, err := New(by, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range events {
+		a.Add([]string{"prod", "staging"}[i%2], e)
+	}
+	return a.Result(top)
+}
+
+func TestGroups(t *testing.T) {
+	r := aggregate(t, []string{"type"}, 0, 0)
+	want := []Group{
+		{Key: []string{"login.failure"}, Count: 3, Users: 0, IPs: 2},
+		{Key: []string{"login.success"}, Count: 2, Users: 2, IPs: 2},
+		{Key: []string{"session.revoke"}, Count: 1, Users: 1, IPs: 1},
+	}
+	if !reflect.DeepEqual(r.Groups, want) {
+		t.Errorf("groups = %+v, want %+v", r.Groups, want)
+	}
+	if !reflect.DeepEqual(r.Total, Group{Count: 6, Users: 2, IPs: 4}) {
+		t.Errorf("total = %+v", r.Total)
+	}
+	if r.Other != nil || r.Buckets != nil {
+		t.Errorf("other = %+v, buckets = %v; want neither", r.Other, r.Buckets)
+	}
+}
+
+func TestTopFoldsOther(t *testing.T) {
+	r := aggregate(t, []string{"ip", Target}, 0, 2)
+	want := []Group{
+		{Key: []string{"198.51.100.7", "prod"}, Count: 2, Users: 0, IPs: 1},
+		{Key: []string{"198.51.100.8", "staging"}, Count: 1, Users: 0, IPs: 1},
+	}
+	if !reflect.DeepEqual(r.Groups, want) {
+		t.Errorf("groups = %+v, want %+v", r.Groups, want)
+	}
+	other := &Group{Key: []string{"(3 others)"}, Count: 3, Users: 2, IPs: 2}
+	if !reflect.DeepEqual(r.Other, other) {
+		t.Errorf("other = %+v, want %+v", r.Other, other)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := aggregate(t, nil, time.Hour, 0)
+	var buckets []string
+	for _, b := range r.Buckets {
+		buckets = append(buckets, b.Format("15:04"))
+	}
+	if want := []string{"03:00", "04:00", "05:00", "06:00"}; !reflect.DeepEqual(buckets, want) {
+		t.Errorf("buckets = %v, want %v", buckets, want)
+	}
+	if len(r.Groups) != 1 || !reflect.DeepEqual(r.Groups[0].Histogram, []int{3, 0, 0, 3}) {
+		t.Errorf("groups = %+v, want one with histogram [3 0 0 3]", r.Groups)
+	}
+
+	r = aggregate(t, []string{"type"}, time.Hour, 0)
+	if got := r.Groups[1].Histogram; !reflect.DeepEqual(got, []int{1, 0, 0, 1}) {
+		t.Errorf("login.success histogram = %v", got)
+	}
+}
+
+func TestPivot(t *testing.T) {
+	r := aggregate(t, []string{"type", "hour(created_at)"}, 0, 0)
+	p, err := r.Pivot(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"login.failure", "login.success", "session.revoke"}; !reflect.DeepEqual(p.Rows, want) {
+		t.Errorf("rows = %v, want %v", p.Rows, want)
+	}
+	if want := []string{"3", "6"}; !reflect.DeepEqual(p.Cols, want) {
+		t.Errorf("cols = %v, want %v", p.Cols, want)
+	}
+	if want := [][]int{{2, 1}, {1, 1}, {0, 1}}; !reflect.DeepEqual(p.Cells, want) || p.Max != 2 {
+		t.Errorf("cells = %v max %d, want %v max 2", p.Cells, p.Max, want)
+	}
+
+	p, _ = r.Pivot([]string{"6", "9", "10"})
+	if want := [][]int{{1, 0, 0}, {1, 0, 0}, {1, 0, 0}}; !reflect.DeepEqual(p.Cells, want) {
+		t.Errorf("cells with fixed columns = %v, want %v", p.Cells, want)
+	}
+
+	if _, err := aggregate(t, []string{"type"}, 0, 0).Pivot(nil); err == nil {
+		t.Error("Pivot with one key: want error")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New([]string{"type", "hour(tpye)"}, 0)
+	var ke *KeyError
+	var qe *query.Error
+	if !errors.As(err, &ke) || ke.Key != "hour(tpye)" || !errors.As(err, &qe) || qe.Pos != 5 {
+		t.Errorf("New = %v, want a KeyError for hour(tpye) at column 6", err)
+	}
+	if _, err := New(nil, 1500*time.Millisecond); err == nil {
+		t.Error("New with a fractional interval: want error")
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	for in, want := range map[string][]string{
+		"":                         nil,
+		"type":                     {"type"},
+		"type, ip":                 {"type", "ip"},
+		"type,hour(created_at),ip": {"type", "hour(created_at)", "ip"},
+		`detail.a,"x,y"`:           {"detail.a", `"x,y"`},
+		"type,":                    {"type", ""},
+	} {
+		if got := SplitKeys(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitKeys(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range []struct {
+		v    any
+		want string
+	}{
+		{nil, "-"},
+		{int64(7), "7"},
+		{float64(2.5), "2.5"},
+		{true, "true"},
+		{time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC), "2025-03-01 03:00:00"},
+		{map[string]any{"a": 1.0}, `{"a":1}`},
+	} {
+		if got := Format(c.v); got != c.want {
+			t.Errorf("Format(%#v) = %q, want %q", c.v, got, c.want)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,16 @@ import (
 // attempted without PLCTL_PROVISIONING_SECRET being set.
 var ErrNoProvisioningSecret = errors.New("PLCTL_PROVISIONING_SECRET is not set")
 
+// HTTPError is a non-2xx response from the API.
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
 // Client communicates with the Private Landing /ops/* API.
 type Client struct {
 	baseURL    string
@@ -36,6 +48,18 @@ func NewClient(baseURL, agentKey, provSecret string) *Client {
 }
 
 // do makes an agent-authenticated request (Bearer agentKey).
+// IsLoopback reports whether host, a hostname or IP address as in
+// url.URL.Hostname (brackets around IPv6 are accepted), only reaches this
+// machine. Other hostnames may resolve anywhere, so only localhost counts.
+func IsLoopback(host string) bool {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
 	return c.request(ctx, method, path, body, out, c.agentKey)
 }
@@ -93,9 +117,9 @@ func (c *Client) requestWithHeaders(ctx context.Context, method, path string, bo
 	if resp.StatusCode >= 400 {
 		var apiErr APIError
 		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
-			return fmt.Errorf("%s (code: %s)", apiErr.Error, apiErr.Code)
+			return &HTTPError{Status: resp.StatusCode, Message: fmt.Sprintf("%s (code: %s)", apiErr.Error, apiErr.Code)}
 		}
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		return &HTTPError{Status: resp.StatusCode, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody))}
 	}
 
 	if out != nil {
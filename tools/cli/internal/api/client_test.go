@@ -133,3 +133,23 @@ func TestDoOmitsContentTypeWithoutBody(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestIsLoopback(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost":        true,
+		"127.0.0.1":        true,
+		"127.8.0.1":        true,
+		"::1":              true,
+		"[::1]":            true,
+		"":                 false,
+		"0.0.0.0":          false,
+		"127.example.com":  false,
+		"localhost.evil":   false,
+		"[::]":             false,
+		"auth.example.com": false,
+	} {
+		if got := IsLoopback(host); got != want {
+			t.Errorf("IsLoopback(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
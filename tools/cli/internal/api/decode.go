@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeEvent decodes a recorded event in any of the shapes plctl sees on
+// the wire: a REST Event row, a WSEventPayload, or a full WSEvent push.
+func DecodeEvent(data []byte) (Event, error) {
+	var probe struct {
+		Type      string          `json:"type"`
+		EventType string          `json:"event_type"`
+		Payload   json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Event{}, fmt.Errorf("decode event: %w", err)
+	}
+
+	switch {
+	case probe.Type == "event" && probe.Payload != nil:
+		var p WSEventPayload
+		if err := json.Unmarshal(probe.Payload, &p); err != nil {
+			return Event{}, fmt.Errorf("decode event payload: %w", err)
+		}
+		return p.Event(), nil
+	case probe.EventType != "":
+		var p WSEventPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return Event{}, fmt.Errorf("decode event payload: %w", err)
+		}
+		return p.Event(), nil
+	}
+
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Event{}, fmt.Errorf("decode event: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import "testing"
+
+func TestDecodeEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		id   int
+		typ  string
+	}{
+		{"rest row", `{"id":1,"type":"login.success","ip_address":"1.2.3.4","detail":"{\"sessionId\":\"s\"}"}`, 1, "login.success"},
+		{"ws payload", `{"event_id":2,"event_type":"login.failure","detail":{"email":"x"}}`, 2, "login.failure"},
+		{"ws envelope", `{"type":"event","payload":{"event_id":3,"event_type":"ws.connect"}}`, 3, "ws.connect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := DecodeEvent([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.ID != tt.id || e.Type != tt.typ {
+				t.Fatalf("expected %d/%s, got %d/%s", tt.id, tt.typ, e.ID, e.Type)
+			}
+		})
+	}
+}
+
+func TestDecodeEventInvalid(t *testing.T) {
+	if _, err := DecodeEvent([]byte("nope")); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
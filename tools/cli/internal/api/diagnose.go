@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// KnownCapabilities lists every capability the ops WebSocket understands.
+var KnownCapabilities = []string{"query_events", "query_sessions", "subscribe_events", "revoke_session"}
+
+// WSHeartbeat is the periodic server heartbeat (every 25s).
+type WSHeartbeat struct {
+	Type          string   `json:"type"`
+	TS            int64    `json:"ts"` // server clock, Unix milliseconds
+	NextCheckMS   int      `json:"next_check_ms"`
+	PingTimeoutMS int      `json:"ping_timeout_ms"`
+	Capabilities  []string `json:"capabilities"`
+}
+
+// RESTProbe is the outcome of an authenticated REST request.
+type RESTProbe struct {
+	Status  int
+	Latency time.Duration
+	// Date is the server's Date header, zero if absent.
+	Date time.Time
+	// TLS is nil for plain HTTP.
+	TLS *tls.ConnectionState
+	// Err is the API error message for non-2xx responses.
+	Err string
+}
+
+// ProbeREST makes one authenticated GET /ops/events?limit=1 and reports the
+// status, latency, server date and TLS state. Only transport errors are
+// returned as err; HTTP errors are reported in the probe.
+func (c *Client) ProbeREST(ctx context.Context) (*RESTProbe, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/ops/events?limit=1", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.agentKey)
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	p := &RESTProbe{Status: resp.StatusCode, Latency: time.Since(start), TLS: resp.TLS}
+	if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		p.Date = d
+	}
+	if resp.StatusCode >= 400 {
+		var apiErr APIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			p.Err = fmt.Sprintf("%s (code: %s)", apiErr.Error, apiErr.Code)
+		} else {
+			p.Err = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+	}
+	return p, nil
+}
+
+// Ping sends an application-level ping and waits for the matching pong,
+// skipping other messages. It returns the round-trip time.
+func Ping(ctx context.Context, conn *websocket.Conn, id string) (time.Duration, error) {
+	start := time.Now()
+	if err := writeJSON(ctx, conn, WSPingRequest{Type: "ping", ID: id}); err != nil {
+		return 0, fmt.Errorf("write ping: %w", err)
+	}
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("read pong: %w", err)
+		}
+		var msg struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if json.Unmarshal(data, &msg) == nil && msg.Type == "pong" && msg.ID == id {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// WaitHeartbeat reads until the next heartbeat, skipping other messages.
+func WaitHeartbeat(ctx context.Context, conn *websocket.Conn) (*WSHeartbeat, error) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("read heartbeat: %w", err)
+		}
+		var hb WSHeartbeat
+		if json.Unmarshal(data, &hb) == nil && hb.Type == "heartbeat" {
+			return &hb, nil
+		}
+	}
+}
@@ -2,11 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
 )
 
+// ErrEventNotFound is returned by FindEvent when no event has the ID.
+var ErrEventNotFound = errors.New("event not found")
+
 // ListEvents returns security events, optionally filtered.
 func (c *Client) ListEvents(ctx context.Context, params EventsParams) (*ListEventsResponse, error) {
 	q := url.Values{}
@@ -19,6 +23,9 @@ func (c *Client) ListEvents(ctx context.Context, params EventsParams) (*ListEven
 	if params.IP != "" {
 		q.Set("ip", params.IP)
 	}
+	if params.ActorID != "" {
+		q.Set("actor_id", params.ActorID)
+	}
 	if params.Since != "" {
 		q.Set("since", params.Since)
 	}
@@ -52,3 +59,55 @@ func (c *Client) GetEventStats(ctx context.Context, since string) (*EventStatsRe
 	}
 	return &out, nil
 }
+
+// ListAllEvents pages through every event matching params, newest first.
+// params.Limit caps the total returned (0 for no cap); params.Offset is ignored.
+func (c *Client) ListAllEvents(ctx context.Context, params EventsParams) ([]Event, error) {
+	return AllEvents(ctx, c, params)
+}
+
+// AllEvents is ListAllEvents for any Reader.
+func AllEvents(ctx context.Context, r Reader, params EventsParams) ([]Event, error) {
+	limit := params.Limit
+	var all []Event
+	for offset := 0; ; offset += maxPageSize {
+		p := params
+		p.Limit = maxPageSize
+		p.Offset = offset
+		resp, err := r.ListEvents(ctx, p)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, resp.Events...)
+		if limit > 0 && len(all) >= limit {
+			return all[:limit], nil
+		}
+		if len(resp.Events) < maxPageSize {
+			return all, nil
+		}
+	}
+}
+
+// FindEvent looks up one event by ID. The API has no single-event route, so
+// it pages through events since since (newest first) and stops once the
+// IDs on a page drop below id.
+func FindEvent(ctx context.Context, r Reader, id int, since string) (*Event, error) {
+	params := EventsParams{Since: since, Limit: maxPageSize}
+	for {
+		resp, err := r.ListEvents(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		oldest := id
+		for i, e := range resp.Events {
+			if e.ID == id {
+				return &resp.Events[i], nil
+			}
+			oldest = min(oldest, e.ID)
+		}
+		if len(resp.Events) < maxPageSize || oldest < id {
+			return nil, ErrEventNotFound
+		}
+		params.Offset += maxPageSize
+	}
+}
@@ -3,8 +3,10 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 )
 
@@ -55,12 +57,15 @@ func TestListEventsWithFilters(t *testing.T) {
 		if q.Get("user_id") != "42" {
 			t.Errorf("expected user_id=42, got %q", q.Get("user_id"))
 		}
+		if q.Get("actor_id") != "agent:monitor" {
+			t.Errorf("expected actor_id=agent:monitor, got %q", q.Get("actor_id"))
+		}
 		json.NewEncoder(w).Encode(ListEventsResponse{Events: []Event{}})
 	}))
 	defer srv.Close()
 
 	c := NewClient(srv.URL, "key", "")
-	_, err := c.ListEvents(context.Background(), EventsParams{Type: "login.success", UserID: "42"})
+	_, err := c.ListEvents(context.Background(), EventsParams{Type: "login.success", UserID: "42", ActorID: "agent:monitor"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -101,3 +106,73 @@ func TestGetEventStatsError(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestListAllEventsPages(t *testing.T) {
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		offsets = append(offsets, q.Get("offset"))
+		if q.Get("limit") != "200" {
+			t.Errorf("expected limit=200, got %q", q.Get("limit"))
+		}
+		n := 200
+		if q.Get("offset") == "200" {
+			n = 5
+		}
+		json.NewEncoder(w).Encode(ListEventsResponse{Events: make([]Event, n)})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", "")
+	events, err := c.ListAllEvents(context.Background(), EventsParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 205 {
+		t.Fatalf("expected 205 events, got %d", len(events))
+	}
+	if len(offsets) != 2 || offsets[0] != "" || offsets[1] != "200" {
+		t.Fatalf("unexpected page offsets: %v", offsets)
+	}
+}
+
+func TestListAllEventsRespectsCap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ListEventsResponse{Events: make([]Event, 200)})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", "")
+	events, err := c.ListAllEvents(context.Background(), EventsParams{Limit: 250})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 250 {
+		t.Fatalf("expected 250 events, got %d", len(events))
+	}
+}
+
+func TestFindEvent(t *testing.T) {
+	// 450 events with IDs 450..1, newest first.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var events []Event
+		for id := 450 - offset; id > max(450-offset-200, 0); id-- {
+			events = append(events, Event{ID: id, Type: "login.success"})
+		}
+		json.NewEncoder(w).Encode(ListEventsResponse{Events: events})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", "")
+	e, err := FindEvent(context.Background(), c, 42, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.ID != 42 {
+		t.Fatalf("expected event 42, got %d", e.ID)
+	}
+	if _, err := FindEvent(context.Background(), c, 999, ""); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"strconv"
+)
+
+// Reader is the read-only query surface shared by the live API and local
+// sources such as evidence bundles. *Client satisfies it.
+type Reader interface {
+	ListEvents(ctx context.Context, params EventsParams) (*ListEventsResponse, error)
+	ListSessions(ctx context.Context, params SessionsParams) (*ListSessionsResponse, error)
+	GetEventStats(ctx context.Context, since string) (*EventStatsResponse, error)
+	ListAgents(ctx context.Context) (*ListAgentsResponse, error)
+}
+
+var _ Reader = (*Client)(nil)
+
+// Default and maximum page sizes applied by the ops API.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// FilterEvents applies EventsParams to an in-memory event list the way
+// GET /ops/events does: exact type, user, IP and actor filters, created_at
+// on or after Since, then offset and limit (default 50, max 200).
+func FilterEvents(events []Event, params EventsParams) []Event {
+	var out []Event
+	for _, e := range events {
+		if params.Type != "" && e.Type != params.Type {
+			continue
+		}
+		if params.UserID != "" && (e.UserID == nil || strconv.Itoa(*e.UserID) != params.UserID) {
+			continue
+		}
+		if params.IP != "" && e.IPAddress != params.IP {
+			continue
+		}
+		if params.ActorID != "" && e.ActorID != params.ActorID {
+			continue
+		}
+		if params.Since != "" && !onOrAfter(e.CreatedAt, params.Since) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return page(out, params.Offset, params.Limit)
+}
+
+// FilterSessions applies SessionsParams to an in-memory session list.
+func FilterSessions(sessions []Session, params SessionsParams) []Session {
+	var out []Session
+	for _, s := range sessions {
+		if params.UserID != "" && strconv.Itoa(s.UserID) != params.UserID {
+			continue
+		}
+		out = append(out, s)
+	}
+	return page(out, params.Offset, params.Limit)
+}
+
+func onOrAfter(ts, since string) bool {
+	t, err := ParseTimestamp(ts)
+	if err != nil {
+		return true
+	}
+	s, err := ParseTimestamp(since)
+	if err != nil {
+		return true
+	}
+	return !t.Before(s)
+}
+
+func page[T any](items []T, offset, limit int) []T {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,49 @@
+package api
+
+import "testing"
+
+func TestFilterEvents(t *testing.T) {
+	uid := 42
+	events := []Event{
+		{ID: 4, Type: "login.success", UserID: &uid, IPAddress: "203.0.113.9", CreatedAt: "2026-03-08 11:00:00"},
+		{ID: 3, Type: "login.failure", IPAddress: "203.0.113.9", CreatedAt: "2026-03-08 10:00:00"},
+		{ID: 2, Type: "agent.provisioned", ActorID: "agent:ops", CreatedAt: "2026-03-07 10:00:00"},
+		{ID: 1, Type: "login.failure", UserID: &uid, CreatedAt: "2026-03-06 10:00:00"},
+	}
+
+	tests := []struct {
+		name   string
+		params EventsParams
+		want   []int
+	}{
+		{"no filter", EventsParams{}, []int{4, 3, 2, 1}},
+		{"type", EventsParams{Type: "login.failure"}, []int{3, 1}},
+		{"user", EventsParams{UserID: "42"}, []int{4, 1}},
+		{"ip", EventsParams{IP: "203.0.113.9"}, []int{4, 3}},
+		{"actor", EventsParams{ActorID: "agent:ops"}, []int{2}},
+		{"since", EventsParams{Since: "2026-03-07T10:00:00Z"}, []int{4, 3, 2}},
+		{"limit offset", EventsParams{Limit: 2, Offset: 1}, []int{3, 2}},
+		{"offset past end", EventsParams{Offset: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterEvents(events, tt.params)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %v", len(got), tt.want)
+			}
+			for i, e := range got {
+				if e.ID != tt.want[i] {
+					t.Errorf("event %d: id = %d, want %d", i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilterSessions(t *testing.T) {
+	sessions := []Session{{ID: "a", UserID: 1}, {ID: "b", UserID: 2}, {ID: "c", UserID: 1}}
+	got := FilterSessions(sessions, SessionsParams{UserID: "1"})
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Errorf("FilterSessions(user 1) = %+v", got)
+	}
+}
@@ -36,3 +36,31 @@ func (c *Client) RevokeSessions(ctx context.Context, req RevokeSessionsRequest)
 	}
 	return &out, nil
 }
+
+// ListAllSessions pages through every active session matching params.
+// params.Limit caps the total returned (0 for no cap); params.Offset is ignored.
+func (c *Client) ListAllSessions(ctx context.Context, params SessionsParams) ([]Session, error) {
+	return AllSessions(ctx, c, params)
+}
+
+// AllSessions is ListAllSessions for any Reader.
+func AllSessions(ctx context.Context, r Reader, params SessionsParams) ([]Session, error) {
+	limit := params.Limit
+	var all []Session
+	for offset := 0; ; offset += maxPageSize {
+		p := params
+		p.Limit = maxPageSize
+		p.Offset = offset
+		resp, err := r.ListSessions(ctx, p)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, resp.Sessions...)
+		if limit > 0 && len(all) >= limit {
+			return all[:limit], nil
+		}
+		if len(resp.Sessions) < maxPageSize {
+			return all, nil
+		}
+	}
+}
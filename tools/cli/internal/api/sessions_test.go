@@ -103,3 +103,28 @@ func TestRevokeSessionsError(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestListAllSessionsPages(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("user_id") != "42" {
+			t.Errorf("expected user_id=42, got %q", r.URL.Query().Get("user_id"))
+		}
+		n := 200
+		if calls == 2 {
+			n = 0
+		}
+		json.NewEncoder(w).Encode(ListSessionsResponse{Sessions: make([]Session, n)})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "key", "")
+	sessions, err := c.ListAllSessions(context.Background(), SessionsParams{UserID: "42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sessions) != 200 || calls != 2 {
+		t.Fatalf("expected 200 sessions over 2 calls, got %d over %d", len(sessions), calls)
+	}
+}
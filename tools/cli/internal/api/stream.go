@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// ErrCredentialRevoked is returned when the server sends credential.revoked.
+var ErrCredentialRevoked = errors.New("agent credential revoked")
+
+// Negotiate sends capability.request and waits for capability.granted.
+func Negotiate(ctx context.Context, conn *websocket.Conn, capabilities []string) (*WSCapabilitiesGranted, error) {
+	capReq := WSCapabilitiesRequest{
+		Type:         "capability.request",
+		Capabilities: capabilities,
+	}
+	if err := writeJSON(ctx, conn, capReq); err != nil {
+		return nil, fmt.Errorf("write capabilities: %w", err)
+	}
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read capabilities: %w", err)
+	}
+	var granted WSCapabilitiesGranted
+	if err := json.Unmarshal(data, &granted); err != nil {
+		return nil, fmt.Errorf("decode capabilities: %w", err)
+	}
+	return &granted, nil
+}
+
+// Has reports whether the named capability was granted.
+func (g *WSCapabilitiesGranted) Has(capability string) bool {
+	for _, c := range g.Granted {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Require returns nil if capability was granted, otherwise an error carrying
+// the server's denial reason.
+func (g *WSCapabilitiesGranted) Require(capability string) error {
+	if g.Has(capability) {
+		return nil
+	}
+	for _, d := range g.Denied {
+		if d.Capability == capability {
+			return fmt.Errorf("%s capability denied: %s", capability, d.Reason)
+		}
+	}
+	return fmt.Errorf("%s capability not granted", capability)
+}
+
+// SubscribeEvents sends subscribe_events and waits for the ack.
+// Protocol messages received before the ack (heartbeat, pong) are skipped.
+func SubscribeEvents(ctx context.Context, conn *websocket.Conn, id string, types []string) (*WSSubscribeResponse, error) {
+	subReq := WSSubscribeRequest{
+		Type:    "subscribe_events",
+		ID:      id,
+		Payload: WSSubscribePayload{Types: types},
+	}
+	if err := writeJSON(ctx, conn, subReq); err != nil {
+		return nil, fmt.Errorf("write subscribe: %w", err)
+	}
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("read subscribe ack: %w", err)
+		}
+		var envelope WSEnvelope
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("decode subscribe ack: %w", err)
+		}
+		if envelope.Type != "subscribe_events" {
+			continue
+		}
+		if envelope.OK != nil && !*envelope.OK {
+			var wsErr WSError
+			if json.Unmarshal(data, &wsErr) == nil && wsErr.Error.Message != "" {
+				return nil, fmt.Errorf("subscribe rejected: %s (code: %s)", wsErr.Error.Message, wsErr.Error.Code)
+			}
+			return nil, fmt.Errorf("subscribe rejected")
+		}
+		var ack WSSubscribeResponse
+		if err := json.Unmarshal(data, &ack); err != nil {
+			return nil, fmt.Errorf("decode subscribe ack: %w", err)
+		}
+		return &ack, nil
+	}
+}
+
+// ReadEvent reads the next message from a subscribed connection.
+// It returns a nil payload for protocol messages (heartbeat, pong,
+// backpressure) and ErrCredentialRevoked when the server revokes the key.
+func ReadEvent(ctx context.Context, conn *websocket.Conn) (*WSEventPayload, error) {
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var envelope WSEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	switch envelope.Type {
+	case "credential.revoked":
+		return nil, ErrCredentialRevoked
+	case "event":
+		var wsEvt WSEvent
+		if err := json.Unmarshal(data, &wsEvt); err != nil {
+			return nil, fmt.Errorf("decode event: %w", err)
+		}
+		return &wsEvt.Payload, nil
+	}
+	return nil, nil
+}
+
+// Handshake proves the client's key end to end: it solves the PoW challenge,
+// connects to /ops/ws, negotiates query_events and disconnects. The grant
+// names the agent the server authenticated.
+func (c *Client) Handshake(ctx context.Context) (*WSCapabilitiesGranted, error) {
+	challenge, err := c.ProbeChallenge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.ConnectWS(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "handshake complete")
+	return Negotiate(ctx, conn, []string{"query_events"})
+}
+
+// Subscription is an /ops/ws connection with an active subscribe_events stream.
+type Subscription struct {
+	Conn    *websocket.Conn
+	Granted *WSCapabilitiesGranted
+	stop    chan struct{}
+}
+
+// Subscribe probes for a PoW challenge, connects, negotiates subscribe_events
+// plus any extra capabilities, and starts a subscription filtered by types.
+// A keepalive goroutine runs until Close is called.
+func (c *Client) Subscribe(ctx context.Context, types []string, extraCaps ...string) (*Subscription, error) {
+	challenge, err := c.ProbeChallenge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.ConnectWS(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := Negotiate(ctx, conn, append([]string{"subscribe_events"}, extraCaps...))
+	if err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+	if err := granted.Require("subscribe_events"); err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+	if _, err := SubscribeEvents(ctx, conn, "sub-1", types); err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+
+	s := &Subscription{Conn: conn, Granted: granted, stop: make(chan struct{})}
+	go KeepAlive(conn, s.stop)
+	return s, nil
+}
+
+// Next blocks until the next pushed event, skipping protocol messages.
+func (s *Subscription) Next(ctx context.Context) (*WSEventPayload, error) {
+	for {
+		p, err := ReadEvent(ctx, s.Conn)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+}
+
+// Close stops the keepalive, unsubscribes (best-effort) and closes the connection.
+func (s *Subscription) Close() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = writeJSON(ctx, s.Conn, WSUnsubscribeRequest{Type: "unsubscribe_events", ID: "unsub-1"})
+	s.Conn.Close(websocket.StatusNormalClosure, "client disconnected")
+}
+
+// Event converts a pushed event payload to the REST Event shape.
+func (p WSEventPayload) Event() Event {
+	var detail *string
+	if p.Detail != nil {
+		s := string(*p.Detail)
+		detail = &s
+	}
+	return Event{
+		ID:        p.EventID,
+		Type:      p.EventType,
+		IPAddress: p.IPAddress,
+		UserID:    p.UserID,
+		Detail:    detail,
+		CreatedAt: p.CreatedAt,
+		ActorID:   p.ActorID,
+	}
+}
+
+// typePattern mirrors the server's subscribe_events type filter schema.
+var typePattern = regexp.MustCompile(`^[a-z_]+(\.\*|\.[a-z_]+)?$`)
+
+// ValidTypePattern reports whether the server would accept pattern as a
+// subscribe_events type filter.
+func ValidTypePattern(pattern string) bool {
+	return typePattern.MatchString(pattern)
+}
+
+// MatchEventType reports whether eventType matches a subscribe_events
+// filter pattern: exact (login.success) or group wildcard (login.*).
+func MatchEventType(pattern, eventType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return strings.HasPrefix(eventType, prefix+".")
+	}
+	return pattern == eventType
+}
+
+// KeepAlive sends application-level ping messages to prevent ping timeout.
+// Runs as a goroutine alongside the read loop; github.com/coder/websocket supports
+// one concurrent reader + one concurrent writer.
+func KeepAlive(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(60 * time.Second)
+	defer ticker.Stop()
+	seq := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			seq++
+			ping := WSPingRequest{
+				Type: "ping",
+				ID:   fmt.Sprintf("keepalive-%d", seq),
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_ = writeJSON(ctx, conn, ping)
+			cancel()
+		}
+	}
+}
+
+func writeJSON(ctx context.Context, conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.Write(ctx, websocket.MessageText, data)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMatchEventType(t *testing.T) {
+	tests := []struct {
+		pattern string
+		typ     string
+		want    bool
+	}{
+		{"login.success", "login.success", true},
+		{"login.success", "login.failure", false},
+		{"login.*", "login.failure", true},
+		{"login.*", "loginx.failure", false},
+		{"login.*", "login", false},
+		{"ws.*", "ws.connect_failure", true},
+	}
+	for _, tt := range tests {
+		if got := MatchEventType(tt.pattern, tt.typ); got != tt.want {
+			t.Errorf("MatchEventType(%q, %q) = %v, want %v", tt.pattern, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestWSEventPayloadEvent(t *testing.T) {
+	uid := 7
+	raw := json.RawMessage(`{"email":"*@example.com"}`)
+	p := WSEventPayload{EventID: 12, EventType: "login.failure", IPAddress: "1.2.3.4", UserID: &uid, Detail: &raw, CreatedAt: "2026-03-04T12:00:00.000Z", ActorID: "app:private-landing"}
+
+	e := p.Event()
+	if e.ID != 12 || e.Type != "login.failure" || *e.UserID != 7 || e.ActorID != "app:private-landing" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	if e.Detail == nil || *e.Detail != `{"email":"*@example.com"}` {
+		t.Fatalf("expected detail JSON string, got %v", e.Detail)
+	}
+}
+
+func TestWSCapabilitiesGrantedHas(t *testing.T) {
+	g := WSCapabilitiesGranted{Granted: []string{"subscribe_events", "query_events"}}
+	if !g.Has("query_events") {
+		t.Fatal("expected query_events granted")
+	}
+	if g.Has("revoke_session") {
+		t.Fatal("expected revoke_session not granted")
+	}
+}
+
+func TestWSCapabilitiesGrantedRequire(t *testing.T) {
+	g := WSCapabilitiesGranted{
+		Granted: []string{"query_events"},
+		Denied:  []WSDeniedCap{{Capability: "revoke_session", Reason: "requires write trust"}},
+	}
+	if err := g.Require("query_events"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.Require("revoke_session"); err == nil || err.Error() != "revoke_session capability denied: requires write trust" {
+		t.Fatalf("err = %v", err)
+	}
+	if err := g.Require("subscribe_events"); err == nil {
+		t.Fatal("expected error for capability neither granted nor denied")
+	}
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// Transport modes for NewTransport.
+const (
+	TransportAuto = "auto"
+	TransportREST = "rest"
+	TransportWS   = "ws"
+)
+
+// Transport runs one-shot ops queries. *Client serves them over REST,
+// *WSTransport over a persistent /ops/ws connection and *AutoTransport
+// picks between the two.
+type Transport interface {
+	Reader
+	RevokeSessions(ctx context.Context, req RevokeSessionsRequest) (*RevokeSessionsResponse, error)
+	// Close releases the transport's connection, if any.
+	Close() error
+}
+
+var (
+	_ Transport = (*Client)(nil)
+	_ Transport = (*WSTransport)(nil)
+	_ Transport = (*AutoTransport)(nil)
+)
+
+// NewTransport returns the transport for mode: rest, ws or auto (also "").
+// The ws connection is dialed lazily on first use.
+func NewTransport(c *Client, mode string) (Transport, error) {
+	switch mode {
+	case TransportREST:
+		return c, nil
+	case TransportWS:
+		return NewWSTransport(c), nil
+	case TransportAuto, "":
+		return &AutoTransport{REST: c, WS: NewWSTransport(c)}, nil
+	}
+	return nil, fmt.Errorf("unknown transport %q (want auto, rest or ws)", mode)
+}
+
+// Close is a no-op; REST holds no connection.
+func (c *Client) Close() error {
+	return nil
+}
+
+// WSTransport sends query_events, query_sessions and revoke_session over one
+// multiplexed /ops/ws connection, redialing after a disconnect. Agents have
+// no WebSocket query and are listed over REST.
+type WSTransport struct {
+	client *Client
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	granted *WSCapabilitiesGranted
+	pending map[string]chan wsReply
+	seq     int
+	stop    chan struct{}
+}
+
+type wsReply struct {
+	data []byte
+	err  error
+}
+
+// NewWSTransport returns a WebSocket transport authenticated as c.
+func NewWSTransport(c *Client) *WSTransport {
+	return &WSTransport{client: c}
+}
+
+// Connected reports whether a connection is currently open.
+func (t *WSTransport) Connected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn != nil
+}
+
+// connect dials and negotiates the query capabilities if not connected.
+// Callers hold t.mu.
+func (t *WSTransport) connect(ctx context.Context) error {
+	if t.conn != nil {
+		return nil
+	}
+	challenge, err := t.client.ProbeChallenge(ctx)
+	if err != nil {
+		return err
+	}
+	conn, err := t.client.ConnectWS(ctx, challenge)
+	if err != nil {
+		return err
+	}
+	granted, err := Negotiate(ctx, conn, []string{"query_events", "query_sessions", "revoke_session"})
+	if err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return err
+	}
+	t.conn, t.granted = conn, granted
+	t.pending = make(map[string]chan wsReply)
+	t.stop = make(chan struct{})
+	go KeepAlive(conn, t.stop)
+	go t.readLoop(conn)
+	return nil
+}
+
+// readLoop routes replies to waiting calls by id until the connection drops,
+// then fails every pending call and clears the connection for a redial.
+func (t *WSTransport) readLoop(conn *websocket.Conn) {
+	var err error
+	for {
+		var data []byte
+		if _, data, err = conn.Read(context.Background()); err != nil {
+			break
+		}
+		var msg struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		if msg.Type == "credential.revoked" {
+			err = ErrCredentialRevoked
+			break
+		}
+		t.mu.Lock()
+		ch := t.pending[msg.ID]
+		delete(t.pending, msg.ID)
+		t.mu.Unlock()
+		if ch != nil {
+			ch <- wsReply{data: data}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != conn {
+		return
+	}
+	for id, ch := range t.pending {
+		ch <- wsReply{err: fmt.Errorf("ws connection lost: %w", err)}
+		delete(t.pending, id)
+	}
+	close(t.stop)
+	t.conn, t.granted, t.stop = nil, nil, nil
+}
+
+// call sends one request and decodes the reply payload into out.
+func (t *WSTransport) call(ctx context.Context, msgType string, payload any, out any) error {
+	t.mu.Lock()
+	if err := t.connect(ctx); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	if err := t.granted.Require(msgType); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	t.seq++
+	id := fmt.Sprintf("q-%d", t.seq)
+	ch := make(chan wsReply, 1)
+	t.pending[id] = ch
+	conn := t.conn
+	t.mu.Unlock()
+
+	req := struct {
+		Type    string `json:"type"`
+		ID      string `json:"id"`
+		Payload any    `json:"payload"`
+	}{msgType, id, payload}
+	if err := writeJSON(ctx, conn, req); err != nil {
+		t.forget(id)
+		return fmt.Errorf("write %s: %w", msgType, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.forget(id)
+		return ctx.Err()
+	case r := <-ch:
+		if r.err != nil {
+			return r.err
+		}
+		var reply struct {
+			OK      bool            `json:"ok"`
+			Payload json.RawMessage `json:"payload"`
+			Error   WSErrorDetail   `json:"error"`
+		}
+		if err := json.Unmarshal(r.data, &reply); err != nil {
+			return fmt.Errorf("decode %s: %w", msgType, err)
+		}
+		if !reply.OK {
+			return fmt.Errorf("%s (code: %s)", reply.Error.Message, reply.Error.Code)
+		}
+		if err := json.Unmarshal(reply.Payload, out); err != nil {
+			return fmt.Errorf("decode %s: %w", msgType, err)
+		}
+		return nil
+	}
+}
+
+func (t *WSTransport) forget(id string) {
+	t.mu.Lock()
+	delete(t.pending, id)
+	t.mu.Unlock()
+}
+
+// wsSince normalizes a since value to the RFC 3339 UTC form the ws schema
+// requires.
+func wsSince(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	ts, err := ParseTimestamp(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid since %q", s)
+	}
+	return ts.UTC().Format(time.RFC3339), nil
+}
+
+// wsUserID converts a user ID filter to the number the ws schema requires.
+func wsUserID(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid user id %q", s)
+	}
+	return id, nil
+}
+
+type wsEventsQuery struct {
+	Since     string `json:"since,omitempty"`
+	EventType string `json:"event_type,omitempty"`
+	UserID    int    `json:"user_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	ActorID   string `json:"actor_id,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	Aggregate bool   `json:"aggregate,omitempty"`
+}
+
+// ListEvents runs query_events.
+func (t *WSTransport) ListEvents(ctx context.Context, params EventsParams) (*ListEventsResponse, error) {
+	q := wsEventsQuery{EventType: params.Type, IP: params.IP, ActorID: params.ActorID, Offset: params.Offset}
+	var err error
+	if q.Since, err = wsSince(params.Since); err != nil {
+		return nil, err
+	}
+	if q.UserID, err = wsUserID(params.UserID); err != nil {
+		return nil, err
+	}
+	if params.Limit > 0 {
+		q.Limit = min(params.Limit, maxPageSize)
+	}
+	var out ListEventsResponse
+	if err := t.call(ctx, "query_events", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEventStats runs query_events with aggregate: true.
+func (t *WSTransport) GetEventStats(ctx context.Context, since string) (*EventStatsResponse, error) {
+	q := wsEventsQuery{Aggregate: true}
+	var err error
+	if q.Since, err = wsSince(since); err != nil {
+		return nil, err
+	}
+	var out EventStatsResponse
+	if err := t.call(ctx, "query_events", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSessions runs query_sessions for active sessions.
+func (t *WSTransport) ListSessions(ctx context.Context, params SessionsParams) (*ListSessionsResponse, error) {
+	q := struct {
+		UserID int `json:"user_id,omitempty"`
+		Limit  int `json:"limit,omitempty"`
+		Offset int `json:"offset,omitempty"`
+	}{Offset: params.Offset}
+	var err error
+	if q.UserID, err = wsUserID(params.UserID); err != nil {
+		return nil, err
+	}
+	if params.Limit > 0 {
+		q.Limit = min(params.Limit, maxPageSize)
+	}
+	var out ListSessionsResponse
+	if err := t.call(ctx, "query_sessions", q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAgents has no ws query and goes over REST.
+func (t *WSTransport) ListAgents(ctx context.Context) (*ListAgentsResponse, error) {
+	return t.client.ListAgents(ctx)
+}
+
+// RevokeSessions runs revoke_session; the connection needs write trust.
+func (t *WSTransport) RevokeSessions(ctx context.Context, req RevokeSessionsRequest) (*RevokeSessionsResponse, error) {
+	payload := struct {
+		Scope    string      `json:"scope"`
+		TargetID interface{} `json:"target_id,omitempty"`
+	}{req.Scope, req.ID}
+	var out RevokeSessionsResponse
+	if err := t.call(ctx, "revoke_session", payload, &out); err != nil {
+		return nil, err
+	}
+	out.Success = true
+	return &out, nil
+}
+
+// Close closes the connection; a later call redials.
+func (t *WSTransport) Close() error {
+	t.mu.Lock()
+	conn := t.conn
+	t.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close(websocket.StatusNormalClosure, "client disconnected")
+}
+
+// AutoTransport uses the ws connection while one is open and REST
+// otherwise, switching to ws when REST is rate limited or unavailable.
+type AutoTransport struct {
+	REST *Client
+	WS   *WSTransport
+}
+
+// restUnavailable reports whether a REST error is worth retrying over ws:
+// a 429, a 5xx or no response at all.
+func restUnavailable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Status == http.StatusTooManyRequests || httpErr.Status >= 500
+	}
+	return err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// autoCall runs viaWS when connected, else viaREST with a ws fallback.
+func autoCall[T any](a *AutoTransport, viaREST, viaWS func() (T, error)) (T, error) {
+	if a.WS.Connected() {
+		if v, err := viaWS(); err == nil || !a.WS.Connected() {
+			return v, err
+		}
+	}
+	v, err := viaREST()
+	if restUnavailable(err) {
+		if wv, wsErr := viaWS(); wsErr == nil {
+			return wv, nil
+		}
+	}
+	return v, err
+}
+
+func (a *AutoTransport) ListEvents(ctx context.Context, params EventsParams) (*ListEventsResponse, error) {
+	return autoCall(a,
+		func() (*ListEventsResponse, error) { return a.REST.ListEvents(ctx, params) },
+		func() (*ListEventsResponse, error) { return a.WS.ListEvents(ctx, params) })
+}
+
+func (a *AutoTransport) ListSessions(ctx context.Context, params SessionsParams) (*ListSessionsResponse, error) {
+	return autoCall(a,
+		func() (*ListSessionsResponse, error) { return a.REST.ListSessions(ctx, params) },
+		func() (*ListSessionsResponse, error) { return a.WS.ListSessions(ctx, params) })
+}
+
+func (a *AutoTransport) GetEventStats(ctx context.Context, since string) (*EventStatsResponse, error) {
+	return autoCall(a,
+		func() (*EventStatsResponse, error) { return a.REST.GetEventStats(ctx, since) },
+		func() (*EventStatsResponse, error) { return a.WS.GetEventStats(ctx, since) })
+}
+
+func (a *AutoTransport) ListAgents(ctx context.Context) (*ListAgentsResponse, error) {
+	return a.REST.ListAgents(ctx)
+}
+
+// RevokeSessions always goes over REST so a revocation is never retried on
+// a second path after an ambiguous failure.
+func (a *AutoTransport) RevokeSessions(ctx context.Context, req RevokeSessionsRequest) (*RevokeSessionsResponse, error) {
+	return a.REST.RevokeSessions(ctx, req)
+}
+
+func (a *AutoTransport) Close() error {
+	return a.WS.Close()
+}
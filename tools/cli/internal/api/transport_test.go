@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coder/websocket"
+)
+
+// fakeWSOps is an /ops server whose REST endpoints return restStatus and
+// whose /ops/ws answers queries, recording every request payload.
+type fakeWSOps struct {
+	restStatus int
+	granted    []string
+
+	mu       sync.Mutex
+	requests []map[string]any
+	dials    int
+}
+
+func (f *fakeWSOps) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ops/ws" {
+		w.WriteHeader(f.restStatus)
+		switch r.URL.Path {
+		case "/ops/events":
+			w.Write([]byte(`{"events":[{"id":1,"type":"rest"}]}`))
+		default:
+			w.Write([]byte(`{"error":"slow down","code":"RATE_LIMITED"}`))
+		}
+		return
+	}
+	if r.Header.Get("Upgrade") == "" {
+		w.WriteHeader(http.StatusUpgradeRequired)
+		return
+	}
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	f.mu.Lock()
+	f.dials++
+	f.mu.Unlock()
+
+	ctx := r.Context()
+	send := func(v any) {
+		b, _ := json.Marshal(v)
+		conn.Write(ctx, websocket.MessageText, b)
+	}
+	if _, _, err := conn.Read(ctx); err != nil {
+		return
+	}
+	send(WSCapabilitiesGranted{Type: "capability.granted", Agent: "test", Granted: f.granted})
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var msg map[string]any
+		json.Unmarshal(data, &msg)
+		f.mu.Lock()
+		f.requests = append(f.requests, msg)
+		f.mu.Unlock()
+
+		payload, _ := msg["payload"].(map[string]any)
+		reply := map[string]any{"type": msg["type"], "id": msg["id"], "ok": true}
+		switch {
+		case msg["type"] == "query_events" && payload["aggregate"] == true:
+			reply["payload"] = map[string]any{"since": payload["since"], "stats": map[string]int{"login.success": 3}}
+		case msg["type"] == "query_events" && payload["event_type"] == "bad":
+			reply["ok"] = false
+			reply["error"] = WSErrorDetail{Code: "INTERNAL_ERROR", Message: "Query failed"}
+		case msg["type"] == "query_events":
+			reply["payload"] = map[string]any{"events": []Event{{ID: 7, Type: "ws"}}, "count": 1}
+		case msg["type"] == "query_sessions":
+			reply["payload"] = map[string]any{"sessions": []Session{{ID: "s1", UserID: 42}}, "count": 1}
+		case msg["type"] == "revoke_session":
+			reply["payload"] = map[string]any{"revoked": 2}
+		case msg["type"] == "drop":
+			return
+		}
+		send(reply)
+	}
+}
+
+func TestWSTransportQueries(t *testing.T) {
+	f := &fakeWSOps{restStatus: http.StatusOK, granted: []string{"query_events", "query_sessions"}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	ws := NewWSTransport(NewClient(srv.URL, "key", ""))
+	defer ws.Close()
+	ctx := context.Background()
+
+	events, err := ws.ListEvents(ctx, EventsParams{UserID: "42", Since: "2026-01-02 03:04:05", Limit: 500})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events.Events) != 1 || events.Events[0].Type != "ws" {
+		t.Fatalf("events = %+v", events.Events)
+	}
+	stats, err := ws.GetEventStats(ctx, "2026-01-01T00:00:00Z")
+	if err != nil || stats.Stats["login.success"] != 3 || stats.Since != "2026-01-01T00:00:00Z" {
+		t.Fatalf("stats = %+v, %v", stats, err)
+	}
+	sessions, err := ws.ListSessions(ctx, SessionsParams{})
+	if err != nil || len(sessions.Sessions) != 1 || sessions.Sessions[0].UserID != 42 {
+		t.Fatalf("sessions = %+v, %v", sessions, err)
+	}
+
+	if _, err := ws.ListEvents(ctx, EventsParams{Type: "bad"}); err == nil || !strings.Contains(err.Error(), "INTERNAL_ERROR") {
+		t.Errorf("error reply: err = %v", err)
+	}
+	if _, err := ws.RevokeSessions(ctx, RevokeSessionsRequest{Scope: "all"}); err == nil || !strings.Contains(err.Error(), "revoke_session") {
+		t.Errorf("revoke without grant: err = %v", err)
+	}
+	if _, err := ws.ListEvents(ctx, EventsParams{UserID: "abc"}); err == nil {
+		t.Error("non-numeric user id: expected error")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dials != 1 {
+		t.Errorf("dials = %d, want one shared connection", f.dials)
+	}
+	first := f.requests[0]["payload"].(map[string]any)
+	if first["since"] != "2026-01-02T03:04:05Z" || first["user_id"] != float64(42) || first["limit"] != float64(200) {
+		t.Errorf("query_events payload = %v", first)
+	}
+}
+
+func TestWSTransportRedials(t *testing.T) {
+	f := &fakeWSOps{restStatus: http.StatusOK, granted: []string{"query_events"}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	ws := NewWSTransport(NewClient(srv.URL, "key", ""))
+	defer ws.Close()
+	ctx := context.Background()
+
+	if err := ws.call(ctx, "query_events", nil, new(any)); err != nil {
+		t.Fatal(err)
+	}
+	// The fake drops the connection on an unknown message type.
+	ws.mu.Lock()
+	ws.granted.Granted = append(ws.granted.Granted, "drop")
+	ws.mu.Unlock()
+	if err := ws.call(ctx, "drop", nil, new(any)); err == nil {
+		t.Fatal("expected connection lost error")
+	}
+	if _, err := ws.ListEvents(ctx, EventsParams{}); err != nil {
+		t.Fatalf("after drop: %v", err)
+	}
+	if f.dials != 2 {
+		t.Errorf("dials = %d, want a redial", f.dials)
+	}
+}
+
+func TestAutoTransportFallsBackToWS(t *testing.T) {
+	f := &fakeWSOps{restStatus: http.StatusTooManyRequests, granted: []string{"query_events"}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	tr, err := NewTransport(NewClient(srv.URL, "key", ""), TransportAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	resp, err := tr.ListEvents(context.Background(), EventsParams{})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if resp.Events[0].Type != "ws" {
+		t.Errorf("served by %q, want ws after REST 429", resp.Events[0].Type)
+	}
+	if _, err := NewTransport(nil, "grpc"); err == nil {
+		t.Error("unknown mode: expected error")
+	}
+}
+
+func TestAutoTransportPrefersREST(t *testing.T) {
+	f := &fakeWSOps{restStatus: http.StatusOK, granted: []string{"query_events"}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	tr, _ := NewTransport(NewClient(srv.URL, "key", ""), TransportAuto)
+	defer tr.Close()
+
+	resp, err := tr.ListEvents(context.Background(), EventsParams{})
+	if err != nil || resp.Events[0].Type != "rest" {
+		t.Fatalf("resp = %+v, err = %v; want REST when healthy", resp, err)
+	}
+	if f.dials != 0 {
+		t.Errorf("dials = %d, want no ws connection", f.dials)
+	}
+}
@@ -1,6 +1,11 @@
 package api
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // APIError represents an error response from the ops API.
 type APIError struct {
@@ -64,12 +69,13 @@ type ListEventsResponse struct {
 
 // EventsParams holds query parameters for GET /ops/events.
 type EventsParams struct {
-	Type   string
-	UserID string
-	IP     string
-	Since  string
-	Limit  int
-	Offset int
+	Type    string
+	UserID  string
+	IP      string
+	ActorID string
+	Since   string
+	Limit   int
+	Offset  int
 }
 
 // EventStatsResponse is the response from GET /ops/events/stats.
@@ -118,3 +124,61 @@ type RevokeSessionsResponse struct {
 func DefaultSince() string {
 	return time.Now().UTC().Add(-24 * time.Hour).Format(time.RFC3339)
 }
+
+// ParseTimestamp parses an event or session timestamp. The server emits
+// ISO 8601 for rows it writes and SQLite datetime() format for defaults.
+func ParseTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateTime, s)
+}
+
+// CompareTimestamps orders two timestamps in time, whichever format each is
+// in; comparing the strings would put every RFC 3339 time after every
+// datetime() one on the same day. Unparseable timestamps sort first.
+func CompareTimestamps(a, b string) int {
+	ta, errA := ParseTimestamp(a)
+	tb, errB := ParseTimestamp(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return ta.Compare(tb)
+}
+
+// ParseDuration extends time.ParseDuration with a whole-day unit (7d).
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// ParseSince resolves a relative duration (30m, 24h, 7d) or an absolute
+// timestamp to the ISO 8601 form the ops API expects. Empty means 24h ago.
+func ParseSince(s string, now time.Time) (string, error) {
+	if s == "" {
+		return now.UTC().Add(-24 * time.Hour).Format(time.RFC3339), nil
+	}
+	if t, err := ParseTimestamp(s); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid since %q: want a duration (24h, 7d) or RFC 3339 timestamp", s)
+	}
+	return now.UTC().Add(-d).Format(time.RFC3339), nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	for _, s := range []string{"2026-03-04T12:00:00.000Z", "2026-03-04T12:00:00Z", "2026-03-04 12:00:00"} {
+		ts, err := ParseTimestamp(s)
+		if err != nil {
+			t.Fatalf("ParseTimestamp(%q): %v", s, err)
+		}
+		if ts.Hour() != 12 {
+			t.Fatalf("ParseTimestamp(%q) hour = %d", s, ts.Hour())
+		}
+	}
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCompareTimestamps(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"2026-03-08T10:00:00Z", "2026-03-08 11:00:00", -1},
+		{"2026-03-08 11:00:00", "2026-03-08T10:00:00.5Z", 1},
+		{"2026-03-08 11:00:00", "2026-03-08T11:00:00Z", 0},
+		{"garbage", "2026-03-08 11:00:00", -1},
+	} {
+		if got := CompareTimestamps(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareTimestamps(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"24h", 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "2026-03-07T12:00:00Z", false},
+		{"7d", "2026-03-01T12:00:00Z", false},
+		{"30m", "2026-03-08T11:30:00Z", false},
+		{"2026-03-01T00:00:00Z", "2026-03-01T00:00:00Z", false},
+		{"last week", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSince(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSince(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -88,17 +88,26 @@ func (c *Client) ConnectWS(ctx context.Context, challenge *ChallengeResult) (*we
 		wsURL += challenge.qs
 	}
 
-	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+	conn, resp, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
 		HTTPHeader: http.Header{
 			"Authorization": []string{"Bearer " + c.agentKey},
 		},
 	})
 	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, &HTTPError{Status: resp.StatusCode, Message: fmt.Sprintf("ws dial: %v", err)}
+		}
 		return nil, fmt.Errorf("ws dial: %w", err)
 	}
 	return conn, nil
 }
 
+// SolvePow finds a solution to a server-issued PoW challenge: the smallest
+// integer whose SHA-256 of nonce+solution has difficulty leading hex zeros.
+func SolvePow(ctx context.Context, nonce string, difficulty int) (int, error) {
+	return solvePow(ctx, nonce, strings.Repeat("0", difficulty))
+}
+
 // solvePow brute-forces the SHA-256 PoW challenge.
 func solvePow(ctx context.Context, nonce, prefix string) (int, error) {
 	for i := 0; ; i++ {
@@ -0,0 +1,470 @@
+// Package archive keeps an append-only local copy of a target's security
+// events in SQLite. It uses the export schema, so plctl --offline reads an
+// archive like any other database export.
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	_ "modernc.org/sqlite"
+)
+
+// schema mirrors the server's tables, with empty session and
+// agent_credential tables so offline.Open accepts the file, plus the
+// archive's own bookkeeping. Triggers keep security_event append-only.
+const schema = `
+CREATE TABLE IF NOT EXISTS security_event (id integer primary key, type text not null, ip_address text not null, user_id integer, user_agent text, status integer, detail text, created_at text not null, actor_id text not null default 'app:private-landing');
+CREATE INDEX IF NOT EXISTS security_event_created_at ON security_event (created_at);
+CREATE TABLE IF NOT EXISTS session (id text primary key, user_id integer not null, user_agent text not null, ip_address text not null, expires_at text not null, created_at text not null);
+CREATE TABLE IF NOT EXISTS agent_credential (id integer primary key autoincrement, name text not null unique, key_hash text not null, trust_level text not null default 'read', description text, created_at text not null, revoked_at text);
+CREATE TABLE IF NOT EXISTS archive_state (key text primary key, value text not null);
+CREATE TABLE IF NOT EXISTS archive_day (day text primary key, events integer not null, sha256 text not null, sealed_at text not null);
+CREATE TRIGGER IF NOT EXISTS security_event_no_update BEFORE UPDATE ON security_event
+	BEGIN SELECT RAISE(ABORT, 'archive is append-only'); END;
+CREATE TRIGGER IF NOT EXISTS security_event_no_delete BEFORE DELETE ON security_event
+	BEGIN SELECT RAISE(ABORT, 'archive is append-only'); END;
+`
+
+// pageSize is the /ops/events maximum.
+const pageSize = 200
+
+// syncOverlap is how far before the mark each sync starts. The Worker stamps
+// created_at before its deferred insert commits, so an event can appear after
+// newer ones; re-reading the overlap picks it up and Add drops the repeats.
+const syncOverlap = 15 * time.Minute
+
+// DefaultPath is <user config dir>/plctl/archive/<target>.db.
+func DefaultPath(target string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "plctl", "archive", filepath.Base(target)+".db")
+}
+
+// Archive is an open archive file.
+type Archive struct {
+	db   *sql.DB
+	path string
+}
+
+// Open opens or creates the archive at path.
+func Open(path string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// One writer; also keeps the triggers and transactions on one connection.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("archive %s: %w", path, err)
+	}
+	return &Archive{db: db, path: path}, nil
+}
+
+// Path is the archive file.
+func (a *Archive) Path() string { return a.path }
+
+// Close closes the archive.
+func (a *Archive) Close() error { return a.db.Close() }
+
+// Stats counts what an Add or Sync did.
+type Stats struct {
+	Fetched    int `json:"fetched"`
+	Added      int `json:"added"`
+	Duplicates int `json:"duplicates"` // already archived, identical
+	Conflicts  int `json:"conflicts"`  // already archived with different content; the archived copy is kept
+	Late       int `json:"late"`       // added to a sealed day, which is resealed
+}
+
+func (s *Stats) add(o Stats) {
+	s.Fetched += o.Fetched
+	s.Added += o.Added
+	s.Duplicates += o.Duplicates
+	s.Conflicts += o.Conflicts
+	s.Late += o.Late
+}
+
+// Add archives events, skipping IDs already present.
+func (a *Archive) Add(ctx context.Context, events []api.Event) (Stats, error) {
+	st := Stats{Fetched: len(events)}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return st, err
+	}
+	defer tx.Rollback()
+	for _, e := range events {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO security_event (id, type, ip_address, user_id, detail, created_at, actor_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+			e.ID, e.Type, e.IPAddress, e.UserID, e.Detail, e.CreatedAt, e.ActorID)
+		if err != nil {
+			return st, fmt.Errorf("archive event %d: %w", e.ID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			old, err := scanEvent(tx.QueryRowContext(ctx, selectEvent+" WHERE id = ?", e.ID))
+			if err != nil {
+				return st, err
+			}
+			if digestLine(old) == digestLine(e) {
+				st.Duplicates++
+			} else {
+				st.Conflicts++
+			}
+			continue
+		}
+		st.Added++
+		res, err = tx.ExecContext(ctx, "DELETE FROM archive_day WHERE day = ?", day(e.CreatedAt))
+		if err != nil {
+			return st, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			st.Late++
+		}
+	}
+	return st, tx.Commit()
+}
+
+// Sync pages every event since syncOverlap before the synced-through mark
+// (or since, for an empty archive) from r, then advances the mark and seals
+// finished days. Pages are archived as they arrive, but the mark only moves
+// once the whole range is in, so an interrupted sync resumes from the same
+// point.
+func (a *Archive) Sync(ctx context.Context, r api.Reader, since string) (Stats, error) {
+	var st Stats
+	if mark, err := a.SyncedThrough(ctx); err != nil {
+		return st, err
+	} else if t, err := api.ParseTimestamp(mark); err == nil {
+		since = t.Add(-syncOverlap).UTC().Format(time.RFC3339)
+	} else if mark != "" {
+		since = mark
+	}
+	newest := ""
+	for offset := 0; ; offset += pageSize {
+		resp, err := r.ListEvents(ctx, api.EventsParams{Since: since, Limit: pageSize, Offset: offset})
+		if err != nil {
+			return st, err
+		}
+		s, err := a.Add(ctx, resp.Events)
+		st.add(s)
+		if err != nil {
+			return st, err
+		}
+		for _, e := range resp.Events {
+			newest = later(newest, e.CreatedAt)
+		}
+		if len(resp.Events) < pageSize {
+			break
+		}
+	}
+	if newest != "" {
+		if err := a.Mark(ctx, newest); err != nil {
+			return st, err
+		}
+	}
+	return st, a.Seal(ctx)
+}
+
+// later returns whichever timestamp is later; unparseable ones lose.
+func later(a, b string) string {
+	ta, errA := api.ParseTimestamp(a)
+	tb, errB := api.ParseTimestamp(b)
+	if errB != nil || (errA == nil && !tb.After(ta)) {
+		return a
+	}
+	return b
+}
+
+// SyncedThrough returns the mark, the newest created_at archived. Every
+// event more than syncOverlap before it is archived. Empty for a new
+// archive.
+func (a *Archive) SyncedThrough(ctx context.Context) (string, error) {
+	var v string
+	err := a.db.QueryRowContext(ctx, "SELECT value FROM archive_state WHERE key = 'synced_through'").Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return v, err
+}
+
+// Mark advances the synced-through mark to createdAt; it never moves back.
+// Callers must know every event before createdAt is archived.
+func (a *Archive) Mark(ctx context.Context, createdAt string) error {
+	cur, err := a.SyncedThrough(ctx)
+	if err != nil {
+		return err
+	}
+	if cur != "" && later(cur, createdAt) == cur {
+		return nil
+	}
+	_, err = a.db.ExecContext(ctx,
+		"INSERT INTO archive_state (key, value) VALUES ('synced_through', ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value",
+		createdAt)
+	return err
+}
+
+// Seal records a digest for each unsealed day wholly before the mark's day.
+func (a *Archive) Seal(ctx context.Context) error {
+	mark, err := a.SyncedThrough(ctx)
+	if err != nil || mark == "" {
+		return err
+	}
+	rows, err := a.db.QueryContext(ctx,
+		`SELECT DISTINCT date(created_at) FROM security_event
+		WHERE date(created_at) < date(?) AND date(created_at) NOT IN (SELECT day FROM archive_day)`, day(mark))
+	if err != nil {
+		return err
+	}
+	var days []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return err
+		}
+		days = append(days, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.DateTime)
+	for _, d := range days {
+		n, sum, err := a.digest(ctx, d)
+		if err != nil {
+			return err
+		}
+		if _, err := a.db.ExecContext(ctx, "INSERT INTO archive_day (day, events, sha256, sealed_at) VALUES (?, ?, ?, ?)", d, n, sum, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// digest hashes a day's events in ID order, one JSON line each.
+func (a *Archive) digest(ctx context.Context, d string) (int, string, error) {
+	rows, err := a.db.QueryContext(ctx, selectEvent+" WHERE date(created_at) = ? ORDER BY id", d)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rows.Close()
+	h := sha256.New()
+	n := 0
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return 0, "", err
+		}
+		h.Write([]byte(digestLine(e)))
+		n++
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), rows.Err()
+}
+
+func digestLine(e api.Event) string {
+	b, _ := json.Marshal(e)
+	return string(b) + "\n"
+}
+
+// day is the UTC date of a timestamp, as SQLite's date() gives it.
+func day(createdAt string) string {
+	t, err := api.ParseTimestamp(createdAt)
+	if err != nil {
+		return createdAt
+	}
+	return t.UTC().Format(time.DateOnly)
+}
+
+const selectEvent = "SELECT id, type, ip_address, user_id, detail, created_at, actor_id FROM security_event"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(s scanner) (api.Event, error) {
+	var e api.Event
+	var uid sql.NullInt64
+	var detail sql.NullString
+	if err := s.Scan(&e.ID, &e.Type, &e.IPAddress, &uid, &detail, &e.CreatedAt, &e.ActorID); err != nil {
+		return e, err
+	}
+	if uid.Valid {
+		n := int(uid.Int64)
+		e.UserID = &n
+	}
+	if detail.Valid {
+		e.Detail = &detail.String
+	}
+	return e, nil
+}
+
+// Status summarizes an archive.
+type Status struct {
+	Path          string `json:"path"`
+	Bytes         int64  `json:"bytes"`
+	Events        int    `json:"events"`
+	First         string `json:"first,omitempty"`
+	Last          string `json:"last,omitempty"`
+	SyncedThrough string `json:"synced_through,omitempty"`
+	Days          int    `json:"days"`
+	SealedDays    int    `json:"sealed_days"`
+}
+
+// Status reports what the archive holds.
+func (a *Archive) Status(ctx context.Context) (*Status, error) {
+	s := &Status{Path: a.path}
+	if fi, err := os.Stat(a.path); err == nil {
+		s.Bytes = fi.Size()
+	}
+	var first, last sql.NullString
+	err := a.db.QueryRowContext(ctx,
+		`SELECT count(*), min(created_at), max(created_at), count(DISTINCT date(created_at)),
+		(SELECT count(*) FROM archive_day) FROM security_event`).Scan(&s.Events, &first, &last, &s.Days, &s.SealedDays)
+	if err != nil {
+		return nil, err
+	}
+	s.First, s.Last = first.String, last.String
+	s.SyncedThrough, err = a.SyncedThrough(ctx)
+	return s, err
+}
+
+// Range is an inclusive span of event IDs.
+type Range struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// DayMismatch is a sealed day whose events no longer match its digest.
+type DayMismatch struct {
+	Day          string `json:"day"`
+	SealedEvents int    `json:"sealed_events"`
+	Events       int    `json:"events"`
+}
+
+// Report is the outcome of Verify.
+type Report struct {
+	Integrity  []string      `json:"integrity,omitempty"` // SQLite integrity_check problems
+	SealedDays int           `json:"sealed_days"`
+	Mismatched []DayMismatch `json:"mismatched,omitempty"`
+	Gaps       []Range       `json:"gaps,omitempty"` // missing IDs between archived ones
+}
+
+// OK reports whether the file and every sealed day are intact. ID gaps are
+// not failures: the server's IDs can skip, but gaps are worth a look.
+func (r *Report) OK() bool { return len(r.Integrity) == 0 && len(r.Mismatched) == 0 }
+
+// Verify checks the database file, recomputes each sealed day's digest and
+// lists gaps in the archived event IDs.
+func (a *Archive) Verify(ctx context.Context) (*Report, error) {
+	r := &Report{}
+	rows, err := a.db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if msg != "ok" {
+			r.Integrity = append(r.Integrity, msg)
+		}
+	}
+	rows.Close()
+
+	type seal struct {
+		day    string
+		events int
+		sum    string
+	}
+	var seals []seal
+	rows, err = a.db.QueryContext(ctx, "SELECT day, events, sha256 FROM archive_day ORDER BY day")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var s seal
+		if err := rows.Scan(&s.day, &s.events, &s.sum); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		seals = append(seals, s)
+	}
+	rows.Close()
+	r.SealedDays = len(seals)
+	for _, s := range seals {
+		n, sum, err := a.digest(ctx, s.day)
+		if err != nil {
+			return nil, err
+		}
+		if sum != s.sum {
+			r.Mismatched = append(r.Mismatched, DayMismatch{Day: s.day, SealedEvents: s.events, Events: n})
+		}
+	}
+
+	rows, err = a.db.QueryContext(ctx,
+		`SELECT id + 1, next - 1 FROM (SELECT id, lead(id) OVER (ORDER BY id) AS next FROM security_event)
+		WHERE next > id + 1 ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var g Range
+		if err := rows.Scan(&g.From, &g.To); err != nil {
+			return nil, err
+		}
+		r.Gaps = append(r.Gaps, g)
+	}
+	return r, rows.Err()
+}
+
+// Export calls fn for each archived event at or after since (and before
+// until, if set), oldest first.
+func (a *Archive) Export(ctx context.Context, since, until string, fn func(api.Event) error) error {
+	q := selectEvent + " WHERE datetime(created_at) >= datetime(?)"
+	args := []any{sqlTime(since)}
+	if until != "" {
+		q += " AND datetime(created_at) < datetime(?)"
+		args = append(args, sqlTime(until))
+	}
+	rows, err := a.db.QueryContext(ctx, q+" ORDER BY created_at, id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sqlTime normalizes a timestamp for datetime(); empty is the epoch.
+func sqlTime(s string) string {
+	t, err := api.ParseTimestamp(s)
+	if err != nil {
+		return "1970-01-01 00:00:00"
+	}
+	return t.UTC().Format(time.DateTime)
+}
@@ -0,0 +1,238 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/offline"
+)
+
+// source serves /ops/events from memory, newest first, like the server.
+type source struct {
+	api.Reader
+	events []api.Event
+	failAt int // fail the request at this offset (0: never)
+}
+
+func (s *source) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	if s.failAt > 0 && p.Offset == s.failAt {
+		return nil, errors.New("connection reset")
+	}
+	since, _ := api.ParseTimestamp(p.Since)
+	var match []api.Event
+	for _, e := range s.events {
+		if t, _ := api.ParseTimestamp(e.CreatedAt); !t.Before(since) {
+			match = append(match, e)
+		}
+	}
+	slices.SortFunc(match, func(a, b api.Event) int {
+		if c := strings.Compare(b.CreatedAt, a.CreatedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	match = match[min(p.Offset, len(match)):]
+	return &api.ListEventsResponse{Events: match[:min(p.Limit, len(match))]}, nil
+}
+
+var start = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// generate returns events from..to (inclusive IDs), ten minutes apart.
+func generate(from, to int) []api.Event {
+	var out []api.Event
+	for id := from; id <= to; id++ {
+		out = append(out, api.Event{
+			ID:        id,
+			Type:      "login.failure",
+			IPAddress: "198.51.100.7",
+			CreatedAt: start.Add(time.Duration(id) * 10 * time.Minute).Format(time.DateTime),
+			ActorID:   "app:private-landing",
+		})
+	}
+	return out
+}
+
+func open(t *testing.T) *Archive {
+	t.Helper()
+	a, err := Open(filepath.Join(t.TempDir(), "nested", "prod.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+func TestSyncIncremental(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	src := &source{events: generate(1, 450)} // about 3 days
+
+	st, err := a.Sync(ctx, src, "1970-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Added != 450 || st.Fetched != 450 {
+		t.Errorf("first sync = %+v, want 450 added", st)
+	}
+	mark, _ := a.SyncedThrough(ctx)
+	if mark != src.events[449].CreatedAt {
+		t.Errorf("mark = %q, want the newest event %q", mark, src.events[449].CreatedAt)
+	}
+
+	// Events in the overlap before the mark are fetched again and
+	// deduplicated.
+	src.events = append(src.events, generate(451, 455)...)
+	if st, err = a.Sync(ctx, src, ""); err != nil {
+		t.Fatal(err)
+	}
+	if st.Added != 5 || st.Duplicates != 2 || st.Fetched != 7 {
+		t.Errorf("second sync = %+v, want 5 added and the overlap deduplicated", st)
+	}
+
+	s, err := a.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Events != 455 || s.Days != 4 || s.SealedDays != 3 || s.SyncedThrough != src.events[454].CreatedAt {
+		t.Errorf("status = %+v", s)
+	}
+}
+
+func TestSyncPicksUpLateCommits(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	src := &source{events: generate(1, 10)}
+	if _, err := a.Sync(ctx, src, "1970-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Event 11 was stamped a few seconds before event 10 but committed
+	// after the first sync read the page.
+	late := generate(11, 11)[0]
+	late.CreatedAt = start.Add(100*time.Minute - 5*time.Second).Format(time.DateTime)
+	src.events = append(src.events, late)
+	st, err := a.Sync(ctx, src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Added != 1 {
+		t.Errorf("sync after a late commit = %+v, want it added", st)
+	}
+}
+
+func TestSyncResumesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	src := &source{events: generate(1, 500), failAt: 2 * pageSize}
+
+	st, err := a.Sync(ctx, src, "1970-01-01T00:00:00Z")
+	if err == nil || st.Added != 2*pageSize {
+		t.Fatalf("interrupted sync = %+v, %v; want two pages then an error", st, err)
+	}
+	if mark, _ := a.SyncedThrough(ctx); mark != "" {
+		t.Errorf("mark = %q after a failed sync, want none", mark)
+	}
+
+	src.failAt = 0
+	if st, err = a.Sync(ctx, src, "1970-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if st.Added != 100 || st.Duplicates != 400 {
+		t.Errorf("resumed sync = %+v, want the 100 missing events", st)
+	}
+}
+
+func TestAddDedupAndLate(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	if _, err := a.Sync(ctx, &source{events: generate(1, 300)}, "1970-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := generate(5, 5)[0]
+	changed.Type = "login.success"
+	late := generate(6, 6)[0]
+	late.ID = 1000
+	st, err := a.Add(ctx, []api.Event{generate(4, 4)[0], changed, late})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{Fetched: 3, Added: 1, Duplicates: 1, Conflicts: 1, Late: 1}); st != want {
+		t.Errorf("Add = %+v, want %+v", st, want)
+	}
+	if err := a.Seal(ctx); err != nil {
+		t.Fatal(err)
+	}
+	r, err := a.Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() || r.SealedDays != 2 {
+		t.Errorf("verify after reseal = %+v, want OK with 2 sealed days", r)
+	}
+	if want := []Range{{301, 999}}; !reflect.DeepEqual(r.Gaps, want) {
+		t.Errorf("gaps = %v, want %v", r.Gaps, want)
+	}
+}
+
+func TestAppendOnlyAndVerify(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	if _, err := a.Sync(ctx, &source{events: generate(1, 300)}, "1970-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{"UPDATE security_event SET type = 'x' WHERE id = 1", "DELETE FROM security_event WHERE id = 1"} {
+		if _, err := a.db.Exec(stmt); err == nil || !strings.Contains(err.Error(), "append-only") {
+			t.Errorf("%s: %v, want append-only error", stmt, err)
+		}
+	}
+
+	// Bypass the guard, as a stray edit with the sqlite3 shell would.
+	a.db.Exec("DROP TRIGGER security_event_no_update")
+	if _, err := a.db.Exec("UPDATE security_event SET ip_address = '10.0.0.1' WHERE id = 10"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := a.Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OK() || len(r.Mismatched) != 1 || r.Mismatched[0].Day != "2025-03-01" {
+		t.Errorf("verify = %+v, want 2025-03-01 mismatched", r)
+	}
+}
+
+func TestOfflineReadsArchive(t *testing.T) {
+	ctx := context.Background()
+	a := open(t)
+	if _, err := a.Sync(ctx, &source{events: generate(1, 20)}, "1970-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	d, err := offline.Open(a.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	resp, err := d.ListEvents(ctx, api.EventsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Events) != 20 || resp.Events[0].ID != 20 {
+		t.Errorf("offline ListEvents = %d events, first %+v", len(resp.Events), resp.Events[0])
+	}
+
+	var ids []int
+	err = a.Export(ctx, start.Add(3*time.Hour).Format(time.RFC3339), start.Add(150*time.Minute+3*time.Hour).Format(time.RFC3339), func(e api.Event) error {
+		ids = append(ids, e.ID)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(ids, []int{18, 19, 20}) {
+		t.Errorf("Export = %v, %v; want [18 19 20]", ids, err)
+	}
+}
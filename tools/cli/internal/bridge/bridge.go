@@ -0,0 +1,220 @@
+// Package bridge re-exposes the ops event feed and queries over plain HTTP
+// for tools that cannot speak the /ops/ws protocol. Clients authenticate
+// with local bearer tokens instead of agent keys.
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/gateway"
+)
+
+// maxBackfill caps how many missed events a resuming client is sent.
+const maxBackfill = 1000
+
+// keepaliveInterval is how often an idle stream sends an SSE comment.
+const keepaliveInterval = 30 * time.Second
+
+// Bridge serves:
+//
+//	GET /events/stream?types=login.*   SSE feed; Last-Event-ID resumes after that event_id
+//	GET /events?type=&user_id=&...     proxies ListEvents
+//	GET /stats?since=                  proxies GetEventStats
+//	GET /healthz                       upstream status (no token required)
+type Bridge struct {
+	Reader api.Reader
+	Hub    *gateway.Hub
+	Tokens *Tokens
+	// Log receives one access line per request.
+	Log io.Writer
+}
+
+// Handler returns the bridge's HTTP handler.
+func (b *Bridge) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/stream", b.auth(b.serveStream))
+	mux.HandleFunc("GET /events", b.auth(b.serveEvents))
+	mux.HandleFunc("GET /stats", b.auth(b.serveStats))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.Hub.Status())
+	})
+	return mux
+}
+
+func (b *Bridge) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		name, valid := "", false
+		if ok {
+			name, valid = b.Tokens.Lookup(token)
+		}
+		if b.Log != nil {
+			who := name
+			if !valid {
+				who = "-"
+			}
+			fmt.Fprintf(b.Log, "%s %s %s %s\n", time.Now().UTC().Format(time.RFC3339), who, r.Method, r.URL.RequestURI())
+		}
+		if !valid {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="plctl-bridge"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing bearer token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (b *Bridge) serveEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params := api.EventsParams{
+		Type:    q.Get("type"),
+		UserID:  q.Get("user_id"),
+		IP:      q.Get("ip"),
+		ActorID: q.Get("actor_id"),
+		Since:   q.Get("since"),
+	}
+	for name, dst := range map[string]*int{"limit": &params.Limit, "offset": &params.Offset} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " must be a non-negative integer"})
+				return
+			}
+			*dst = n
+		}
+	}
+	resp, err := b.Reader.ListEvents(r.Context(), params)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (b *Bridge) serveStats(w http.ResponseWriter, r *http.Request) {
+	resp, err := b.Reader.GetEventStats(r.Context(), r.URL.Query().Get("since"))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (b *Bridge) serveStream(w http.ResponseWriter, r *http.Request) {
+	types, err := gateway.ParseTypes(r.URL.Query()["types"])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	lastID := 0
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if lastID, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Last-Event-ID must be an event_id"})
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Register before backfilling so nothing pushed in between is lost;
+	// duplicates are skipped by event_id below.
+	c := b.Hub.Add(types)
+	defer b.Hub.Remove(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	sent := lastID
+	if lastID > 0 {
+		missed, truncated, err := b.backfill(r, lastID, c)
+		if err != nil {
+			fmt.Fprintf(w, ": backfill failed: %s\n\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		}
+		if truncated {
+			fmt.Fprintf(w, ": backfill truncated to the latest %d events\n\n", maxBackfill)
+		}
+		for _, e := range missed {
+			if gateway.WriteSSE(w, e) != nil {
+				return
+			}
+			sent = e.ID
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case e := <-c.Events:
+			if e.ID != 0 && e.ID <= sent {
+				continue
+			}
+			if gateway.WriteSSE(w, e) != nil {
+				return
+			}
+			sent = max(sent, e.ID)
+		}
+		flusher.Flush()
+	}
+}
+
+// backfill fetches events newer than lastID via ListEvents, oldest first,
+// filtered like the client's live subscription.
+func (b *Bridge) backfill(r *http.Request, lastID int, c *gateway.Client) (events []api.Event, truncated bool, err error) {
+	const pageSize = 200
+	for offset := 0; ; offset += pageSize {
+		resp, err := b.Reader.ListEvents(r.Context(), api.EventsParams{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, e := range resp.Events {
+			if e.ID <= lastID {
+				return reverseMatching(events, c), false, nil
+			}
+			events = append(events, e)
+			if len(events) >= maxBackfill {
+				return reverseMatching(events, c), true, nil
+			}
+		}
+		if len(resp.Events) < pageSize {
+			return reverseMatching(events, c), false, nil
+		}
+	}
+}
+
+func reverseMatching(events []api.Event, c *gateway.Client) []api.Event {
+	out := make([]api.Event, 0, len(events))
+	for _, e := range events {
+		if c.Matches(e.Type) {
+			out = append(out, e)
+		}
+	}
+	slices.Reverse(out)
+	return out
+}
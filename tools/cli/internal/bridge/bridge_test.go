@@ -0,0 +1,176 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/gateway"
+)
+
+const testToken = "plb_0123456789abcdef"
+
+// fakeReader serves events 1..n newest first, honoring limit and offset.
+type fakeReader struct {
+	n      int
+	params []api.EventsParams
+}
+
+func (f *fakeReader) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	f.params = append(f.params, p)
+	var all []api.Event
+	for id := f.n; id >= 1; id-- {
+		typ := "login.success"
+		if id%2 == 0 {
+			typ = "session.revoke"
+		}
+		all = append(all, api.Event{ID: id, Type: typ})
+	}
+	return &api.ListEventsResponse{Events: api.FilterEvents(all, api.EventsParams{Limit: p.Limit, Offset: p.Offset})}, nil
+}
+
+func (f *fakeReader) ListSessions(context.Context, api.SessionsParams) (*api.ListSessionsResponse, error) {
+	return &api.ListSessionsResponse{}, nil
+}
+
+func (f *fakeReader) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	return &api.EventStatsResponse{Stats: map[string]int{"login.success": 1}, Since: since}, nil
+}
+
+func (f *fakeReader) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{}, nil
+}
+
+func newTestBridge(t *testing.T, r *fakeReader) (*Bridge, *httptest.Server) {
+	t.Helper()
+	tokens, err := ParseTokens(strings.NewReader("# dashboards\ngrafana " + testToken + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bridge{Reader: r, Hub: gateway.NewHub(), Tokens: tokens}
+	srv := httptest.NewServer(b.Handler())
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+func get(t *testing.T, url, token string, header ...string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAuth(t *testing.T) {
+	_, srv := newTestBridge(t, &fakeReader{})
+	for _, token := range []string{"", "plb_wrongwrongwrongwrong"} {
+		resp := get(t, srv.URL+"/stats", token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, resp.StatusCode)
+		}
+	}
+	resp := get(t, srv.URL+"/healthz", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz status %d", resp.StatusCode)
+	}
+}
+
+func TestProxyEvents(t *testing.T) {
+	r := &fakeReader{n: 3}
+	_, srv := newTestBridge(t, r)
+
+	resp := get(t, srv.URL+"/events?type=login.success&user_id=42&limit=5", testToken)
+	defer resp.Body.Close()
+	var out api.ListEventsResponse
+	json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != http.StatusOK || len(out.Events) != 3 {
+		t.Errorf("status %d, %d events", resp.StatusCode, len(out.Events))
+	}
+	want := api.EventsParams{Type: "login.success", UserID: "42", Limit: 5}
+	if r.params[0] != want {
+		t.Errorf("params = %+v, want %+v", r.params[0], want)
+	}
+
+	bad := get(t, srv.URL+"/events?limit=x", testToken)
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad limit status %d", bad.StatusCode)
+	}
+}
+
+// readIDs reads SSE id lines until n are seen.
+func readIDs(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var ids []string
+	for len(ids) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v (ids so far %v)", err, ids)
+		}
+		if id, ok := strings.CutPrefix(strings.TrimSpace(line), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestStreamResumesFromLastEventID(t *testing.T) {
+	b, srv := newTestBridge(t, &fakeReader{n: 450})
+
+	resp := get(t, srv.URL+"/events/stream?types=login.*", testToken, "Last-Event-ID", "440")
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	// Missed odd (login.success) events after 440, oldest first.
+	if got := strings.Join(readIDs(t, r, 5), ","); got != "441,443,445,447,449" {
+		t.Errorf("backfill ids = %s", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.Hub.Status().Clients != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 449 was already sent by backfill; 451 is new.
+	b.Hub.Publish(api.Event{ID: 449, Type: "login.success"})
+	b.Hub.Publish(api.Event{ID: 450, Type: "session.revoke"})
+	b.Hub.Publish(api.Event{ID: 451, Type: "login.failure"})
+	if got := readIDs(t, r, 1)[0]; got != "451" {
+		t.Errorf("live id = %s, want 451", got)
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	if _, err := ParseTokens(strings.NewReader("a short\n")); err == nil {
+		t.Error("short token accepted")
+	}
+	if _, err := ParseTokens(strings.NewReader("# nothing\n")); err == nil {
+		t.Error("empty tokens file accepted")
+	}
+	tok := NewToken()
+	tokens, err := ParseTokens(strings.NewReader("ci " + tok))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := tokens.Lookup(tok); !ok || name != "ci" {
+		t.Errorf("Lookup = %q, %v", name, ok)
+	}
+}
@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tokenPrefix marks bridge tokens so they are not mistaken for agent keys.
+const tokenPrefix = "plb_"
+
+// Tokens maps local bearer tokens to client names. Only SHA-256 digests are
+// kept in memory.
+type Tokens struct {
+	entries []tokenEntry
+}
+
+type tokenEntry struct {
+	name   string
+	digest [sha256.Size]byte
+}
+
+// NewToken returns a random bridge token.
+func NewToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// LoadTokens reads a tokens file.
+func LoadTokens(path string) (*Tokens, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTokens(f)
+}
+
+// ParseTokens reads "name token" lines. Blank lines and # comments are ignored.
+func ParseTokens(r io.Reader) (*Tokens, error) {
+	t := &Tokens{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("tokens line %d: want \"name token\"", n)
+		}
+		if len(fields[1]) < 16 {
+			return nil, fmt.Errorf("tokens line %d: token for %s is too short", n, fields[0])
+		}
+		t.entries = append(t.entries, tokenEntry{name: fields[0], digest: sha256.Sum256([]byte(fields[1]))})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.entries) == 0 {
+		return nil, fmt.Errorf("no tokens defined")
+	}
+	return t, nil
+}
+
+// Lookup returns the client name for a presented token.
+func (t *Tokens) Lookup(token string) (string, bool) {
+	digest := sha256.Sum256([]byte(token))
+	name, found := "", false
+	// Compare against every entry so timing does not reveal which matched.
+	for _, e := range t.entries {
+		if subtle.ConstantTimeCompare(digest[:], e.digest[:]) == 1 {
+			name, found = e.name, true
+		}
+	}
+	return name, found
+}
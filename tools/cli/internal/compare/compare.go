@@ -0,0 +1,183 @@
+// Package compare contrasts event counts in the current window with the
+// windows before it and flags types whose change stands out from that
+// history.
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Flag marks a type whose current count is anomalous against its baseline.
+type Flag int
+
+const (
+	None  Flag = iota
+	Spike      // well above the baseline: a likely regression
+	Drop       // well below the baseline
+)
+
+func (f Flag) String() string {
+	return [...]string{"", "spike", "drop"}[f]
+}
+
+// MarshalText encodes the flag by name.
+func (f Flag) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+
+// Options configures a comparison.
+type Options struct {
+	Window  time.Duration // length of each window
+	History int           // earlier windows forming the baseline; the first is the previous window
+	Z       float64       // |z| at or above which a change is flagged
+	Min     int           // don't flag types where neither the current count nor the baseline mean reaches this
+	Now     time.Time     // end of the current window; zero means time.Now
+}
+
+// Change is one event type's current count against its history.
+type Change struct {
+	Type    string  `json:"type"`
+	Current int     `json:"current"`
+	History []int   `json:"history"` // baseline windows, most recent first
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stddev"`
+	Z       float64 `json:"z"`
+	Flag    Flag    `json:"flag,omitempty"`
+}
+
+// Previous is the count in the window just before the current one.
+func (c Change) Previous() int { return c.History[0] }
+
+// Delta is the change from the previous window.
+func (c Change) Delta() int { return c.Current - c.Previous() }
+
+// Percent formats the change from the previous window.
+func (c Change) Percent() string {
+	switch prev := c.Previous(); {
+	case c.Current == 0 && prev == 0:
+		return "–"
+	case prev == 0:
+		return "new"
+	default:
+		return fmt.Sprintf("%+.0f%%", float64(c.Current-prev)/float64(prev)*100)
+	}
+}
+
+// Comparison is every type seen in the current window or its history,
+// flagged types first by |z|, then by current count.
+type Comparison struct {
+	Window  time.Duration `json:"window"`
+	Since   time.Time     `json:"since"` // start of the current window
+	Until   time.Time     `json:"until"`
+	Changes []Change      `json:"changes"`
+}
+
+// Flagged returns the flagged changes.
+func (c *Comparison) Flagged() []Change {
+	var out []Change
+	for _, ch := range c.Changes {
+		if ch.Flag != None {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// Compare reads stats for the current window and o.History windows before
+// it. The stats endpoint only takes a start, so it asks for each cumulative
+// range from one fixed now and takes the differences.
+func Compare(ctx context.Context, r api.Reader, o Options) (*Comparison, error) {
+	if o.Window <= 0 || o.History < 1 {
+		return nil, fmt.Errorf("compare needs a positive window and at least one earlier window")
+	}
+	now := o.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC().Truncate(time.Second)
+
+	cumulative := make([]map[string]int, o.History+1)
+	for k := range cumulative {
+		since := now.Add(-time.Duration(k+1) * o.Window)
+		resp, err := r.GetEventStats(ctx, since.Format(time.RFC3339))
+		if err != nil {
+			return nil, fmt.Errorf("event stats since %s: %w", since.Format(time.RFC3339), err)
+		}
+		cumulative[k] = resp.Stats
+	}
+	windows := make([]map[string]int, len(cumulative))
+	windows[0] = cumulative[0]
+	for k := 1; k < len(cumulative); k++ {
+		windows[k] = make(map[string]int, len(cumulative[k]))
+		for t, n := range cumulative[k] {
+			windows[k][t] = max(n-cumulative[k-1][t], 0)
+		}
+	}
+	return &Comparison{
+		Window:  o.Window,
+		Since:   now.Add(-o.Window),
+		Until:   now,
+		Changes: changes(windows, o),
+	}, nil
+}
+
+// changes scores each type in windows[0] against windows[1:]. The standard
+// deviation has a floor of √mean (at least 1), the noise expected of a
+// count, so a flat history does not make every small change infinite.
+func changes(windows []map[string]int, o Options) []Change {
+	types := map[string]bool{}
+	for _, w := range windows {
+		for t := range w {
+			types[t] = true
+		}
+	}
+	var out []Change
+	for t := range types {
+		c := Change{Type: t, Current: windows[0][t], History: make([]int, len(windows)-1)}
+		for k := range c.History {
+			c.History[k] = windows[k+1][t]
+			c.Mean += float64(c.History[k])
+		}
+		c.Mean /= float64(len(c.History))
+		for _, n := range c.History {
+			c.StdDev += (float64(n) - c.Mean) * (float64(n) - c.Mean)
+		}
+		c.StdDev = math.Sqrt(c.StdDev / float64(len(c.History)))
+		c.Z = (float64(c.Current) - c.Mean) / max(c.StdDev, math.Sqrt(max(c.Mean, 1)))
+		if math.Abs(c.Z) >= o.Z && max(float64(c.Current), c.Mean) >= float64(o.Min) {
+			c.Flag = Spike
+			if c.Z < 0 {
+				c.Flag = Drop
+			}
+		}
+		out = append(out, c)
+	}
+	slices.SortFunc(out, Order)
+	return out
+}
+
+// Order sorts flagged changes first by descending |z|, then the rest by
+// descending current count, then by type.
+func Order(a, b Change) int {
+	if (a.Flag != None) != (b.Flag != None) {
+		if a.Flag != None {
+			return -1
+		}
+		return 1
+	}
+	if a.Flag != None && math.Abs(a.Z) != math.Abs(b.Z) {
+		if math.Abs(a.Z) > math.Abs(b.Z) {
+			return -1
+		}
+		return 1
+	}
+	if a.Current != b.Current {
+		return b.Current - a.Current
+	}
+	return strings.Compare(a.Type, b.Type)
+}
@@ -0,0 +1,132 @@
+package compare
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+var now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeStats answers GetEventStats from in-memory events, like the server:
+// counts by type of events at or after since.
+type fakeStats struct {
+	api.Reader
+	events []api.Event
+	since  []string
+}
+
+func (f *fakeStats) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	f.since = append(f.since, since)
+	start, _ := api.ParseTimestamp(since)
+	stats := map[string]int{}
+	for _, e := range f.events {
+		if t, _ := api.ParseTimestamp(e.CreatedAt); !t.Before(start) {
+			stats[e.Type]++
+		}
+	}
+	return &api.EventStatsResponse{Since: since, Stats: stats}, nil
+}
+
+// hourly builds events with counts[k] of typ in the k-th hour before now.
+func hourly(typ string, counts ...int) []api.Event {
+	var out []api.Event
+	for k, n := range counts {
+		for range n {
+			at := now.Add(-time.Duration(k)*time.Hour - 30*time.Minute)
+			out = append(out, api.Event{Type: typ, CreatedAt: at.Format(time.DateTime)})
+		}
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	f := &fakeStats{}
+	for _, e := range [][]api.Event{
+		hourly("login.failure", 20, 2, 3, 2),
+		hourly("login.success", 5, 5, 4, 6),
+		hourly("registration.failure", 6),
+		hourly("session.revoke", 0, 10, 10, 10),
+		hourly("challenge.issued", 3),
+		hourly("ws.unauthorized", 0, 0, 0, 1),
+		hourly("login.failure", 0, 0, 0, 0, 50), // before the oldest window
+	} {
+		f.events = append(f.events, e...)
+	}
+
+	c, err := Compare(context.Background(), f, Options{Window: time.Hour, History: 3, Z: 3, Min: 5, Now: now.Add(500 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2025-03-01T11:00:00Z", "2025-03-01T10:00:00Z", "2025-03-01T09:00:00Z", "2025-03-01T08:00:00Z"}; !reflect.DeepEqual(f.since, want) {
+		t.Errorf("since = %v, want %v", f.since, want)
+	}
+	if !c.Since.Equal(now.Add(-time.Hour)) || !c.Until.Equal(now) {
+		t.Errorf("window = %s to %s", c.Since, c.Until)
+	}
+
+	type row struct {
+		typ     string
+		current int
+		history []int
+		flag    Flag
+	}
+	var got []row
+	for _, ch := range c.Changes {
+		got = append(got, row{ch.Type, ch.Current, ch.History, ch.Flag})
+	}
+	want := []row{
+		{"login.failure", 20, []int{2, 3, 2}, Spike},
+		{"registration.failure", 6, []int{0, 0, 0}, Spike},
+		{"session.revoke", 0, []int{10, 10, 10}, Drop},
+		{"login.success", 5, []int{5, 4, 6}, None},
+		{"challenge.issued", 3, []int{0, 0, 0}, None}, // z 3, but under Min
+		{"ws.unauthorized", 0, []int{0, 0, 1}, None},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes =\n%+v\nwant\n%+v", got, want)
+	}
+	if len(c.Flagged()) != 3 {
+		t.Errorf("flagged = %d, want 3", len(c.Flagged()))
+	}
+
+	ls := c.Changes[3]
+	if ls.Mean != 5 || math.Abs(ls.StdDev-math.Sqrt(2.0/3)) > 1e-9 || math.Abs(ls.Z) > 1e-9 {
+		t.Errorf("login.success mean %v stddev %v z %v", ls.Mean, ls.StdDev, ls.Z)
+	}
+	if math.Abs(c.Changes[1].Z-6) > 1e-9 {
+		t.Errorf("registration.failure z = %v, want 6 (stddev floor 1)", c.Changes[1].Z)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	for _, c := range []struct {
+		cur, prev int
+		want      string
+	}{
+		{0, 0, "–"},
+		{4, 0, "new"},
+		{15, 10, "+50%"},
+		{0, 10, "-100%"},
+	} {
+		ch := Change{Current: c.cur, History: []int{c.prev}}
+		if got := ch.Percent(); got != c.want {
+			t.Errorf("Percent(%d, %d) = %q, want %q", c.cur, c.prev, got, c.want)
+		}
+		if ch.Delta() != c.cur-c.prev {
+			t.Errorf("Delta(%d, %d) = %d", c.cur, c.prev, ch.Delta())
+		}
+	}
+}
+
+func TestCompareOptions(t *testing.T) {
+	for _, o := range []Options{{Window: time.Hour}, {History: 3}} {
+		if _, err := Compare(context.Background(), &fakeStats{}, o); err == nil {
+			t.Errorf("Compare(%+v): want error", o)
+		}
+	}
+}
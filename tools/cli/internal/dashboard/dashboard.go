@@ -0,0 +1,172 @@
+// Package dashboard keeps the rolling state behind the TUI wallboard: live
+// events bucketed per minute for sparklines, periodic stats, and the
+// derived login ratio, challenge rate and top IPs.
+package dashboard
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+const (
+	// Window is how far back the dashboard looks.
+	Window = time.Hour
+	// maxPoints bounds memory on a very busy deployment.
+	maxPoints = 20000
+)
+
+type point struct {
+	id  int
+	at  time.Time
+	typ string
+	ip  string
+}
+
+// Ranked is a key with an occurrence count.
+type Ranked struct {
+	Key   string
+	Count int
+}
+
+// Dashboard is the wallboard state. The zero value is ready to use.
+type Dashboard struct {
+	points []point // oldest first
+	seen   map[int]bool
+
+	// Stats are event counts over Window from the last refresh.
+	Stats          map[string]int
+	ActiveSessions int
+	Updated        time.Time
+}
+
+// Add records an event, ignoring duplicates and anything outside Window.
+func (d *Dashboard) Add(e api.Event, now time.Time) {
+	at, err := api.ParseTimestamp(e.CreatedAt)
+	if err != nil || now.Sub(at) > Window {
+		return
+	}
+	if d.seen == nil {
+		d.seen = make(map[int]bool)
+	}
+	if e.ID != 0 {
+		if d.seen[e.ID] {
+			return
+		}
+		d.seen[e.ID] = true
+	}
+	p := point{id: e.ID, at: at, typ: e.Type, ip: e.IPAddress}
+	// Live events arrive in order; backfill is sorted once in Prune.
+	d.points = append(d.points, p)
+	if len(d.points) > maxPoints {
+		d.points = d.points[len(d.points)-maxPoints:]
+	}
+}
+
+// Prune drops events older than Window and restores time order.
+func (d *Dashboard) Prune(now time.Time) {
+	sort.SliceStable(d.points, func(i, j int) bool { return d.points[i].at.Before(d.points[j].at) })
+	cut := sort.Search(len(d.points), func(i int) bool { return now.Sub(d.points[i].at) <= Window })
+	for _, p := range d.points[:cut] {
+		delete(d.seen, p.id)
+	}
+	d.points = d.points[cut:]
+}
+
+// Series returns counts of events matching pattern (as in subscription
+// filters, e.g. login.*) in n equal buckets covering Window, oldest first.
+func (d *Dashboard) Series(pattern string, n int, now time.Time) []int {
+	out := make([]int, n)
+	if n == 0 {
+		return out
+	}
+	width := Window / time.Duration(n)
+	for _, p := range d.points {
+		age := now.Sub(p.at)
+		if age < 0 || age > Window || !api.MatchEventType(pattern, p.typ) {
+			continue
+		}
+		i := n - 1 - int(age/width)
+		out[max(i, 0)]++
+	}
+	return out
+}
+
+// TopIPs returns the n most frequent source IPs in the window.
+func (d *Dashboard) TopIPs(n int) []Ranked {
+	counts := make(map[string]int)
+	for _, p := range d.points {
+		if p.ip != "" {
+			counts[p.ip]++
+		}
+	}
+	ranked := make([]Ranked, 0, len(counts))
+	for ip, c := range counts {
+		ranked = append(ranked, Ranked{ip, c})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Key < ranked[j].Key
+	})
+	return ranked[:min(n, len(ranked))]
+}
+
+// Types returns the stats event types, busiest first.
+func (d *Dashboard) Types() []string {
+	types := make([]string, 0, len(d.Stats))
+	for t := range d.Stats {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if d.Stats[types[i]] != d.Stats[types[j]] {
+			return d.Stats[types[i]] > d.Stats[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// LoginRatio is login successes over all login attempts in the window; ok
+// is false when there were none.
+func (d *Dashboard) LoginRatio() (ratio float64, ok bool) {
+	success, failure := d.Stats["login.success"], d.Stats["login.failure"]
+	if success+failure == 0 {
+		return 0, false
+	}
+	return float64(success) / float64(success+failure), true
+}
+
+// ChallengeRate is PoW challenges issued per minute over the window.
+func (d *Dashboard) ChallengeRate() float64 {
+	return float64(d.Stats["challenge.issued"]) / Window.Minutes()
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values scaled to their maximum; zero is a space.
+func Sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if v == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparks[v*(len(sparks)-1)/peak])
+	}
+	return b.String()
+}
+
+// Gauge renders ratio (0..1) as a bar of width cells.
+func Gauge(ratio float64, width int) string {
+	ratio = min(max(ratio, 0), 1)
+	filled := int(ratio*float64(width) + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
@@ -0,0 +1,62 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func TestSeriesAndPrune(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	var d Dashboard
+	d.Add(api.Event{ID: 1, Type: "login.failure", IPAddress: "10.0.0.1", CreatedAt: at(30 * time.Second)}, now)
+	d.Add(api.Event{ID: 2, Type: "login.success", IPAddress: "10.0.0.2", CreatedAt: at(90 * time.Second)}, now)
+	d.Add(api.Event{ID: 3, Type: "login.failure", IPAddress: "10.0.0.1", CreatedAt: at(59 * time.Minute)}, now)
+	d.Add(api.Event{ID: 3, Type: "login.failure", IPAddress: "10.0.0.1", CreatedAt: at(59 * time.Minute)}, now) // duplicate
+	d.Add(api.Event{ID: 4, Type: "login.failure", CreatedAt: at(2 * time.Hour)}, now)                           // outside window
+
+	got := d.Series("login.*", 6, now) // 10-minute buckets
+	if want := []int{1, 0, 0, 0, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Series = %v, want %v", got, want)
+	}
+	if got := d.Series("login.success", 6, now); got[5] != 1 {
+		t.Errorf("exact type series = %v", got)
+	}
+	if top := d.TopIPs(1); len(top) != 1 || top[0] != (Ranked{"10.0.0.1", 2}) {
+		t.Errorf("TopIPs = %v", top)
+	}
+
+	d.Prune(now.Add(2 * time.Minute))
+	if got := d.Series("login.*", 6, now.Add(2*time.Minute)); got[5] != 2 || got[0] != 0 {
+		t.Errorf("after prune = %v", got)
+	}
+}
+
+func TestDerivedStats(t *testing.T) {
+	d := Dashboard{Stats: map[string]int{"login.success": 3, "login.failure": 1, "challenge.issued": 120}}
+	if r, ok := d.LoginRatio(); !ok || r != 0.75 {
+		t.Errorf("LoginRatio = %v, %v", r, ok)
+	}
+	if got := d.ChallengeRate(); got != 2 {
+		t.Errorf("ChallengeRate = %v, want 2/min", got)
+	}
+	if got := d.Types(); !reflect.DeepEqual(got, []string{"challenge.issued", "login.success", "login.failure"}) {
+		t.Errorf("Types = %v", got)
+	}
+	if _, ok := (&Dashboard{}).LoginRatio(); ok {
+		t.Error("LoginRatio ok with no logins")
+	}
+}
+
+func TestSparklineAndGauge(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Gauge(0.75, 4); got != "███░" {
+		t.Errorf("Gauge = %q", got)
+	}
+}
@@ -0,0 +1,255 @@
+// Package doctor checks an agent key end to end: REST reachability, TLS,
+// the PoW challenge, WebSocket capability negotiation, ping round trip and
+// clock skew against the server heartbeat.
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Check statuses, in increasing severity.
+const (
+	StatusOK   = "ok"
+	StatusSkip = "skip"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+const (
+	// maxSkew is the clock difference beyond which the skew check warns.
+	maxSkew = 5 * time.Second
+	// certWarn is how close to expiry a certificate draws a warning.
+	certWarn = 14 * 24 * time.Hour
+)
+
+// Check is one diagnostic step.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Identity is what the server reported during capability negotiation.
+type Identity struct {
+	Agent        string            `json:"agent"`
+	ConnectionID string            `json:"connection_id"`
+	Granted      []string          `json:"granted"`
+	Denied       []api.WSDeniedCap `json:"denied"`
+}
+
+// TLSInfo summarizes the negotiated TLS connection.
+type TLSInfo struct {
+	Version     string    `json:"version"`
+	CipherSuite string    `json:"cipher_suite"`
+	ALPN        string    `json:"alpn,omitempty"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	NotAfter    time.Time `json:"not_after"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Target   string        `json:"target"`
+	Identity *Identity     `json:"identity,omitempty"`
+	TLS      *TLSInfo      `json:"tls,omitempty"`
+	RTT      time.Duration `json:"rtt_ns,omitempty"`
+	Skew     time.Duration `json:"skew_ns,omitempty"`
+	Checks   []Check       `json:"checks"`
+}
+
+// Failed reports whether any check failed.
+func (r *Report) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(name, status, format string, args ...any) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Doctor runs the checks against one target.
+type Doctor struct {
+	Client *api.Client
+	Target string
+	// Heartbeat is how long to wait for a server heartbeat to measure clock
+	// skew. Zero skips the heartbeat and uses the coarser HTTP Date header.
+	Heartbeat time.Duration
+
+	now func() time.Time
+}
+
+// Identify negotiates every known capability and returns the grant. It is
+// the quick path behind whoami.
+func (d *Doctor) Identify(ctx context.Context) (*Report, error) {
+	r := &Report{Target: d.Target}
+	conn, err := d.connect(ctx, r)
+	if err != nil {
+		return r, err
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	return r, nil
+}
+
+// Run performs every check. Failures are recorded in the report; later
+// checks that depend on a failed one are skipped.
+func (d *Doctor) Run(ctx context.Context) *Report {
+	if d.now == nil {
+		d.now = time.Now
+	}
+	r := &Report{Target: d.Target}
+
+	var serverDate time.Time
+	probe, err := d.Client.ProbeREST(ctx)
+	switch {
+	case err != nil:
+		r.add("rest", StatusFail, "%v", err)
+	case probe.Err != "":
+		r.add("rest", StatusFail, "%s", probe.Err)
+	default:
+		r.add("rest", StatusOK, "HTTP %d in %s", probe.Status, probe.Latency.Round(time.Millisecond))
+	}
+	if probe != nil {
+		serverDate = probe.Date
+		d.checkTLS(r, probe.TLS)
+	}
+
+	conn, err := d.connect(ctx, r)
+	if err != nil {
+		r.add("ping", StatusSkip, "no connection")
+		r.add("clock", StatusSkip, "no connection")
+		return r
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	if rtt, err := api.Ping(ctx, conn, "doctor-1"); err != nil {
+		r.add("ping", StatusFail, "%v", err)
+	} else {
+		r.RTT = rtt
+		r.add("ping", StatusOK, "round trip %s", rtt.Round(time.Millisecond))
+	}
+
+	switch {
+	case d.Heartbeat > 0:
+		hctx, cancel := context.WithTimeout(ctx, d.Heartbeat)
+		hb, err := api.WaitHeartbeat(hctx, conn)
+		cancel()
+		if err != nil {
+			r.add("clock", StatusWarn, "no heartbeat within %s", d.Heartbeat)
+			break
+		}
+		// The heartbeat was stamped roughly half a round trip ago.
+		r.Skew = d.now().Sub(time.UnixMilli(hb.TS)) - r.RTT/2
+		d.checkSkew(r, "heartbeat")
+	case !serverDate.IsZero():
+		r.Skew = d.now().Sub(serverDate)
+		d.checkSkew(r, "HTTP Date, ±1s")
+	default:
+		r.add("clock", StatusSkip, "no server time available")
+	}
+	return r
+}
+
+// connect runs the challenge, dial and negotiation checks.
+func (d *Doctor) connect(ctx context.Context, r *Report) (*websocket.Conn, error) {
+	start := time.Now()
+	challenge, err := d.Client.ProbeChallenge(ctx)
+	if err != nil {
+		r.add("challenge", StatusFail, "%v", err)
+		return nil, err
+	}
+	if challenge.Required {
+		r.add("challenge", StatusOK, "difficulty %d solved in %s", challenge.Difficulty, time.Since(start).Round(time.Millisecond))
+	} else {
+		r.add("challenge", StatusOK, "not required")
+	}
+
+	conn, err := d.Client.ConnectWS(ctx, challenge)
+	if err != nil {
+		r.add("websocket", StatusFail, "%v", err)
+		return nil, err
+	}
+	r.add("websocket", StatusOK, "connected")
+
+	granted, err := api.Negotiate(ctx, conn, api.KnownCapabilities)
+	if err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		r.add("capabilities", StatusFail, "%v", err)
+		return nil, err
+	}
+	r.Identity = &Identity{
+		Agent:        granted.Agent,
+		ConnectionID: granted.ConnectionID,
+		Granted:      granted.Granted,
+		Denied:       granted.Denied,
+	}
+	switch {
+	case len(granted.Granted) == 0:
+		r.add("capabilities", StatusFail, "all capabilities denied")
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, errors.New("all capabilities denied")
+	case len(granted.Denied) > 0:
+		names := make([]string, len(granted.Denied))
+		for i, dc := range granted.Denied {
+			names[i] = dc.Capability
+		}
+		r.add("capabilities", StatusWarn, "%d granted, denied: %s", len(granted.Granted), strings.Join(names, ", "))
+	default:
+		r.add("capabilities", StatusOK, "all %d granted", len(granted.Granted))
+	}
+	return conn, nil
+}
+
+func (d *Doctor) checkTLS(r *Report, cs *tls.ConnectionState) {
+	if cs == nil {
+		if u, err := url.Parse(d.Target); err == nil && api.IsLoopback(u.Hostname()) {
+			r.add("tls", StatusSkip, "plain HTTP to loopback")
+		} else {
+			r.add("tls", StatusWarn, "plain HTTP; the agent key travels unencrypted")
+		}
+		return
+	}
+	info := &TLSInfo{
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ALPN:        cs.NegotiatedProtocol,
+	}
+	if len(cs.PeerCertificates) > 0 {
+		leaf := cs.PeerCertificates[0]
+		info.Subject = leaf.Subject.CommonName
+		info.Issuer = leaf.Issuer.CommonName
+		info.NotAfter = leaf.NotAfter
+	}
+	r.TLS = info
+
+	left := info.NotAfter.Sub(d.now())
+	switch {
+	case cs.Version < tls.VersionTLS12:
+		r.add("tls", StatusWarn, "%s is below TLS 1.2", info.Version)
+	case !info.NotAfter.IsZero() && left < certWarn:
+		r.add("tls", StatusWarn, "%s, certificate expires in %d days", info.Version, int(left.Hours()/24))
+	default:
+		r.add("tls", StatusOK, "%s %s, certificate valid until %s", info.Version, info.CipherSuite, info.NotAfter.Format(time.DateOnly))
+	}
+}
+
+func (d *Doctor) checkSkew(r *Report, source string) {
+	skew := r.Skew.Round(time.Millisecond)
+	if r.Skew > maxSkew || r.Skew < -maxSkew {
+		r.add("clock", StatusWarn, "local clock is %s off the server (%s); PoW and token expiry may misbehave", skew, source)
+		return
+	}
+	r.add("clock", StatusOK, "skew %s (%s)", skew, source)
+}
@@ -0,0 +1,122 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/private-landing/cli/internal/api"
+)
+
+// fakeOps serves /ops/events and an /ops/ws that grants read capabilities,
+// answers pings and sends one heartbeat stamped skew behind local time.
+func fakeOps(t *testing.T, skew time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/ops/events":
+			w.Write([]byte(`{"events":[],"count":0}`))
+		case r.URL.Path == "/ops/ws" && r.Header.Get("Upgrade") == "":
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+		case r.URL.Path == "/ops/ws":
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.CloseNow()
+			ctx := r.Context()
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+			send := func(v any) {
+				b, _ := json.Marshal(v)
+				conn.Write(ctx, websocket.MessageText, b)
+			}
+			send(api.WSCapabilitiesGranted{
+				Type:         "capability.granted",
+				ConnectionID: "conn-1",
+				Agent:        "siem",
+				Granted:      []string{"query_events", "query_sessions", "subscribe_events"},
+				Denied:       []api.WSDeniedCap{{Capability: "revoke_session", Reason: "requires write trust"}},
+			})
+			for {
+				_, data, err := conn.Read(ctx)
+				if err != nil {
+					return
+				}
+				var ping api.WSPingRequest
+				json.Unmarshal(data, &ping)
+				send(map[string]any{"type": "pong", "id": ping.ID, "ok": true})
+				send(api.WSHeartbeat{Type: "heartbeat", TS: time.Now().Add(-skew).UnixMilli()})
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func statuses(r *Report) map[string]string {
+	m := make(map[string]string)
+	for _, c := range r.Checks {
+		m[c.Name] = c.Status
+	}
+	return m
+}
+
+func TestRun(t *testing.T) {
+	srv := fakeOps(t, 10*time.Second)
+	defer srv.Close()
+
+	d := &Doctor{Client: api.NewClient(srv.URL, "key", ""), Target: srv.URL, Heartbeat: 5 * time.Second}
+	r := d.Run(context.Background())
+
+	want := map[string]string{
+		"rest":         StatusOK,
+		"tls":          StatusSkip, // plain HTTP to 127.0.0.1
+		"challenge":    StatusOK,
+		"websocket":    StatusOK,
+		"capabilities": StatusWarn,
+		"ping":         StatusOK,
+		"clock":        StatusWarn,
+	}
+	got := statuses(r)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s = %q, want %q (%+v)", name, got[name], status, r.Checks)
+		}
+	}
+	if r.Failed() {
+		t.Error("Failed() = true, want warnings only")
+	}
+	if r.Identity == nil || r.Identity.Agent != "siem" || r.Identity.ConnectionID != "conn-1" {
+		t.Fatalf("identity = %+v", r.Identity)
+	}
+	if len(r.Identity.Denied) != 1 || r.Identity.Denied[0].Reason != "requires write trust" {
+		t.Errorf("denied = %+v", r.Identity.Denied)
+	}
+	if r.Skew < 9*time.Second || r.Skew > 11*time.Second {
+		t.Errorf("skew = %s, want about 10s", r.Skew)
+	}
+}
+
+func TestRunUnreachable(t *testing.T) {
+	d := &Doctor{Client: api.NewClient("http://127.0.0.1:1", "key", ""), Target: "http://127.0.0.1:1"}
+	r := d.Run(context.Background())
+	if !r.Failed() {
+		t.Fatal("Failed() = false for unreachable target")
+	}
+	got := statuses(r)
+	if got["rest"] != StatusFail || got["challenge"] != StatusFail || got["ping"] != StatusSkip {
+		t.Errorf("checks = %+v", r.Checks)
+	}
+	for _, c := range r.Checks {
+		if c.Name == "rest" && !strings.Contains(c.Detail, "request failed") {
+			t.Errorf("rest detail = %q", c.Detail)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+// Package evidence writes and reads incident evidence bundles: a gzipped tar
+// of events, sessions, stats and agents with a SHA-256 manifest and an
+// optional ed25519 signature.
+package evidence
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Format identifies the bundle layout version.
+const Format = "plctl-evidence/1"
+
+// File names inside a bundle.
+const (
+	ManifestFile  = "manifest.json"
+	SignatureFile = "manifest.sig"
+	EventsFile    = "events.ndjson"
+	SessionsFile  = "sessions.json"
+	StatsFile     = "stats.json"
+	AgentsFile    = "agents.json"
+)
+
+// ErrDigestMismatch is returned when a bundle file does not match its manifest digest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// ErrBadSignature is returned when a bundle's signature does not verify.
+var ErrBadSignature = errors.New("signature does not verify")
+
+// ErrUnlisted is returned when a bundle carries a file its manifest does
+// not list, so its contents are not covered by a digest.
+var ErrUnlisted = errors.New("not listed in the manifest")
+
+// Query records the selectors used to collect a bundle.
+type Query struct {
+	UserID  string `json:"user_id,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	Type    string `json:"type,omitempty"`
+	ActorID string `json:"actor_id,omitempty"`
+	Since   string `json:"since"`
+	Until   string `json:"until"`
+}
+
+// FileEntry is a manifest record for one bundle file.
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes a bundle's provenance and contents.
+type Manifest struct {
+	Format      string      `json:"format"`
+	CreatedAt   string      `json:"created_at"`
+	Version     string      `json:"plctl_version"`
+	Target      string      `json:"target"`
+	Environment string      `json:"environment,omitempty"`
+	Query       Query       `json:"query"`
+	Files       []FileEntry `json:"files"`
+}
+
+// Signature is an ed25519 signature over the raw manifest.json bytes.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// Bundle is the in-memory form of an evidence bundle.
+type Bundle struct {
+	Manifest  Manifest
+	Events    []api.Event
+	Sessions  []api.Session
+	Stats     *api.EventStatsResponse
+	Agents    []api.Agent
+	Signature *Signature
+}
+
+// Write serializes b as a gzipped tar. Manifest.Files is recomputed. When key
+// is non-nil the manifest is signed.
+func Write(w io.Writer, b *Bundle, key ed25519.PrivateKey) error {
+	var events bytes.Buffer
+	enc := json.NewEncoder(&events)
+	for _, e := range b.Events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("encode event: %w", err)
+		}
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{{EventsFile, events.Bytes()}}
+	for _, f := range []struct {
+		name string
+		v    interface{}
+	}{
+		{SessionsFile, nonNil(b.Sessions)},
+		{StatsFile, b.Stats},
+		{AgentsFile, nonNil(b.Agents)},
+	} {
+		data, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", f.name, err)
+		}
+		files = append(files, struct {
+			name string
+			data []byte
+		}{f.name, data})
+	}
+
+	b.Manifest.Format = Format
+	b.Manifest.Files = make([]FileEntry, len(files))
+	for i, f := range files {
+		sum := sha256.Sum256(f.data)
+		b.Manifest.Files[i] = FileEntry{Name: f.name, Size: len(f.data), SHA256: hex.EncodeToString(sum[:])}
+	}
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	modTime, err := api.ParseTimestamp(b.Manifest.CreatedAt)
+	if err != nil {
+		modTime = time.Now()
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := add(ManifestFile, manifest); err != nil {
+		return err
+	}
+	if key != nil {
+		b.Signature = &Signature{
+			Algorithm: "ed25519",
+			PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)),
+		}
+		sig, _ := json.MarshalIndent(b.Signature, "", "  ")
+		if err := add(SignatureFile, sig); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := add(f.name, f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFile writes a bundle to path, refusing to overwrite an existing file.
+func WriteFile(path string, b *Bundle, key ed25519.PrivateKey) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, b, key); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// Read parses a bundle, verifying every file digest and the signature if present.
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+	defer gz.Close()
+
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		contents[hdr.Name] = data
+	}
+
+	manifest, ok := contents[ManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("bundle has no %s", ManifestFile)
+	}
+	var b Bundle
+	if err := json.Unmarshal(manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if b.Manifest.Format != Format {
+		return nil, fmt.Errorf("unsupported bundle format %q", b.Manifest.Format)
+	}
+
+	// Only files the manifest lists, and so hashes, are decoded.
+	verified := make(map[string][]byte, len(b.Manifest.Files))
+	for _, f := range b.Manifest.Files {
+		data, ok := contents[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing from bundle", f.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("%s: %w", f.Name, ErrDigestMismatch)
+		}
+		verified[f.Name] = data
+	}
+	for name := range contents {
+		if _, ok := verified[name]; !ok && name != ManifestFile && name != SignatureFile {
+			return nil, fmt.Errorf("%s: %w", name, ErrUnlisted)
+		}
+	}
+
+	if sig, ok := contents[SignatureFile]; ok {
+		b.Signature = &Signature{}
+		if err := json.Unmarshal(sig, b.Signature); err != nil {
+			return nil, fmt.Errorf("decode signature: %w", err)
+		}
+		if err := b.Signature.verify(manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, line := range bytes.Split(verified[EventsFile], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		e, err := api.DecodeEvent(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EventsFile, err)
+		}
+		b.Events = append(b.Events, e)
+	}
+	for name, v := range map[string]interface{}{SessionsFile: &b.Sessions, StatsFile: &b.Stats, AgentsFile: &b.Agents} {
+		if data, ok := verified[name]; ok {
+			if err := json.Unmarshal(data, v); err != nil {
+				return nil, fmt.Errorf("decode %s: %w", name, err)
+			}
+		}
+	}
+	return &b, nil
+}
+
+// Open reads and verifies a bundle file.
+func Open(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (s *Signature) verify(manifest []byte) error {
+	if s.Algorithm != "ed25519" {
+		return fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+	pub, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid signature public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(pub), manifest, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// LoadSigningKey reads a PEM-encoded PKCS#8 ed25519 private key, as produced
+// by `openssl genpkey -algorithm ed25519`.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// LoadPublicKey reads a PEM-encoded PKIX ed25519 public key, as produced by
+// `openssl pkey -pubout`.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// SignedBy reports whether the bundle carries a verified signature from pub.
+// Read has already checked the signature itself; this pins the signer.
+func (b *Bundle) SignedBy(pub ed25519.PublicKey) bool {
+	if b.Signature == nil {
+		return false
+	}
+	got, err := base64.StdEncoding.DecodeString(b.Signature.PublicKey)
+	return err == nil && pub.Equal(ed25519.PublicKey(got))
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// --- api.Reader over bundle contents (read-only TUI) ---
+
+var _ api.Reader = (*Bundle)(nil)
+
+// ListEvents filters the bundled events.
+func (b *Bundle) ListEvents(_ context.Context, params api.EventsParams) (*api.ListEventsResponse, error) {
+	return &api.ListEventsResponse{Events: api.FilterEvents(b.Events, params)}, nil
+}
+
+// ListSessions filters the bundled sessions.
+func (b *Bundle) ListSessions(_ context.Context, params api.SessionsParams) (*api.ListSessionsResponse, error) {
+	return &api.ListSessionsResponse{Sessions: api.FilterSessions(b.Sessions, params)}, nil
+}
+
+// GetEventStats returns the stats captured at collection time. A non-empty
+// since recomputes counts from the bundled events instead.
+func (b *Bundle) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	if since == "" && b.Stats != nil {
+		return b.Stats, nil
+	}
+	// Unparseable timestamps count, as they do in api.FilterEvents.
+	from, err := api.ParseTimestamp(since)
+	stats := make(map[string]int)
+	for _, e := range b.Events {
+		if err == nil {
+			if t, terr := api.ParseTimestamp(e.CreatedAt); terr == nil && t.Before(from) {
+				continue
+			}
+		}
+		stats[e.Type]++
+	}
+	return &api.EventStatsResponse{Stats: stats, Since: since}, nil
+}
+
+// ListAgents returns the agents captured at collection time.
+func (b *Bundle) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{Agents: nonNil(b.Agents)}, nil
+}
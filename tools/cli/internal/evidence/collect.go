@@ -0,0 +1,125 @@
+package evidence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Ops is the subset of api.Client operations collection uses.
+type Ops interface {
+	ListAllEvents(ctx context.Context, params api.EventsParams) ([]api.Event, error)
+	ListAllSessions(ctx context.Context, params api.SessionsParams) ([]api.Session, error)
+	GetEventStats(ctx context.Context, since string) (*api.EventStatsResponse, error)
+	ListAgents(ctx context.Context) (*api.ListAgentsResponse, error)
+}
+
+// Collector gathers a bundle from the ops API.
+type Collector struct {
+	Ops Ops
+	// Version is the plctl build recorded in the manifest.
+	Version string
+	// Target is the API URL the evidence was collected from.
+	Target      string
+	Environment string
+
+	now func() time.Time
+}
+
+// Collect pages through every event and session matching q. q.Since accepts
+// a timestamp or a duration (7d, 24h) and defaults to 24h. When both UserID
+// and IP are set the bundle holds the union of both selections.
+func (c *Collector) Collect(ctx context.Context, q Query) (*Bundle, error) {
+	if c.now == nil {
+		c.now = time.Now
+	}
+	now := c.now().UTC()
+	since, err := api.ParseSince(q.Since, now)
+	if err != nil {
+		return nil, err
+	}
+	q.Since = since
+	q.Until = now.Format(time.RFC3339)
+
+	base := api.EventsParams{Type: q.Type, ActorID: q.ActorID, Since: since}
+	var selections []api.EventsParams
+	if q.UserID != "" {
+		p := base
+		p.UserID = q.UserID
+		selections = append(selections, p)
+	}
+	if q.IP != "" {
+		p := base
+		p.IP = q.IP
+		selections = append(selections, p)
+	}
+	if len(selections) == 0 {
+		selections = append(selections, base)
+	}
+
+	seen := make(map[int]bool)
+	var events []api.Event
+	for _, p := range selections {
+		page, err := c.Ops.ListAllEvents(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("list events: %w", err)
+		}
+		for _, e := range page {
+			if !seen[e.ID] {
+				seen[e.ID] = true
+				events = append(events, e)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return api.CompareTimestamps(events[i].CreatedAt, events[j].CreatedAt) > 0 })
+
+	sessions, err := c.sessions(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	stats, err := c.Ops.GetEventStats(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	agents, err := c.Ops.ListAgents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list agents: %w", err)
+	}
+
+	return &Bundle{
+		Manifest: Manifest{
+			Format:      Format,
+			CreatedAt:   now.Format(time.RFC3339),
+			Version:     c.Version,
+			Target:      c.Target,
+			Environment: c.Environment,
+			Query:       q,
+		},
+		Events:   events,
+		Sessions: sessions,
+		Stats:    stats,
+		Agents:   agents.Agents,
+	}, nil
+}
+
+// sessions returns active sessions for q.UserID plus any on q.IP. The API has
+// no IP filter, so IP selection is done client-side.
+func (c *Collector) sessions(ctx context.Context, q Query) ([]api.Session, error) {
+	if q.IP == "" {
+		return c.Ops.ListAllSessions(ctx, api.SessionsParams{UserID: q.UserID})
+	}
+	all, err := c.Ops.ListAllSessions(ctx, api.SessionsParams{})
+	if err != nil {
+		return nil, err
+	}
+	var out []api.Session
+	for _, s := range all {
+		if s.IPAddress == q.IP || (q.UserID != "" && fmt.Sprint(s.UserID) == q.UserID) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,218 @@
+package evidence
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+type fakeOps struct {
+	eventParams []api.EventsParams
+}
+
+func (f *fakeOps) ListAllEvents(_ context.Context, p api.EventsParams) ([]api.Event, error) {
+	f.eventParams = append(f.eventParams, p)
+	uid := 42
+	if p.UserID != "" {
+		return []api.Event{
+			{ID: 3, Type: "login.success", UserID: &uid, IPAddress: "203.0.113.9", CreatedAt: "2026-03-08 11:00:00"},
+			{ID: 1, Type: "login.failure", UserID: &uid, IPAddress: "198.51.100.1", CreatedAt: "2026-03-07 09:00:00"},
+		}, nil
+	}
+	return []api.Event{
+		{ID: 3, Type: "login.success", UserID: &uid, IPAddress: "203.0.113.9", CreatedAt: "2026-03-08 11:00:00"},
+		// RFC 3339, unlike the others, so ordering can't rely on the strings.
+		{ID: 2, Type: "login.failure", IPAddress: "203.0.113.9", CreatedAt: "2026-03-08T10:00:00Z"},
+	}, nil
+}
+
+func (f *fakeOps) ListAllSessions(_ context.Context, _ api.SessionsParams) ([]api.Session, error) {
+	return []api.Session{
+		{ID: "s1", UserID: 42, IPAddress: "198.51.100.1"},
+		{ID: "s2", UserID: 7, IPAddress: "203.0.113.9"},
+		{ID: "s3", UserID: 8, IPAddress: "192.0.2.1"},
+	}, nil
+}
+
+func (f *fakeOps) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 9}, Since: since}, nil
+}
+
+func (f *fakeOps) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{Agents: []api.Agent{{Name: "ops", TrustLevel: "read"}}}, nil
+}
+
+func collect(t *testing.T) *Bundle {
+	t.Helper()
+	c := &Collector{
+		Ops:     &fakeOps{},
+		Version: "1.2.3",
+		Target:  "https://staging.example.com",
+		now:     func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
+	}
+	b, err := c.Collect(context.Background(), Query{UserID: "42", IP: "203.0.113.9", Since: "7d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCollectUnion(t *testing.T) {
+	b := collect(t)
+
+	var ids []int
+	for _, e := range b.Events {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 2 || ids[2] != 1 {
+		t.Errorf("events = %v, want [3 2 1]", ids)
+	}
+	if len(b.Sessions) != 2 || b.Sessions[0].ID != "s1" || b.Sessions[1].ID != "s2" {
+		t.Errorf("sessions = %+v", b.Sessions)
+	}
+	q := b.Manifest.Query
+	if q.Since != "2026-03-01T12:00:00Z" || q.Until != "2026-03-08T12:00:00Z" {
+		t.Errorf("window = %s..%s", q.Since, q.Until)
+	}
+	if b.Manifest.Version != "1.2.3" || b.Stats.Stats["login.failure"] != 9 || len(b.Agents) != 1 {
+		t.Errorf("manifest/stats/agents not captured: %+v", b.Manifest)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	var buf bytes.Buffer
+	if err := Write(&buf, collect(t), key); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Events) != 3 || len(got.Sessions) != 2 || got.Stats == nil || len(got.Agents) != 1 {
+		t.Errorf("round trip lost data: %+v", got)
+	}
+	if len(got.Manifest.Files) != 4 {
+		t.Errorf("manifest files = %d, want 4", len(got.Manifest.Files))
+	}
+	if !got.SignedBy(key.Public().(ed25519.PublicKey)) {
+		t.Error("SignedBy(signer) = false")
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	if got.SignedBy(other) {
+		t.Error("SignedBy(other) = true")
+	}
+}
+
+// rewrite copies a bundle, passing each file through edit.
+func rewrite(t *testing.T, data []byte, edit func(name string, body []byte) []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(tr)
+		body = edit(hdr.Name, body)
+		hdr.Size = int64(len(body))
+		tw.WriteHeader(hdr)
+		tw.Write(body)
+	}
+	tw.Close()
+	gw.Close()
+	return out.Bytes()
+}
+
+func TestReadDetectsTampering(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	var buf bytes.Buffer
+	if err := Write(&buf, collect(t), key); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := rewrite(t, buf.Bytes(), func(name string, body []byte) []byte {
+		if name == EventsFile {
+			return bytes.Replace(body, []byte("203.0.113.9"), []byte("203.0.113.8"), 1)
+		}
+		return body
+	})
+	if _, err := Read(bytes.NewReader(tampered)); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("tampered events: err = %v, want ErrDigestMismatch", err)
+	}
+
+	resigned := rewrite(t, buf.Bytes(), func(name string, body []byte) []byte {
+		if name == ManifestFile {
+			return bytes.Replace(body, []byte("1.2.3"), []byte("9.9.9"), 1)
+		}
+		return body
+	})
+	if _, err := Read(bytes.NewReader(resigned)); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered manifest: err = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestReadRejectsUnlistedFiles(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, collect(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{EventsFile, SessionsFile, StatsFile, AgentsFile} {
+		unlisted := rewrite(t, buf.Bytes(), func(file string, body []byte) []byte {
+			if file != ManifestFile {
+				return body
+			}
+			var m Manifest
+			json.Unmarshal(body, &m)
+			m.Files = slices.DeleteFunc(m.Files, func(f FileEntry) bool { return f.Name == name })
+			out, _ := json.Marshal(m)
+			return out
+		})
+		if _, err := Read(bytes.NewReader(unlisted)); !errors.Is(err, ErrUnlisted) {
+			t.Errorf("%s missing from the manifest: err = %v, want ErrUnlisted", name, err)
+		}
+	}
+}
+
+func TestBundleReader(t *testing.T) {
+	b := collect(t)
+	ctx := context.Background()
+
+	events, _ := b.ListEvents(ctx, api.EventsParams{IP: "203.0.113.9"})
+	if len(events.Events) != 2 {
+		t.Errorf("ListEvents(ip) = %d, want 2", len(events.Events))
+	}
+	sessions, _ := b.ListSessions(ctx, api.SessionsParams{UserID: "42"})
+	if len(sessions.Sessions) != 1 {
+		t.Errorf("ListSessions(user) = %d, want 1", len(sessions.Sessions))
+	}
+	stats, _ := b.GetEventStats(ctx, "")
+	if stats.Stats["login.failure"] != 9 {
+		t.Errorf("GetEventStats(\"\") = %v, want captured stats", stats.Stats)
+	}
+	stats, _ = b.GetEventStats(ctx, "2026-03-08 00:00:00")
+	if stats.Stats["login.failure"] != 1 || stats.Stats["login.success"] != 1 {
+		t.Errorf("GetEventStats(since) = %v", stats.Stats)
+	}
+}
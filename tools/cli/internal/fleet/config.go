@@ -0,0 +1,116 @@
+// Package fleet runs the same ops queries concurrently against several
+// Private Landing deployments and merges the results per target.
+//
+// Targets are named contexts in a YAML file (default
+// $XDG_CONFIG_HOME/plctl/contexts.yaml, or $PLCTL_CONFIG):
+//
+//	contexts:
+//	  prod-eu:
+//	    url: https://eu.example.com
+//	    api_key_env: PLCTL_PROD_EU_KEY
+//	  prod-us:
+//	    url: https://us.example.com
+//	    api_key_env: PLCTL_PROD_US_KEY
+//	    provisioning_secret_env: PLCTL_PROD_US_PROVISIONING
+package fleet
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/private-landing/cli/internal/api"
+	"gopkg.in/yaml.v3"
+)
+
+// Context is one configured deployment.
+type Context struct {
+	URL string `yaml:"url"`
+	// APIKeyEnv names the environment variable holding the agent key.
+	// APIKey may hold the key inline; prefer the env var.
+	APIKeyEnv string `yaml:"api_key_env"`
+	APIKey    string `yaml:"api_key"`
+	// ProvisioningSecretEnv names the variable holding the provisioning secret.
+	ProvisioningSecretEnv string `yaml:"provisioning_secret_env"`
+}
+
+// Config is the contexts file.
+type Config struct {
+	Contexts map[string]Context `yaml:"contexts"`
+}
+
+// Target is a resolved context with a ready client.
+type Target struct {
+	Name   string
+	URL    string
+	Client *api.Client
+}
+
+// DefaultConfigPath returns $PLCTL_CONFIG or the per-user contexts file.
+func DefaultConfigPath() string {
+	if p := os.Getenv("PLCTL_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "contexts.yaml"
+	}
+	return filepath.Join(dir, "plctl", "contexts.yaml")
+}
+
+// LoadConfig reads a contexts file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read contexts: %w", err)
+	}
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parse contexts: %w", err)
+	}
+	return &c, nil
+}
+
+// Names returns the configured context names, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Targets resolves named contexts in the given order.
+func (c *Config) Targets(names []string) ([]Target, error) {
+	targets := make([]Target, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		ctx, ok := c.Contexts[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown context %q (configured: %s)", name, strings.Join(c.Names(), ", "))
+		}
+		key := ctx.APIKey
+		if ctx.APIKeyEnv != "" {
+			key = os.Getenv(ctx.APIKeyEnv)
+		}
+		if ctx.URL == "" || key == "" {
+			return nil, fmt.Errorf("context %q: url and an API key (api_key_env or api_key) are required", name)
+		}
+		var secret string
+		if ctx.ProvisioningSecretEnv != "" {
+			secret = os.Getenv(ctx.ProvisioningSecretEnv)
+		}
+		targets = append(targets, Target{Name: name, URL: ctx.URL, Client: api.NewClient(ctx.URL, key, secret)})
+	}
+	return targets, nil
+}
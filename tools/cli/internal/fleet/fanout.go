@@ -0,0 +1,129 @@
+package fleet
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Result is one target's outcome.
+type Result[T any] struct {
+	Target string
+	Value  T
+	Err    error
+}
+
+// Failure is a target whose call failed.
+type Failure struct {
+	Target string
+	Err    error
+}
+
+// Do calls fn against every target concurrently. Results are in target order.
+func Do[T any](ctx context.Context, targets []Target, fn func(context.Context, *api.Client) (T, error)) []Result[T] {
+	results := make([]Result[T], len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := fn(ctx, t.Client)
+			results[i] = Result[T]{Target: t.Name, Value: v, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Row is an item tagged with the target it came from.
+type Row[T any] struct {
+	Target string
+	Item   T
+}
+
+// Merge flattens per-target lists into tagged rows and collects failures.
+func Merge[T any](results []Result[[]T]) ([]Row[T], []Failure) {
+	var rows []Row[T]
+	var failed []Failure
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, Failure{r.Target, r.Err})
+			continue
+		}
+		for _, item := range r.Value {
+			rows = append(rows, Row[T]{Target: r.Target, Item: item})
+		}
+	}
+	return rows, failed
+}
+
+// MergeEvents merges event lists newest first across targets.
+func MergeEvents(results []Result[[]api.Event]) ([]Row[api.Event], []Failure) {
+	rows, failed := Merge(results)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return api.CompareTimestamps(rows[i].Item.CreatedAt, rows[j].Item.CreatedAt) > 0
+	})
+	return rows, failed
+}
+
+// Stats is event counts by type and target.
+type Stats struct {
+	// Targets that answered, in request order.
+	Targets []string
+	// Types sorted by total count, descending.
+	Types  []string
+	Counts map[string]map[string]int // type -> target -> count
+}
+
+// Total is the sum across targets for an event type.
+func (s *Stats) Total(eventType string) int {
+	n := 0
+	for _, c := range s.Counts[eventType] {
+		n += c
+	}
+	return n
+}
+
+// Spread is the difference between the highest and lowest target count for
+// an event type, counting missing targets as zero.
+func (s *Stats) Spread(eventType string) int {
+	lo, hi := -1, 0
+	for _, t := range s.Targets {
+		c := s.Counts[eventType][t]
+		if lo < 0 || c < lo {
+			lo = c
+		}
+		hi = max(hi, c)
+	}
+	return hi - max(lo, 0)
+}
+
+// MergeStats combines per-target stats responses.
+func MergeStats(results []Result[*api.EventStatsResponse]) (*Stats, []Failure) {
+	s := &Stats{Counts: make(map[string]map[string]int)}
+	var failed []Failure
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, Failure{r.Target, r.Err})
+			continue
+		}
+		s.Targets = append(s.Targets, r.Target)
+		for typ, n := range r.Value.Stats {
+			if s.Counts[typ] == nil {
+				s.Counts[typ] = make(map[string]int)
+				s.Types = append(s.Types, typ)
+			}
+			s.Counts[typ][r.Target] = n
+		}
+	}
+	sort.Slice(s.Types, func(i, j int) bool {
+		ti, tj := s.Total(s.Types[i]), s.Total(s.Types[j])
+		if ti != tj {
+			return ti > tj
+		}
+		return s.Types[i] < s.Types[j]
+	})
+	return s, failed
+}
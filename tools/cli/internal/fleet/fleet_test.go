@@ -0,0 +1,144 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func writeConfig(t *testing.T, body string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "contexts.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestTargets(t *testing.T) {
+	t.Setenv("TEST_EU_KEY", "eu-key")
+	c := writeConfig(t, `
+contexts:
+  prod-eu:
+    url: https://eu.example.com
+    api_key_env: TEST_EU_KEY
+  prod-us:
+    url: https://us.example.com
+    api_key: inline-key
+  broken:
+    url: https://broken.example.com
+    api_key_env: TEST_UNSET_KEY
+`)
+
+	targets, err := c.Targets([]string{"prod-us", "prod-eu", "prod-us"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tg := range targets {
+		names = append(names, tg.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"prod-us", "prod-eu"}) {
+		t.Errorf("names = %v, want requested order without duplicates", names)
+	}
+
+	if _, err := c.Targets([]string{"staging"}); err == nil {
+		t.Error("unknown context: expected error")
+	}
+	if _, err := c.Targets([]string{"broken"}); err == nil {
+		t.Error("missing key: expected error")
+	}
+}
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contexts.yaml")
+	os.WriteFile(path, []byte("contexts:\n  a:\n    uri: https://a.example.com\n"), 0o600)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected error for misspelled field")
+	}
+}
+
+func TestDoAndMergeStats(t *testing.T) {
+	serve := func(stats map[string]int, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				http.Error(w, `{"error":"down"}`, status)
+				return
+			}
+			json.NewEncoder(w).Encode(api.EventStatsResponse{Stats: stats, Since: "2026-01-01T00:00:00Z"})
+		}))
+	}
+	eu := serve(map[string]int{"login.success": 10, "login.failure": 2}, http.StatusOK)
+	defer eu.Close()
+	us := serve(map[string]int{"login.success": 4}, http.StatusOK)
+	defer us.Close()
+	down := serve(nil, http.StatusBadGateway)
+	defer down.Close()
+
+	targets := []Target{
+		{Name: "eu", Client: api.NewClient(eu.URL, "k", "")},
+		{Name: "down", Client: api.NewClient(down.URL, "k", "")},
+		{Name: "us", Client: api.NewClient(us.URL, "k", "")},
+	}
+	results := Do(context.Background(), targets, func(ctx context.Context, c *api.Client) (*api.EventStatsResponse, error) {
+		return c.GetEventStats(ctx, "")
+	})
+	for i, r := range results {
+		if r.Target != targets[i].Name {
+			t.Fatalf("result %d from %s, want target order", i, r.Target)
+		}
+	}
+
+	s, failed := MergeStats(results)
+	if len(failed) != 1 || failed[0].Target != "down" {
+		t.Fatalf("failed = %+v, want down only", failed)
+	}
+	if !reflect.DeepEqual(s.Targets, []string{"eu", "us"}) {
+		t.Errorf("targets = %v", s.Targets)
+	}
+	if !reflect.DeepEqual(s.Types, []string{"login.success", "login.failure"}) {
+		t.Errorf("types = %v, want by total descending", s.Types)
+	}
+	if got := s.Total("login.success"); got != 14 {
+		t.Errorf("total = %d, want 14", got)
+	}
+	if got := s.Spread("login.success"); got != 6 {
+		t.Errorf("spread = %d, want 6", got)
+	}
+	// us reported no failures, which counts as zero.
+	if got := s.Spread("login.failure"); got != 2 {
+		t.Errorf("spread of missing type = %d, want 2", got)
+	}
+}
+
+func TestMergeEvents(t *testing.T) {
+	results := []Result[[]api.Event]{
+		{Target: "eu", Value: []api.Event{{ID: 2, CreatedAt: "2026-01-01T00:03:00Z"}, {ID: 1, CreatedAt: "2026-01-01T00:01:00Z"}}},
+		{Target: "us", Err: errors.New("timeout")},
+		{Target: "ap", Value: []api.Event{{ID: 7, CreatedAt: "2026-01-01 00:02:00"}}}, // datetime() format
+	}
+	rows, failed := MergeEvents(results)
+	var got []string
+	for _, r := range rows {
+		got = append(got, fmt.Sprintf("%s/%d", r.Target, r.Item.ID))
+	}
+	if want := []string{"eu/2", "ap/7", "eu/1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if len(failed) != 1 || failed[0].Target != "us" {
+		t.Errorf("failed = %+v", failed)
+	}
+}
@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/private-landing/cli/internal/api"
+)
+
+func TestHubFiltersPerClient(t *testing.T) {
+	h := NewHub()
+	all := h.Add(nil)
+	logins := h.Add([]string{"login.*"})
+	revokes := h.Add([]string{"session.revoke"})
+
+	for _, typ := range []string{"login.success", "login.failure", "session.revoke", "session.revoke_all", "loginx.foo"} {
+		h.Publish(api.Event{Type: typ})
+	}
+	if n := len(all.Events); n != 5 {
+		t.Errorf("unfiltered client got %d events, want 5", n)
+	}
+	if n := len(logins.Events); n != 2 {
+		t.Errorf("login.* client got %d events, want 2", n)
+	}
+	if n := len(revokes.Events); n != 1 {
+		t.Errorf("session.revoke client got %d events, want 1", n)
+	}
+
+	h.Remove(logins)
+	if st := h.Status(); st.Clients != 2 || st.Received != 5 {
+		t.Errorf("Status = %+v", st)
+	}
+}
+
+func TestHubDropsForSlowClient(t *testing.T) {
+	h := NewHub()
+	c := h.Add(nil)
+	for i := 0; i < clientBuffer+10; i++ {
+		h.Publish(api.Event{ID: i, Type: "login.success"})
+	}
+	if c.Dropped() != 10 {
+		t.Errorf("Dropped = %d, want 10", c.Dropped())
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	got, err := ParseTypes([]string{"login.*, session.revoke", "agent.provisioned"})
+	if err != nil || strings.Join(got, "|") != "login.*|session.revoke|agent.provisioned" {
+		t.Errorf("ParseTypes = %v, %v", got, err)
+	}
+	if _, err := ParseTypes([]string{"login.**"}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+// waitClients blocks until the hub has n clients.
+func waitClients(t *testing.T, h *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for h.Status().Clients != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("hub has %d clients, want %d", h.Status().Clients, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamTransports(t *testing.T) {
+	h := NewHub()
+	srv := httptest.NewServer(h.Handler(nil))
+	defer srv.Close()
+
+	tests := []struct {
+		name, query, accept, want string
+	}{
+		{"ndjson", "?types=login.*", "", `"type":"login.failure"`},
+		{"sse", "?types=login.*", "text/event-stream", "event: login.failure\nid: 7\ndata: {"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+"/events"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			waitClients(t, h, 1)
+
+			h.Publish(api.Event{ID: 6, Type: "session.revoke"})
+			h.Publish(api.Event{ID: 7, Type: "login.failure"})
+
+			r := bufio.NewReader(resp.Body)
+			var got strings.Builder
+			for !strings.Contains(got.String(), tt.want) {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					t.Fatalf("read: %v (got %q)", err, got.String())
+				}
+				got.WriteString(line)
+			}
+			if strings.Contains(got.String(), "session.revoke") {
+				t.Error("filtered event delivered")
+			}
+			resp.Body.Close()
+			waitClients(t, h, 0)
+		})
+	}
+
+	resp, _ := http.Get(srv.URL + "/events?types=bad..type")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid filter status = %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	h := NewHub()
+	srv := httptest.NewServer(h.Handler(nil))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/events?types=agent.*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	waitClients(t, h, 1)
+
+	h.Publish(api.Event{ID: 1, Type: "login.success"})
+	h.Publish(api.Event{ID: 2, Type: "agent.revoked"})
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e api.Event
+	json.Unmarshal(data, &e)
+	if e.ID != 2 || e.Type != "agent.revoked" {
+		t.Errorf("got %+v, want agent.revoked #2", e)
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gw.sock")
+	ln, err := Listen("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	// A stale socket left behind is replaced.
+	ln, err = Listen("unix://" + path)
+	if err != nil {
+		t.Fatalf("relisten: %v", err)
+	}
+	ln.Close()
+}
+
+func TestListenRefusesRemote(t *testing.T) {
+	for _, addr := range []string{":0", "tcp://0.0.0.0:0", "[::]:0", "example.com:0"} {
+		if ln, err := Listen(addr); err == nil {
+			ln.Close()
+			t.Errorf("Listen(%q) accepted a non-loopback address", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:0", "tcp://[::1]:0", "localhost:0"} {
+		ln, err := Listen(addr)
+		if err != nil {
+			// Hosts without IPv6 can't bind ::1; that's not what this tests.
+			if strings.Contains(addr, "::1") {
+				continue
+			}
+			t.Errorf("Listen(%q): %v", addr, err)
+			continue
+		}
+		ln.Close()
+	}
+	ln, err := ListenRemote("tcp://0.0.0.0:0")
+	if err != nil {
+		t.Fatalf("ListenRemote: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,111 @@
+// Package gateway shares one upstream /ops/ws subscription between many local
+// clients. Each client receives the events matching its own type filters over
+// WebSocket, Server-Sent Events or NDJSON.
+package gateway
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// clientBuffer is the number of events queued per client before new events
+// are dropped for that client.
+const clientBuffer = 256
+
+// Hub fans events out to subscribers.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+
+	upstream atomic.Bool
+	received atomic.Int64
+}
+
+// NewHub returns an empty hub.
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+// Client is one local subscriber.
+type Client struct {
+	// Types are subscribe_events patterns; empty receives everything.
+	Types   []string
+	Events  chan api.Event
+	dropped atomic.Int64
+}
+
+// Dropped returns the number of events discarded because the client fell behind.
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Matches applies the client's filters with the server's glob semantics.
+func (c *Client) Matches(eventType string) bool {
+	if len(c.Types) == 0 {
+		return true
+	}
+	for _, t := range c.Types {
+		if api.MatchEventType(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add registers a client for the given type filters.
+func (h *Hub) Add(types []string) *Client {
+	c := &Client{Types: types, Events: make(chan api.Event, clientBuffer)}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	return c
+}
+
+// Remove unregisters a client and closes its channel.
+func (h *Hub) Remove(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.Events)
+	}
+}
+
+// Publish delivers e to every matching client without blocking.
+func (h *Hub) Publish(e api.Event) {
+	h.received.Add(1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if !c.Matches(e.Type) {
+			continue
+		}
+		select {
+		case c.Events <- e:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+}
+
+// SetUpstream records whether the upstream subscription is connected.
+func (h *Hub) SetUpstream(connected bool) {
+	h.upstream.Store(connected)
+}
+
+// Status is the /healthz response.
+type Status struct {
+	Upstream bool  `json:"upstream"`
+	Clients  int   `json:"clients"`
+	Received int64 `json:"received"`
+}
+
+// Status reports the hub's current state.
+func (h *Hub) Status() Status {
+	h.mu.Lock()
+	n := len(h.clients)
+	h.mu.Unlock()
+	return Status{Upstream: h.upstream.Load(), Clients: n, Received: h.received.Load()}
+}
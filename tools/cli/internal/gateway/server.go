@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/private-landing/cli/internal/api"
+)
+
+// sseKeepalive is how often an idle SSE stream sends a comment line.
+const sseKeepalive = 30 * time.Second
+
+// Handler serves the gateway endpoints:
+//
+//	GET /events?types=login.*,session.revoke   WebSocket, SSE or NDJSON
+//	GET /healthz                               upstream and client status
+//
+// /events upgrades to WebSocket when requested, streams SSE when the client
+// accepts text/event-stream (or ?format=sse), and NDJSON otherwise. Every
+// transport carries the REST Event JSON shape. originPatterns lists extra
+// browser origins allowed to open WebSockets.
+func (h *Hub) Handler(originPatterns []string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		h.serveEvents(w, r, originPatterns)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.Status())
+	})
+	return mux
+}
+
+// ParseTypes splits comma-separated type filters and validates each against
+// the server's subscribe_events schema.
+func ParseTypes(values []string) ([]string, error) {
+	var types []string
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+			if !api.ValidTypePattern(t) {
+				return nil, fmt.Errorf("invalid type filter %q", t)
+			}
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+func (h *Hub) serveEvents(w http.ResponseWriter, r *http.Request, originPatterns []string) {
+	types, err := ParseTypes(r.URL.Query()["types"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket"):
+		h.serveWS(w, r, types, originPatterns)
+	case r.URL.Query().Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream"):
+		h.serveStream(w, r, types, "text/event-stream", WriteSSE)
+	default:
+		h.serveStream(w, r, types, "application/x-ndjson", writeNDJSON)
+	}
+}
+
+func writeNDJSON(w http.ResponseWriter, e api.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// WriteSSE writes e as a Server-Sent Event named by its type, with the event
+// ID as the SSE id so clients can resume with Last-Event-ID.
+func WriteSSE(w http.ResponseWriter, e api.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", e.Type, e.ID, data)
+	return err
+}
+
+func (h *Hub) serveStream(w http.ResponseWriter, r *http.Request, types []string, contentType string, write func(http.ResponseWriter, api.Event) error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := h.Add(types)
+	defer h.Remove(c)
+
+	var keepalive <-chan time.Time
+	if contentType == "text/event-stream" {
+		t := time.NewTicker(sseKeepalive)
+		defer t.Stop()
+		keepalive = t.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case e := <-c.Events:
+			if err := write(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *Hub) serveWS(w http.ResponseWriter, r *http.Request, types []string, originPatterns []string) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: originPatterns})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	// Clients only receive; CloseRead cancels ctx when they disconnect.
+	ctx := conn.CloseRead(r.Context())
+
+	c := h.Add(types)
+	defer h.Remove(c)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-c.Events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			wctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			err = conn.Write(wctx, websocket.MessageText, data)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Listen opens a listener for unix:///path, tcp://host:port or host:port.
+// A stale unix socket is removed and the new one is made owner-only. The
+// gateway has no authentication, so TCP addresses must be loopback; remote
+// consumers should go through plctl bridge, which requires bearer tokens.
+func Listen(addr string) (net.Listener, error) {
+	return listen(addr, false)
+}
+
+// ListenRemote is Listen without the loopback restriction, for callers that
+// have explicitly opted in to exposing the gateway.
+func ListenRemote(addr string) (net.Listener, error) {
+	return listen(addr, true)
+}
+
+func listen(addr string, allowRemote bool) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		if fi, err := os.Lstat(path); err == nil {
+			if fi.Mode().Type() != fs.ModeSocket {
+				return nil, fmt.Errorf("%s exists and is not a socket", path)
+			}
+			os.Remove(path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+	hostport := strings.TrimPrefix(addr, "tcp://")
+	if !allowRemote && !isLoopback(hostport) {
+		return nil, fmt.Errorf("%s is not a loopback address; use plctl bridge for remote consumers", addr)
+	}
+	return net.Listen("tcp", hostport)
+}
+
+// isLoopback reports whether hostport only binds loopback interfaces. An
+// empty host binds every interface; other hostnames may resolve anywhere.
+func isLoopback(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	return err == nil && api.IsLoopback(host)
+}
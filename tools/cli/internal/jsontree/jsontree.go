@@ -0,0 +1,280 @@
+// Package jsontree parses JSON into an ordered tree and renders it as an
+// aligned, colorized outline whose objects and arrays can be folded.
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/private-landing/cli/internal/ui"
+)
+
+// Kind is a JSON value type.
+type Kind int
+
+const (
+	Object Kind = iota
+	Array
+	String
+	Number
+	Bool
+	Null
+)
+
+// Node is one JSON value. Object keys keep their document order.
+type Node struct {
+	Key       string // object key or "[i]"; empty for the root
+	Kind      Kind
+	Value     string // scalar text; strings are unquoted
+	Children  []*Node
+	Collapsed bool
+}
+
+var (
+	keyStyle    = ui.PromptStyle
+	stringStyle = ui.SuccessStyle
+	numberStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	boolStyle   = ui.TitleStyle
+	nullStyle   = ui.DimStyle
+	foldStyle   = ui.DimStyle
+)
+
+// Parse decodes data into a tree.
+func Parse(data []byte) (*Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return n, nil
+}
+
+// ParseOrText parses data, falling back to a single string node for
+// payloads that are not JSON.
+func ParseOrText(data string) *Node {
+	if n, err := Parse([]byte(data)); err == nil {
+		return n
+	}
+	return &Node{Kind: String, Value: data}
+}
+
+func parseValue(dec *json.Decoder) (*Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			n := &Node{Kind: Object}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				child, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				child.Key = kt.(string)
+				n.Children = append(n.Children, child)
+			}
+			_, err := dec.Token() // '}'
+			return n, err
+		case '[':
+			n := &Node{Kind: Array}
+			for dec.More() {
+				child, err := parseValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				child.Key = fmt.Sprintf("[%d]", len(n.Children))
+				n.Children = append(n.Children, child)
+			}
+			_, err := dec.Token() // ']'
+			return n, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %q", v)
+	case string:
+		return &Node{Kind: String, Value: v}, nil
+	case json.Number:
+		return &Node{Kind: Number, Value: v.String()}, nil
+	case bool:
+		return &Node{Kind: Bool, Value: fmt.Sprint(v)}, nil
+	case nil:
+		return &Node{Kind: Null, Value: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+// Container reports whether n is an object or array.
+func (n *Node) Container() bool {
+	return n.Kind == Object || n.Kind == Array
+}
+
+// Field returns the scalar value of the top-level key, if present.
+func (n *Node) Field(key string) (string, bool) {
+	if n.Kind != Object {
+		return "", false
+	}
+	for _, c := range n.Children {
+		if c.Key == key && !c.Container() {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// JSON re-encodes the subtree compactly, keeping key order.
+func (n *Node) JSON() string {
+	var b strings.Builder
+	n.writeJSON(&b)
+	return b.String()
+}
+
+func (n *Node) writeJSON(b *strings.Builder) {
+	switch n.Kind {
+	case Object, Array:
+		open, close := "{", "}"
+		if n.Kind == Array {
+			open, close = "[", "]"
+		}
+		b.WriteString(open)
+		for i, c := range n.Children {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			if n.Kind == Object {
+				k, _ := json.Marshal(c.Key)
+				b.Write(k)
+				b.WriteString(":")
+			}
+			c.writeJSON(b)
+		}
+		b.WriteString(close)
+	case String:
+		s, _ := json.Marshal(n.Value)
+		b.Write(s)
+	default:
+		b.WriteString(n.Value)
+	}
+}
+
+// Text is what copying the node yields: the raw value for scalars and
+// compact JSON for containers.
+func (n *Node) Text() string {
+	if n.Container() {
+		return n.JSON()
+	}
+	return n.Value
+}
+
+// Line is one visible row of the outline.
+type Line struct {
+	Node     *Node
+	Depth    int
+	KeyWidth int // widest sibling key, for aligning values
+}
+
+// Lines flattens the visible part of the tree. The root's children start
+// at depth 0; a scalar root is a single line without a key.
+func (n *Node) Lines() []Line {
+	if !n.Container() {
+		return []Line{{Node: n}}
+	}
+	var out []Line
+	appendLines(&out, n, 0)
+	return out
+}
+
+func appendLines(out *[]Line, n *Node, depth int) {
+	width := 0
+	for _, c := range n.Children {
+		width = max(width, lipgloss.Width(c.Key))
+	}
+	for _, c := range n.Children {
+		*out = append(*out, Line{Node: c, Depth: depth, KeyWidth: width})
+		if c.Container() && !c.Collapsed {
+			appendLines(out, c, depth+1)
+		}
+	}
+}
+
+// Render formats the line, cutting the value so the whole line fits in
+// width runes (0 for no limit).
+func (l Line) Render(width int) string {
+	n := l.Node
+	prefix := strings.Repeat("  ", l.Depth)
+	if n.Key != "" {
+		prefix += fmt.Sprintf("%-*s  ", l.KeyWidth, n.Key)
+	}
+	value, style := n.summary()
+	if width > 0 {
+		value = truncate(value, width-lipgloss.Width(prefix))
+	}
+	key := prefix
+	if n.Key != "" {
+		indent := strings.Repeat("  ", l.Depth)
+		key = indent + keyStyle.Render(strings.TrimPrefix(prefix, indent))
+	}
+	return key + style.Render(value)
+}
+
+func (n *Node) summary() (string, lipgloss.Style) {
+	switch n.Kind {
+	case Object, Array:
+		unit := "key"
+		if n.Kind == Array {
+			unit = "item"
+		}
+		if len(n.Children) != 1 {
+			unit += "s"
+		}
+		if n.Collapsed {
+			return fmt.Sprintf("▸ %d %s", len(n.Children), unit), foldStyle
+		}
+		if len(n.Children) == 0 {
+			return "(empty)", foldStyle
+		}
+		return "▾", foldStyle
+	case String:
+		q, _ := json.Marshal(n.Value)
+		return string(q), stringStyle
+	case Number:
+		return n.Value, numberStyle
+	case Bool:
+		return n.Value, boolStyle
+	}
+	return n.Value, nullStyle
+}
+
+// Render formats every visible line of the tree.
+func Render(n *Node, width int) string {
+	var b strings.Builder
+	for _, l := range n.Lines() {
+		b.WriteString(l.Render(width))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,76 @@
+package jsontree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const sample = `{"reason":"bad_password","email":"ana@example.com","nested":{"difficulty":4,"ok":true,"none":null},"list":["é",2]}`
+
+func TestParseKeepsOrder(t *testing.T) {
+	n, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, c := range n.Children {
+		keys = append(keys, c.Key)
+	}
+	if got := strings.Join(keys, ","); got != "reason,email,nested,list" {
+		t.Errorf("keys = %s", got)
+	}
+	if got := n.JSON(); got != sample {
+		t.Errorf("JSON round trip:\n got %s\nwant %s", got, sample)
+	}
+	if v, ok := n.Field("email"); !ok || v != "ana@example.com" {
+		t.Errorf("Field(email) = %q, %v", v, ok)
+	}
+	if _, ok := n.Field("nested"); ok {
+		t.Error("Field returned a container")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{`{"a":`, `{"a":1} x`, ``} {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+		}
+	}
+	if n := ParseOrText("not json"); n.Kind != String || n.Value != "not json" {
+		t.Errorf("ParseOrText = %+v", n)
+	}
+}
+
+func TestLinesFold(t *testing.T) {
+	n, _ := Parse([]byte(sample))
+	if got := len(n.Lines()); got != 9 {
+		t.Fatalf("expanded lines = %d, want 9", got)
+	}
+	n.Children[2].Collapsed = true
+	lines := n.Lines()
+	if len(lines) != 6 {
+		t.Fatalf("folded lines = %d, want 6", len(lines))
+	}
+	if !strings.Contains(lines[2].Render(0), "▸ 3 keys") {
+		t.Errorf("folded summary = %q", lines[2].Render(0))
+	}
+	if lines[0].KeyWidth != len("reason") {
+		t.Errorf("KeyWidth = %d", lines[0].KeyWidth)
+	}
+	if got := n.Children[2].Text(); got != `{"difficulty":4,"ok":true,"none":null}` {
+		t.Errorf("Text = %s", got)
+	}
+}
+
+func TestRenderTruncatesRunes(t *testing.T) {
+	n, _ := Parse([]byte(`{"k":"ééééééééééééééééééé"}`))
+	line := n.Lines()[0].Render(10)
+	if w := lipgloss.Width(line); w > 10 {
+		t.Errorf("width = %d, want <= 10: %q", w, line)
+	}
+	if !strings.Contains(line, "…") {
+		t.Errorf("missing ellipsis: %q", line)
+	}
+}
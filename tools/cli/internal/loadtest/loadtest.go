@@ -0,0 +1,277 @@
+// Package loadtest drives abuse scenarios against a development deployment
+// to exercise its rate limits and adaptive PoW challenges, then correlates
+// what the client saw with the security events the server recorded.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Scenario names.
+const (
+	Login         = "login"          // valid logins with the test account
+	WrongPassword = "wrong-password" // failed logins from one IP
+	SpoofedIPs    = "spoofed-ips"    // failed logins from many forwarded IPs
+	Register      = "register"       // registration burst
+	WSFlood       = "ws-flood"       // /ops/ws connect and negotiate
+)
+
+// Scenarios lists every scenario name.
+var Scenarios = []string{Login, WrongPassword, SpoofedIPs, Register, WSFlood}
+
+// Outcome classifies one attempt by what the client saw.
+type Outcome string
+
+const (
+	OK          Outcome = "ok"
+	Rejected    Outcome = "rejected"     // 400/401: bad credentials or input
+	RateLimited Outcome = "rate_limited" // 429
+	Challenged  Outcome = "challenged"   // 403 challenge left unsolved or failed
+	Failed      Outcome = "error"        // transport errors and other statuses
+)
+
+// Config describes a run.
+type Config struct {
+	Scenario    string
+	BaseURL     string
+	Client      *api.Client // agent client, for ws-flood
+	Requests    int
+	Concurrency int
+	// Email and Password are the test account for the login scenario.
+	Email    string
+	Password string
+	// IPs is the number of distinct addresses spoofed-ips rotates through.
+	IPs int
+	// NoPow leaves PoW challenges unsolved.
+	NoPow bool
+}
+
+// Attempt is one client action.
+type Attempt struct {
+	Outcome    Outcome
+	Status     int
+	Latency    time.Duration
+	Challenged bool // a PoW challenge was issued
+	Solved     bool // and solved
+	Err        string
+}
+
+// Run executes the scenario with at most cfg.Concurrency attempts in
+// flight and returns them in completion order.
+func Run(ctx context.Context, cfg Config) ([]Attempt, error) {
+	attempt, err := cfg.attempter()
+	if err != nil {
+		return nil, err
+	}
+	conc := max(cfg.Concurrency, 1)
+	jobs := make(chan int)
+	results := make(chan Attempt)
+	var wg sync.WaitGroup
+	for range conc {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				a := attempt(ctx, i)
+				a.Latency = time.Since(start)
+				results <- a
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range cfg.Requests {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var out []Attempt
+	for a := range results {
+		out = append(out, a)
+	}
+	return out, ctx.Err()
+}
+
+func (cfg Config) attempter() (func(ctx context.Context, i int) Attempt, error) {
+	h := &httpRunner{base: cfg.BaseURL, pow: !cfg.NoPow, http: &http.Client{
+		Timeout:       30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+	run := strconv.FormatInt(time.Now().Unix(), 36)
+	switch cfg.Scenario {
+	case Login:
+		if cfg.Email == "" || cfg.Password == "" {
+			return nil, errors.New("login scenario needs a test account email and password")
+		}
+		return func(ctx context.Context, _ int) Attempt {
+			return h.post(ctx, "/auth/login", map[string]string{"email": cfg.Email, "password": cfg.Password}, "")
+		}, nil
+	case WrongPassword:
+		return func(ctx context.Context, i int) Attempt {
+			return h.post(ctx, "/auth/login", map[string]string{"email": "loadtest@example.test", "password": "wrong-" + strconv.Itoa(i)}, "")
+		}, nil
+	case SpoofedIPs:
+		n := max(cfg.IPs, 1)
+		return func(ctx context.Context, i int) Attempt {
+			return h.post(ctx, "/auth/login", map[string]string{"email": "loadtest@example.test", "password": "wrong-" + strconv.Itoa(i)}, SpoofedIP(i%n))
+		}, nil
+	case Register:
+		return func(ctx context.Context, i int) Attempt {
+			email := fmt.Sprintf("loadtest-%s-%d@example.test", run, i)
+			return h.post(ctx, "/auth/register", map[string]string{"email": email, "password": "Loadtest-" + run + "-passphrase"}, "")
+		}, nil
+	case WSFlood:
+		if cfg.Client == nil {
+			return nil, errors.New("ws-flood needs an agent client")
+		}
+		return func(ctx context.Context, _ int) Attempt {
+			return wsAttempt(ctx, cfg.Client, !cfg.NoPow)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown scenario %q", cfg.Scenario)
+}
+
+// SpoofedIP returns the i'th address in 198.18.0.0/15, the range reserved
+// for benchmarking.
+func SpoofedIP(i int) string {
+	return fmt.Sprintf("198.%d.%d.%d", 18+(i>>16)&1, (i>>8)&0xff, i&0xff)
+}
+
+type httpRunner struct {
+	base string
+	pow  bool
+	http *http.Client
+}
+
+// post sends a JSON request, solving a PoW challenge when one is issued.
+// spoof, when set, goes in X-Forwarded-For and CF-Connecting-IP; whether
+// either is honored depends on the dev runtime.
+func (h *httpRunner) post(ctx context.Context, path string, body map[string]string, spoof string) Attempt {
+	status, resp, err := h.send(ctx, path, body, spoof)
+	if err != nil {
+		return Attempt{Outcome: Failed, Err: err.Error()}
+	}
+	var a Attempt
+	if status == http.StatusForbidden && resp.Challenge != nil && resp.Challenge.Nonce != "" {
+		a.Challenged = true
+		if !h.pow {
+			return Attempt{Outcome: Challenged, Status: status, Challenged: true}
+		}
+		solution, err := api.SolvePow(ctx, resp.Challenge.Nonce, resp.Challenge.Difficulty)
+		if err != nil {
+			return Attempt{Outcome: Failed, Challenged: true, Err: err.Error()}
+		}
+		body["challengeNonce"] = resp.Challenge.Nonce
+		body["challengeSolution"] = strconv.Itoa(solution)
+		a.Solved = true
+		if status, resp, err = h.send(ctx, path, body, spoof); err != nil {
+			a.Outcome, a.Err = Failed, err.Error()
+			return a
+		}
+	}
+	a.Status = status
+	a.Outcome = classify(status)
+	if a.Outcome == Challenged || a.Outcome == Failed {
+		a.Err = resp.Error
+	}
+	return a
+}
+
+type response struct {
+	Error     string `json:"error"`
+	Challenge *struct {
+		Nonce      string `json:"nonce"`
+		Difficulty int    `json:"difficulty"`
+	} `json:"challenge"`
+}
+
+func (h *httpRunner) send(ctx context.Context, path string, body map[string]string, spoof string) (int, response, error) {
+	var out response
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.base+path, bytes.NewReader(b))
+	if err != nil {
+		return 0, out, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if spoof != "" {
+		req.Header.Set("X-Forwarded-For", spoof)
+		req.Header.Set("CF-Connecting-IP", spoof)
+	}
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return 0, out, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(data, &out)
+	return resp.StatusCode, out, nil
+}
+
+func wsAttempt(ctx context.Context, c *api.Client, pow bool) Attempt {
+	var a Attempt
+	challenge, err := c.ProbeChallenge(ctx)
+	if !pow && err == nil && challenge.Required {
+		// ProbeChallenge always solves; with NoPow, count the challenge and
+		// stop short of using the solution.
+		return Attempt{Outcome: Challenged, Status: http.StatusForbidden, Challenged: true}
+	}
+	if err != nil {
+		return Attempt{Outcome: Failed, Err: err.Error()}
+	}
+	a.Challenged, a.Solved = challenge.Required, challenge.Required
+	conn, err := c.ConnectWS(ctx, challenge)
+	if err != nil {
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) {
+			a.Status = httpErr.Status
+			a.Outcome = classify(httpErr.Status)
+		} else {
+			a.Outcome = Failed
+		}
+		a.Err = err.Error()
+		return a
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if _, err := api.Negotiate(ctx, conn, []string{"query_events"}); err != nil {
+		a.Outcome, a.Err = Failed, err.Error()
+		return a
+	}
+	a.Status, a.Outcome = http.StatusSwitchingProtocols, OK
+	return a
+}
+
+func classify(status int) Outcome {
+	switch {
+	case status < 300 || status == http.StatusSwitchingProtocols:
+		return OK
+	case status == http.StatusTooManyRequests:
+		return RateLimited
+	case status == http.StatusForbidden:
+		return Challenged
+	case status == http.StatusBadRequest || status == http.StatusUnauthorized:
+		return Rejected
+	}
+	return Failed
+}
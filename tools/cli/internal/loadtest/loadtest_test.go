@@ -0,0 +1,211 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// fakeAuth rate limits logins per forwarded IP after limit attempts, and
+// challenges any request that has no solution when challenge is set.
+type fakeAuth struct {
+	limit     int
+	challenge bool
+
+	mu       sync.Mutex
+	perIP    map[string]int
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *fakeAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	if r.Header.Get("Accept") != "application/json" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	w.Header().Set("Content-Type", "application/json")
+
+	f.mu.Lock()
+	ip := r.Header.Get("X-Forwarded-For")
+	f.perIP[ip]++
+	count := f.perIP[ip]
+	f.mu.Unlock()
+	if count > f.limit {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"Too many requests"}`))
+		return
+	}
+	if f.challenge && body["challengeSolution"] == "" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"Challenge required","challenge":{"type":"pow","difficulty":1,"nonce":"abc"}}`))
+		return
+	}
+	switch {
+	case r.URL.Path == "/auth/register":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"success":true}`))
+	case body["password"] == "right":
+		w.Write([]byte(`{"success":true}`))
+	default:
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Invalid email or password"}`))
+	}
+}
+
+func newFake(limit int, challenge bool) (*fakeAuth, *httptest.Server) {
+	f := &fakeAuth{limit: limit, challenge: challenge, perIP: make(map[string]int)}
+	return f, httptest.NewServer(f)
+}
+
+func TestRunWrongPasswordRateLimited(t *testing.T) {
+	f, srv := newFake(5, false)
+	defer srv.Close()
+
+	attempts, err := Run(context.Background(), Config{Scenario: WrongPassword, BaseURL: srv.URL, Requests: 20, Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Summarize(Config{Scenario: WrongPassword, Concurrency: 4}, time.Now(), time.Second, attempts)
+	if r.Requests != 20 || r.Outcomes[Rejected] != 5 || r.Outcomes[RateLimited] != 15 {
+		t.Errorf("outcomes = %v over %d", r.Outcomes, r.Requests)
+	}
+	if p := f.peak.Load(); p > 4 {
+		t.Errorf("peak concurrency = %d, want <= 4", p)
+	}
+}
+
+func TestRunSpoofedIPsSpreadsLimit(t *testing.T) {
+	_, srv := newFake(5, false)
+	defer srv.Close()
+
+	attempts, err := Run(context.Background(), Config{Scenario: SpoofedIPs, BaseURL: srv.URL, Requests: 20, Concurrency: 4, IPs: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range attempts {
+		if a.Outcome != Rejected {
+			t.Fatalf("attempt = %+v, want every attempt rejected rather than limited", a)
+		}
+	}
+}
+
+func TestRunSolvesChallenge(t *testing.T) {
+	_, srv := newFake(100, true)
+	defer srv.Close()
+
+	cfg := Config{Scenario: Login, BaseURL: srv.URL, Requests: 3, Concurrency: 1, Email: "probe@example.test", Password: "right"}
+	attempts, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range attempts {
+		if a.Outcome != OK || !a.Challenged || !a.Solved {
+			t.Errorf("attempt = %+v, want solved and ok", a)
+		}
+	}
+
+	cfg.NoPow = true
+	attempts, _ = Run(context.Background(), cfg)
+	for _, a := range attempts {
+		if a.Outcome != Challenged || a.Solved {
+			t.Errorf("no-pow attempt = %+v, want unsolved challenge", a)
+		}
+	}
+}
+
+func TestRunConfigErrors(t *testing.T) {
+	for _, cfg := range []Config{
+		{Scenario: "nope"},
+		{Scenario: Login},
+		{Scenario: WSFlood},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("Run(%q) succeeded, want error", cfg.Scenario)
+		}
+	}
+}
+
+func TestSpoofedIP(t *testing.T) {
+	for i, want := range map[int]string{0: "198.18.0.0", 257: "198.18.1.1", 1 << 16: "198.19.0.0"} {
+		if got := SpoofedIP(i); got != want {
+			t.Errorf("SpoofedIP(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestSummarizeLatency(t *testing.T) {
+	var attempts []Attempt
+	for i := 1; i <= 100; i++ {
+		attempts = append(attempts, Attempt{Outcome: OK, Latency: time.Duration(i) * time.Millisecond})
+	}
+	attempts = append(attempts, Attempt{Outcome: Failed, Latency: 20 * time.Second, Err: "timeout"})
+	r := Summarize(Config{Scenario: Login}, time.Now(), time.Second, attempts)
+
+	if r.Latency.P50 != 51*time.Millisecond || r.Latency.Max != 20*time.Second {
+		t.Errorf("p50 = %s, max = %s", r.Latency.P50, r.Latency.Max)
+	}
+	h := r.Latency.Histogram
+	if h[0].Le != 5*time.Millisecond || h[0].Count != 5 {
+		t.Errorf("first bucket = %+v, want 5 at ≤5ms", h[0])
+	}
+	if last := h[len(h)-1]; last.Le != 0 || last.Count != 1 {
+		t.Errorf("+Inf bucket = %+v", last)
+	}
+	total := 0
+	for _, b := range h {
+		total += b.Count
+	}
+	if total != len(attempts) || r.Errors["timeout"] != 1 {
+		t.Errorf("histogram total = %d, errors = %v", total, r.Errors)
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	r := &Report{Scenario: WrongPassword, Started: time.Now(), Challenged: 2,
+		Outcomes: map[Outcome]int{Rejected: 5, RateLimited: 15}}
+	var since string
+	err := r.Correlate(context.Background(), func(_ context.Context, s string) (*api.EventStatsResponse, error) {
+		since = s
+		return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 5, "rate_limit.reject": 14, "challenge.issued": 2}}, nil
+	})
+	if err != nil || since == "" {
+		t.Fatalf("err = %v, since = %q", err, since)
+	}
+	want := map[string][2]int{"rejected (401)": {5, 5}, "rate limited (429)": {15, 14}, "challenged": {2, 2}}
+	if len(r.Correlation) != len(want) {
+		t.Fatalf("correlation = %+v", r.Correlation)
+	}
+	for _, c := range r.Correlation {
+		if w := want[c.Client]; !c.Checked || c.Count != w[0] || c.Server != w[1] {
+			t.Errorf("%s = %d/%d, want %v", c.Client, c.Count, c.Server, w)
+		}
+	}
+
+	r = &Report{Scenario: Register, Outcomes: map[Outcome]int{}}
+	err = r.Correlate(context.Background(), func(context.Context, string) (*api.EventStatsResponse, error) {
+		return nil, errors.New("down")
+	})
+	if err == nil || len(r.Correlation) == 0 || r.Correlation[0].Checked {
+		t.Errorf("err = %v, correlation = %+v; want unchecked rows and an error", err, r.Correlation)
+	}
+}
@@ -0,0 +1,180 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Buckets are the latency histogram upper bounds; a final +Inf bucket
+// catches the rest.
+var Buckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Bucket is one histogram bar. Le is zero for the +Inf bucket.
+type Bucket struct {
+	Le    time.Duration `json:"le"`
+	Count int           `json:"count"`
+}
+
+// Latency summarises attempt latencies.
+type Latency struct {
+	P50       time.Duration `json:"p50"`
+	P95       time.Duration `json:"p95"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+	Histogram []Bucket      `json:"histogram"`
+}
+
+// Correlation pairs a client-side count with the server events it should
+// have produced.
+type Correlation struct {
+	Client  string   `json:"client"`
+	Count   int      `json:"count"`
+	Events  []string `json:"events"`
+	Server  int      `json:"server"`
+	Checked bool     `json:"checked"` // false when server stats were unavailable
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Scenario    string          `json:"scenario"`
+	Target      string          `json:"target"`
+	Started     time.Time       `json:"started"`
+	Duration    time.Duration   `json:"duration"`
+	Requests    int             `json:"requests"`
+	Concurrency int             `json:"concurrency"`
+	Outcomes    map[Outcome]int `json:"outcomes"`
+	Challenged  int             `json:"challenged"`
+	Solved      int             `json:"solved"`
+	Latency     Latency         `json:"latency"`
+	Errors      map[string]int  `json:"errors,omitempty"`
+	Correlation []Correlation   `json:"correlation,omitempty"`
+}
+
+// maxErrors caps the distinct error strings kept in a report.
+const maxErrors = 10
+
+// Summarize builds a report from a run's attempts.
+func Summarize(cfg Config, started time.Time, elapsed time.Duration, attempts []Attempt) *Report {
+	r := &Report{
+		Scenario:    cfg.Scenario,
+		Target:      cfg.BaseURL,
+		Started:     started,
+		Duration:    elapsed,
+		Requests:    len(attempts),
+		Concurrency: cfg.Concurrency,
+		Outcomes:    make(map[Outcome]int),
+		Errors:      make(map[string]int),
+	}
+	lat := make([]time.Duration, 0, len(attempts))
+	for _, a := range attempts {
+		r.Outcomes[a.Outcome]++
+		if a.Challenged {
+			r.Challenged++
+		}
+		if a.Solved {
+			r.Solved++
+		}
+		if a.Err != "" && (len(r.Errors) < maxErrors || r.Errors[a.Err] > 0) {
+			r.Errors[a.Err]++
+		}
+		lat = append(lat, a.Latency)
+	}
+	r.Latency = summarizeLatency(lat)
+	return r
+}
+
+func summarizeLatency(lat []time.Duration) Latency {
+	var l Latency
+	l.Histogram = make([]Bucket, len(Buckets)+1)
+	for i, b := range Buckets {
+		l.Histogram[i].Le = b
+	}
+	if len(lat) == 0 {
+		return l
+	}
+	slices.Sort(lat)
+	pct := func(p float64) time.Duration {
+		return lat[int(float64(len(lat)-1)*p)]
+	}
+	l.P50, l.P95, l.P99, l.Max = pct(0.50), pct(0.95), pct(0.99), lat[len(lat)-1]
+	for _, d := range lat {
+		i, _ := slices.BinarySearch(Buckets, d)
+		l.Histogram[i].Count++
+	}
+	return l
+}
+
+// expectation is a client outcome and the event types the server records
+// for it.
+type expectation struct {
+	client  string
+	outcome Outcome
+	events  []string
+}
+
+func expectations(scenario string) []expectation {
+	limited := expectation{"rate limited (429)", RateLimited, []string{"rate_limit.reject"}}
+	switch scenario {
+	case Login:
+		return []expectation{{"logged in", OK, []string{"login.success"}}, limited}
+	case WrongPassword, SpoofedIPs:
+		return []expectation{{"rejected (401)", Rejected, []string{"login.failure"}}, limited}
+	case Register:
+		return []expectation{
+			{"registered", OK, []string{"registration.success"}},
+			{"rejected (400)", Rejected, []string{"registration.failure"}},
+			limited,
+		}
+	case WSFlood:
+		return []expectation{{"connected", OK, []string{"ws.connect"}}, {"refused (401)", Rejected, []string{"ws.connect_failure"}}, limited}
+	}
+	return nil
+}
+
+// Correlate fills in r.Correlation from event stats since the run started.
+// Counts include any other traffic on the target during the window, so a
+// shared deployment will over-report.
+func (r *Report) Correlate(ctx context.Context, stats func(ctx context.Context, since string) (*api.EventStatsResponse, error)) error {
+	since := r.Started.UTC().Add(-time.Second).Format(time.RFC3339)
+	resp, err := stats(ctx, since)
+	checked := err == nil
+	count := func(types []string) int {
+		if resp == nil {
+			return 0
+		}
+		n := 0
+		for _, t := range types {
+			n += resp.Stats[t]
+		}
+		return n
+	}
+	for _, e := range expectations(r.Scenario) {
+		r.Correlation = append(r.Correlation, Correlation{
+			Client: e.client, Count: r.Outcomes[e.outcome], Events: e.events, Server: count(e.events), Checked: checked,
+		})
+	}
+	issued := []string{"challenge.issued"}
+	r.Correlation = append(r.Correlation, Correlation{
+		Client: "challenged", Count: r.Challenged, Events: issued, Server: count(issued), Checked: checked,
+	})
+	if err != nil {
+		return fmt.Errorf("event stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// paramName maps a Go field name to the ops API query parameter name:
+// UserID -> user_id, IP -> ip, ActorID -> actor_id.
+func paramName(field string) string {
+	runes := []rune(field)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// schemaFor derives a JSON Schema object from the exported string and int
+// fields of a params struct such as api.EventsParams.
+func schemaFor(v interface{}, descriptions map[string]string) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	props := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		prop := map[string]interface{}{}
+		switch f.Type.Kind() {
+		case reflect.String:
+			prop["type"] = "string"
+		case reflect.Int:
+			prop["type"] = "integer"
+			prop["minimum"] = 0
+		default:
+			continue
+		}
+		name := paramName(f.Name)
+		if d := descriptions[name]; d != "" {
+			prop["description"] = d
+		}
+		props[name] = prop
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+// decodeArgs fills the params struct dst from tool call arguments using the
+// same names as schemaFor. Unknown arguments are rejected.
+func decodeArgs(raw json.RawMessage, dst interface{}) error {
+	args := map[string]interface{}{}
+	if len(raw) > 0 && string(raw) != "null" {
+		dec := json.NewDecoder(strings.NewReader(string(raw)))
+		dec.UseNumber()
+		if err := dec.Decode(&args); err != nil {
+			return fmt.Errorf("arguments must be an object: %w", err)
+		}
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		fields[paramName(t.Field(i).Name)] = i
+	}
+	for name, val := range args {
+		i, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown argument %q", name)
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			switch val := val.(type) {
+			case string:
+				field.SetString(val)
+			case json.Number:
+				field.SetString(val.String())
+			default:
+				return fmt.Errorf("%s must be a string", name)
+			}
+		case reflect.Int:
+			n, ok := val.(json.Number)
+			if !ok {
+				return fmt.Errorf("%s must be an integer", name)
+			}
+			i, err := n.Int64()
+			if err != nil || i < 0 {
+				return fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			field.SetInt(i)
+		}
+	}
+	return nil
+}
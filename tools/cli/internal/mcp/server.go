@@ -0,0 +1,244 @@
+// Package mcp serves ops capabilities as Model Context Protocol tools over
+// stdio (newline-delimited JSON-RPC 2.0), with a live-events resource fed by
+// the ws subscription.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// ProtocolVersion is the newest MCP revision this server implements.
+const ProtocolVersion = "2025-06-18"
+
+var supportedVersions = map[string]bool{
+	"2025-06-18": true,
+	"2025-03-26": true,
+	"2024-11-05": true,
+}
+
+// LiveEventsURI identifies the live-events resource.
+const LiveEventsURI = "plctl://events/live"
+
+// liveBufferSize is the number of recent pushed events kept for the resource.
+const liveBufferSize = 100
+
+// Ops is the API surface the server exposes.
+type Ops interface {
+	api.Reader
+	RevokeSessions(ctx context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error)
+}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server handles one MCP session.
+type Server struct {
+	Ops Ops
+	// AllowWrite exposes destructive tools. They are additionally gated on
+	// the agent holding write trust (see SetWritable).
+	AllowWrite bool
+	// Log receives one JSON line per tool call.
+	Log     io.Writer
+	Version string
+
+	mu         sync.Mutex
+	out        *json.Encoder
+	writable   bool
+	live       []api.Event
+	subscribed bool
+	now        func() time.Time
+}
+
+// SetWritable records whether the agent holds write trust, as shown by the
+// revoke_session capability grant. Clients are told when the tool list changes.
+func (s *Server) SetWritable(ok bool) {
+	s.mu.Lock()
+	changed := s.writable != ok && s.AllowWrite
+	s.writable = ok
+	s.mu.Unlock()
+	if changed {
+		s.notify("notifications/tools/list_changed", nil)
+	}
+}
+
+func (s *Server) canWrite() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.AllowWrite && s.writable
+}
+
+// PushEvent appends a live event to the resource buffer and notifies
+// subscribed clients.
+func (s *Server) PushEvent(e api.Event) {
+	s.mu.Lock()
+	s.live = append(s.live, e)
+	if len(s.live) > liveBufferSize {
+		s.live = s.live[len(s.live)-liveBufferSize:]
+	}
+	subscribed := s.subscribed
+	s.mu.Unlock()
+	if subscribed {
+		s.notify("notifications/resources/updated", map[string]string{"uri": LiveEventsURI})
+	}
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// exhausted or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	if s.now == nil {
+		s.now = time.Now
+	}
+	s.mu.Lock()
+	s.out = json.NewEncoder(out)
+	s.mu.Unlock()
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.send(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error"}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if req.ID != nil {
+				s.send(response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{codeInvalidRequest, "invalid request"}})
+			}
+			continue
+		}
+		result, rerr := s.handle(ctx, req)
+		if req.ID == nil {
+			continue // notification
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		s.send(resp)
+	}
+	return sc.Err()
+}
+
+func (s *Server) send(v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.out != nil {
+		s.out.Encode(v)
+	}
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &p)
+		version := ProtocolVersion
+		if supportedVersions[p.ProtocolVersion] {
+			version = p.ProtocolVersion
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]bool{"listChanged": true},
+				"resources": map[string]bool{"subscribe": true},
+			},
+			"serverInfo": map[string]string{"name": "plctl", "version": s.Version},
+		}, nil
+	case "ping", "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools()}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid params"}
+		}
+		return s.call(ctx, p.Name, p.Arguments)
+	case "resources/list":
+		return map[string]interface{}{"resources": []map[string]string{{
+			"uri":         LiveEventsURI,
+			"name":        "live-events",
+			"description": fmt.Sprintf("The %d most recent security events pushed over the ops WebSocket", liveBufferSize),
+			"mimeType":    "application/json",
+		}}}, nil
+	case "resources/read", "resources/subscribe", "resources/unsubscribe":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		json.Unmarshal(req.Params, &p)
+		if p.URI != LiveEventsURI {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown resource %q", p.URI)}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch req.Method {
+		case "resources/subscribe":
+			s.subscribed = true
+			return nil, nil
+		case "resources/unsubscribe":
+			s.subscribed = false
+			return nil, nil
+		}
+		text, _ := json.Marshal(append([]api.Event{}, s.live...))
+		return map[string]interface{}{"contents": []map[string]string{{
+			"uri":      LiveEventsURI,
+			"mimeType": "application/json",
+			"text":     string(text),
+		}}}, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+type fakeOps struct {
+	eventParams []api.EventsParams
+	revokes     []api.RevokeSessionsRequest
+}
+
+func (f *fakeOps) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	f.eventParams = append(f.eventParams, p)
+	return &api.ListEventsResponse{Events: []api.Event{{ID: 1, Type: "login.failure"}}}, nil
+}
+
+func (f *fakeOps) ListSessions(context.Context, api.SessionsParams) (*api.ListSessionsResponse, error) {
+	return &api.ListSessionsResponse{}, nil
+}
+
+func (f *fakeOps) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 3}, Since: since}, nil
+}
+
+func (f *fakeOps) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{}, nil
+}
+
+func (f *fakeOps) RevokeSessions(_ context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error) {
+	f.revokes = append(f.revokes, req)
+	return &api.RevokeSessionsResponse{Success: true, Revoked: 2}, nil
+}
+
+// serve runs the server over the given request lines and returns the decoded
+// output messages.
+func serve(t *testing.T, s *Server, lines ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var msgs []map[string]interface{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func toolNames(msg map[string]interface{}) []string {
+	var names []string
+	for _, tool := range msg["result"].(map[string]interface{})["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestInitializeAndList(t *testing.T) {
+	s := &Server{Ops: &fakeOps{}, Version: "1.0.0"}
+	msgs := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"nope"}`,
+	)
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3 (notification gets no reply)", len(msgs))
+	}
+	init := msgs[0]["result"].(map[string]interface{})
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v", init["protocolVersion"])
+	}
+	if got := strings.Join(toolNames(msgs[1]), ","); got != "list_sessions,query_events,event_stats,list_agents" {
+		t.Errorf("tools = %s", got)
+	}
+	if code := msgs[2]["error"].(map[string]interface{})["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("unknown method code = %v", code)
+	}
+}
+
+func TestQueryEventsSchemaAndArgs(t *testing.T) {
+	schema := schemaFor(api.EventsParams{}, nil)
+	props := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"type", "user_id", "ip", "actor_id", "since", "limit", "offset"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("schema missing %s", name)
+		}
+	}
+
+	ops := &fakeOps{}
+	var log bytes.Buffer
+	s := &Server{Ops: ops, Log: &log}
+	msgs := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query_events","arguments":{"user_id":42,"ip":"203.0.113.9","limit":10}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"query_events","arguments":{"bogus":1}}}`,
+	)
+	want := api.EventsParams{UserID: "42", IP: "203.0.113.9", Limit: 10}
+	if len(ops.eventParams) != 1 || ops.eventParams[0] != want {
+		t.Errorf("params = %+v, want %+v", ops.eventParams, want)
+	}
+	content := msgs[0]["result"].(map[string]interface{})["content"].([]interface{})
+	if !strings.Contains(content[0].(map[string]interface{})["text"].(string), "login.failure") {
+		t.Errorf("content = %v", content)
+	}
+	if msgs[1]["error"] == nil {
+		t.Error("unknown argument accepted")
+	}
+	if n := strings.Count(log.String(), "\n"); n != 2 {
+		t.Errorf("logged %d calls, want 2", n)
+	}
+}
+
+func TestRevokeRequiresWriteTrust(t *testing.T) {
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"revoke_session","arguments":{"scope":"user","id":"42"}}}`
+
+	ops := &fakeOps{}
+	s := &Server{Ops: ops, AllowWrite: true}
+	msgs := serve(t, s, call)
+	if msgs[0]["error"] == nil || len(ops.revokes) != 0 {
+		t.Error("revoke allowed without write trust")
+	}
+
+	s = &Server{Ops: ops}
+	s.SetWritable(true)
+	msgs = serve(t, s, call)
+	if msgs[0]["error"] == nil || len(ops.revokes) != 0 {
+		t.Error("revoke allowed without --allow-write")
+	}
+
+	s = &Server{Ops: ops, AllowWrite: true}
+	s.SetWritable(true)
+	msgs = serve(t, s, `{"jsonrpc":"2.0","id":0,"method":"tools/list"}`, call)
+	if names := toolNames(msgs[0]); names[len(names)-1] != "revoke_session" {
+		t.Errorf("tools = %v, want revoke_session listed", names)
+	}
+	if msgs[1]["error"] != nil || len(ops.revokes) != 1 || ops.revokes[0].ID != "42" {
+		t.Errorf("revoke failed: %v %+v", msgs[1], ops.revokes)
+	}
+}
+
+func TestLiveEventsResource(t *testing.T) {
+	s := &Server{Ops: &fakeOps{}}
+	var out bytes.Buffer
+	s.Serve(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"plctl://events/live"}}`), &out)
+	for i := 0; i < liveBufferSize+5; i++ {
+		s.PushEvent(api.Event{ID: i, Type: "login.success"})
+	}
+	if n := strings.Count(out.String(), "notifications/resources/updated"); n != liveBufferSize+5 {
+		t.Errorf("sent %d update notifications", n)
+	}
+
+	msgs := serve(t, s, `{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"plctl://events/live"}}`)
+	text := msgs[0]["result"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	var events []api.Event
+	json.Unmarshal([]byte(text), &events)
+	if len(events) != liveBufferSize || events[0].ID != 5 {
+		t.Errorf("buffer holds %d events starting at %d", len(events), events[0].ID)
+	}
+}
+
+func TestParamName(t *testing.T) {
+	for in, want := range map[string]string{"UserID": "user_id", "IP": "ip", "ActorID": "actor_id", "Since": "since"} {
+		if got := paramName(in); got != want {
+			t.Errorf("paramName(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Tool is an MCP tool definition.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Annotations map[string]bool        `json:"annotations,omitempty"`
+}
+
+type statsArgs struct {
+	Since string
+}
+
+type revokeArgs struct {
+	Scope string
+	ID    string
+}
+
+var paramDescriptions = map[string]string{
+	"type":     "Exact event type, e.g. login.failure",
+	"user_id":  "Numeric user ID",
+	"ip":       "Client IP address",
+	"actor_id": "Actor that caused the event, e.g. app:private-landing or agent:<name>",
+	"since":    "Only include events at or after this ISO 8601 timestamp",
+	"limit":    "Maximum results (default 50, max 200)",
+	"offset":   "Results to skip for pagination",
+	"scope":    "user revokes every session for a user ID; session revokes one session ID",
+	"id":       "User ID or session ID, depending on scope",
+}
+
+var readOnly = map[string]bool{"readOnlyHint": true}
+
+func (s *Server) tools() []Tool {
+	tools := []Tool{
+		{
+			Name:        "list_sessions",
+			Description: "List active sessions, optionally for one user.",
+			InputSchema: schemaFor(api.SessionsParams{}, paramDescriptions),
+			Annotations: readOnly,
+		},
+		{
+			Name:        "query_events",
+			Description: "Query security events, newest first.",
+			InputSchema: schemaFor(api.EventsParams{}, paramDescriptions),
+			Annotations: readOnly,
+		},
+		{
+			Name:        "event_stats",
+			Description: "Count security events by type since a timestamp (default: last 24 hours).",
+			InputSchema: schemaFor(statsArgs{}, paramDescriptions),
+			Annotations: readOnly,
+		},
+		{
+			Name:        "list_agents",
+			Description: "List active agent credentials and their trust levels.",
+			InputSchema: schemaFor(struct{}{}, nil),
+			Annotations: readOnly,
+		},
+	}
+	if s.canWrite() {
+		schema := schemaFor(revokeArgs{}, paramDescriptions)
+		schema["properties"].(map[string]interface{})["scope"].(map[string]interface{})["enum"] = []string{"user", "session"}
+		schema["required"] = []string{"scope", "id"}
+		tools = append(tools, Tool{
+			Name:        "revoke_session",
+			Description: "Revoke sessions for a user or a single session. Destructive: the affected users are signed out.",
+			InputSchema: schema,
+			Annotations: map[string]bool{"destructiveHint": true},
+		})
+	}
+	return tools
+}
+
+// callLog is one tool call audit record.
+type callLog struct {
+	Time       time.Time       `json:"ts"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	OK         bool            `json:"ok"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+func (s *Server) call(ctx context.Context, name string, args json.RawMessage) (interface{}, *rpcError) {
+	start := s.now()
+	result, err := s.exec(ctx, name, args)
+	if rerr, ok := err.(*rpcError); ok {
+		s.logCall(start, name, args, rerr)
+		return nil, rerr
+	}
+	s.logCall(start, name, args, err)
+	if err != nil {
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}, nil
+	}
+	text, _ := json.MarshalIndent(result, "", "  ")
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": string(text)}},
+	}, nil
+}
+
+func (s *Server) logCall(start time.Time, name string, args json.RawMessage, err error) {
+	if s.Log == nil {
+		return
+	}
+	entry := callLog{
+		Time:       start.UTC(),
+		Tool:       name,
+		Arguments:  args,
+		OK:         err == nil,
+		DurationMS: s.now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	json.NewEncoder(s.Log).Encode(entry)
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// exec runs a tool. Unknown tools and bad arguments are protocol errors
+// (*rpcError); API failures are returned as tool errors.
+func (s *Server) exec(ctx context.Context, name string, args json.RawMessage) (interface{}, error) {
+	invalid := func(err error) error { return &rpcError{codeInvalidParams, err.Error()} }
+
+	switch name {
+	case "list_sessions":
+		var p api.SessionsParams
+		if err := decodeArgs(args, &p); err != nil {
+			return nil, invalid(err)
+		}
+		return s.Ops.ListSessions(ctx, p)
+	case "query_events":
+		var p api.EventsParams
+		if err := decodeArgs(args, &p); err != nil {
+			return nil, invalid(err)
+		}
+		return s.Ops.ListEvents(ctx, p)
+	case "event_stats":
+		var p statsArgs
+		if err := decodeArgs(args, &p); err != nil {
+			return nil, invalid(err)
+		}
+		return s.Ops.GetEventStats(ctx, p.Since)
+	case "list_agents":
+		var p struct{}
+		if err := decodeArgs(args, &p); err != nil {
+			return nil, invalid(err)
+		}
+		return s.Ops.ListAgents(ctx)
+	case "revoke_session":
+		if !s.canWrite() {
+			return nil, &rpcError{codeInvalidParams, "revoke_session is not enabled (requires --allow-write and a write-trust agent key)"}
+		}
+		var p revokeArgs
+		if err := decodeArgs(args, &p); err != nil {
+			return nil, invalid(err)
+		}
+		if p.Scope != "user" && p.Scope != "session" {
+			return nil, invalid(fmt.Errorf("scope must be user or session"))
+		}
+		if p.ID == "" {
+			return nil, invalid(fmt.Errorf("id is required"))
+		}
+		return s.Ops.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: p.Scope, ID: p.ID})
+	}
+	return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", name)}
+}
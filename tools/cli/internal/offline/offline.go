@@ -0,0 +1,324 @@
+// Package offline reads a local database export so the ops views and
+// queries work without credentials or network. It accepts a SQLite file, a
+// SQL dump, or a backup.sh archive containing one.
+package offline
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	_ "modernc.org/sqlite"
+)
+
+// Page sizes, matching GET /ops/*.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// sqliteMagic starts every SQLite database file.
+const sqliteMagic = "SQLite format 3\x00"
+
+// DB is a read-only view of a database export. *DB satisfies api.Reader.
+type DB struct {
+	db   *sql.DB
+	path string
+	tmp  string // extracted database file, if any
+	// AsOf stands in for the server's "now": sessions count as active if
+	// they expire after it, and the default since is 24h before it. It
+	// defaults to the newest timestamp in the export.
+	AsOf time.Time
+}
+
+var _ api.Reader = (*DB)(nil)
+
+// Open loads the export at path. SQLite files are opened read-only; dumps
+// and archives are loaded into memory.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(len(sqliteMagic))
+
+	d := &DB{path: path}
+	switch {
+	case string(head) == sqliteMagic:
+		d.db, err = sql.Open("sqlite", "file:"+path+"?mode=ro")
+	case len(head) >= 2 && head[0] == 0x1f && head[1] == 0x8b:
+		var dump []byte
+		if dump, err = dumpFromArchive(br); err == nil {
+			d.db, d.tmp, err = load(dump)
+		}
+	default:
+		var dump []byte
+		if dump, err = io.ReadAll(br); err == nil {
+			d.db, d.tmp, err = load(dump)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	if err := d.check(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// dumpFromArchive returns the SQL dump, or an embedded SQLite file, from a
+// gzipped tar such as backup.sh writes (database.sql), or from a plain
+// gzipped dump.
+func dumpFromArchive(r io.Reader) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("archive has no .sql or .db file")
+		}
+		if err != nil {
+			// Not a tar: a gzipped dump.
+			return data, nil
+		}
+		if ext := path.Ext(h.Name); h.Typeflag == tar.TypeReg && (ext == ".sql" || ext == ".db") {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// load builds an in-memory database from a SQL dump. A SQLite file image
+// is written to a temporary file instead, removed again on Close.
+func load(dump []byte) (db *sql.DB, tmp string, err error) {
+	if bytes.HasPrefix(dump, []byte(sqliteMagic)) {
+		f, err := os.CreateTemp("", "plctl-offline-*.db")
+		if err != nil {
+			return nil, "", err
+		}
+		_, err = f.Write(dump)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			db, err = sql.Open("sqlite", "file:"+f.Name()+"?mode=ro")
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, "", err
+		}
+		return db, f.Name(), nil
+	}
+	if db, err = memory(); err != nil {
+		return nil, "", err
+	}
+	if _, err := db.Exec(string(dump)); err != nil {
+		db.Close()
+		return nil, "", fmt.Errorf("load dump: %w", err)
+	}
+	return db, "", nil
+}
+
+func memory() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Each connection to :memory: is its own database.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// check confirms the ops tables exist and sets AsOf.
+func (d *DB) check() error {
+	var latest sql.NullString
+	err := d.db.QueryRow(`SELECT max(t) FROM (
+		SELECT max(created_at) AS t FROM security_event
+		UNION ALL SELECT max(created_at) FROM session
+		UNION ALL SELECT max(created_at) FROM agent_credential)`).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("not a Private Landing export: %w", err)
+	}
+	d.AsOf = time.Now().UTC()
+	if latest.Valid {
+		if t, err := api.ParseTimestamp(latest.String); err == nil {
+			d.AsOf = t.UTC()
+		}
+	}
+	return nil
+}
+
+// Path is the export the database was loaded from.
+func (d *DB) Path() string { return d.path }
+
+// Close releases the database.
+func (d *DB) Close() error {
+	err := d.db.Close()
+	if d.tmp != "" {
+		os.Remove(d.tmp)
+	}
+	return err
+}
+
+// sqliteTime formats t the way the server stores timestamps.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
+// since resolves a since parameter like the server does, defaulting to 24h
+// before AsOf. Comparisons go through datetime() so ISO and SQLite formats
+// order correctly.
+func (d *DB) since(s string) string {
+	if s != "" {
+		if t, err := api.ParseTimestamp(s); err == nil {
+			return sqliteTime(t)
+		}
+	}
+	return sqliteTime(d.AsOf.Add(-24 * time.Hour))
+}
+
+func page(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return min(limit, maxPageSize), max(offset, 0)
+}
+
+// ListEvents mirrors GET /ops/events.
+func (d *DB) ListEvents(ctx context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	clauses := []string{"datetime(created_at) >= datetime(?)"}
+	args := []any{d.since(p.Since)}
+	if p.Type != "" {
+		clauses, args = append(clauses, "type = ?"), append(args, p.Type)
+	}
+	if uid, err := strconv.Atoi(p.UserID); err == nil {
+		clauses, args = append(clauses, "user_id = ?"), append(args, uid)
+	}
+	if p.IP != "" {
+		clauses, args = append(clauses, "ip_address = ?"), append(args, p.IP)
+	}
+	if p.ActorID != "" {
+		clauses, args = append(clauses, "actor_id = ?"), append(args, p.ActorID)
+	}
+	limit, offset := page(p.Limit, p.Offset)
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT id, type, ip_address, user_id, detail, created_at, actor_id FROM security_event WHERE "+
+			strings.Join(clauses, " AND ")+" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []api.Event{}
+	for rows.Next() {
+		var e api.Event
+		var uid sql.NullInt64
+		var detail sql.NullString
+		if err := rows.Scan(&e.ID, &e.Type, &e.IPAddress, &uid, &detail, &e.CreatedAt, &e.ActorID); err != nil {
+			return nil, err
+		}
+		if uid.Valid {
+			n := int(uid.Int64)
+			e.UserID = &n
+		}
+		if detail.Valid {
+			e.Detail = &detail.String
+		}
+		events = append(events, e)
+	}
+	return &api.ListEventsResponse{Events: events}, rows.Err()
+}
+
+// ListSessions mirrors GET /ops/sessions: sessions active as of AsOf.
+func (d *DB) ListSessions(ctx context.Context, p api.SessionsParams) (*api.ListSessionsResponse, error) {
+	clauses := []string{"datetime(expires_at) > datetime(?)"}
+	args := []any{sqliteTime(d.AsOf)}
+	if uid, err := strconv.Atoi(p.UserID); err == nil {
+		clauses, args = append(clauses, "user_id = ?"), append(args, uid)
+	}
+	limit, offset := page(p.Limit, p.Offset)
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT id, user_id, ip_address, user_agent, created_at, expires_at FROM session WHERE "+
+			strings.Join(clauses, " AND ")+" ORDER BY created_at DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sessions := []api.Session{}
+	for rows.Next() {
+		var s api.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return &api.ListSessionsResponse{Sessions: sessions}, rows.Err()
+}
+
+// GetEventStats mirrors GET /ops/events/stats.
+func (d *DB) GetEventStats(ctx context.Context, since string) (*api.EventStatsResponse, error) {
+	from := d.since(since)
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT type, COUNT(*) FROM security_event WHERE datetime(created_at) >= datetime(?) GROUP BY type", from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stats := make(map[string]int)
+	for rows.Next() {
+		var t string
+		var n int
+		if err := rows.Scan(&t, &n); err != nil {
+			return nil, err
+		}
+		stats[t] = n
+	}
+	t, _ := time.Parse(time.DateTime, from)
+	return &api.EventStatsResponse{Stats: stats, Since: t.Format(time.RFC3339)}, rows.Err()
+}
+
+// ListAgents mirrors GET /ops/agents: credentials not revoked.
+func (d *DB) ListAgents(ctx context.Context) (*api.ListAgentsResponse, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT name, trust_level, description, created_at FROM agent_credential WHERE revoked_at IS NULL ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	agents := []api.Agent{}
+	for rows.Next() {
+		var a api.Agent
+		var desc sql.NullString
+		if err := rows.Scan(&a.Name, &a.TrustLevel, &desc, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if desc.Valid {
+			a.Description = &desc.String
+		}
+		agents = append(agents, a)
+	}
+	return &api.ListAgentsResponse{Agents: agents}, rows.Err()
+}
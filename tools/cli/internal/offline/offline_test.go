@@ -0,0 +1,146 @@
+package offline
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// dump is a trimmed `.dump` of the auth and observability schema.
+const dump = `PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE account (id integer primary key, email text unique not null, password_data text not null, created_at text default current_timestamp);
+CREATE TABLE session (id text primary key, user_id integer not null, user_agent text not null, ip_address text not null, expires_at text not null, created_at text not null);
+CREATE TABLE security_event (id integer primary key autoincrement, type text not null, ip_address text not null, user_id integer, user_agent text, status integer, detail text, created_at text not null default (datetime('now')), actor_id text not null default 'app:private-landing');
+CREATE TABLE agent_credential (id integer primary key autoincrement, name text not null unique, key_hash text not null, trust_level text not null default 'read', description text, created_at text not null default (datetime('now')), revoked_at text);
+INSERT INTO session VALUES('s-live',1,'ua','203.0.113.1','2025-03-08 12:00:00','2025-03-01 11:00:00');
+INSERT INTO session VALUES('s-old',1,'ua','203.0.113.1','2025-02-20 12:00:00','2025-02-13 12:00:00');
+INSERT INTO security_event VALUES(1,'login.success','203.0.113.1',1,'ua',200,'{"sessionId":"s-live"}','2025-03-01 11:00:00','app:private-landing');
+INSERT INTO security_event VALUES(2,'login.failure','198.51.100.7',NULL,'ua',401,'{"email":"*@example.com"}','2025-03-01 11:30:00','app:private-landing');
+INSERT INTO security_event VALUES(3,'login.failure','198.51.100.7',NULL,'ua',401,NULL,'2025-03-01 12:00:00','app:private-landing');
+INSERT INTO security_event VALUES(4,'login.failure','198.51.100.7',NULL,'ua',401,NULL,'2025-02-01 12:00:00','app:private-landing');
+INSERT INTO agent_credential VALUES(1,'ops-bot','x','write','On-call bot','2025-01-01 00:00:00',NULL);
+INSERT INTO agent_credential VALUES(2,'old-bot','y','read',NULL,'2024-01-01 00:00:00','2024-06-01 00:00:00');
+COMMIT;
+`
+
+func writeExports(t *testing.T) map[string]string {
+	dir := t.TempDir()
+	paths := map[string]string{
+		"sql":    filepath.Join(dir, "database.sql"),
+		"tar.gz": filepath.Join(dir, "backup.tar.gz"),
+		"db":     filepath.Join(dir, "backup.db"),
+	}
+	if err := os.WriteFile(paths["sql"], []byte(dump), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "backup/database.sql", Mode: 0o600, Size: int64(len(dump)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(dump))
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(paths["tar.gz"], buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", paths["db"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(dump); err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestOpenFormats(t *testing.T) {
+	for format, path := range writeExports(t) {
+		t.Run(format, func(t *testing.T) {
+			d, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if got := d.AsOf.Format("2006-01-02 15:04:05"); got != "2025-03-01 12:00:00" {
+				t.Errorf("AsOf = %s, want the newest timestamp", got)
+			}
+			resp, err := d.ListEvents(context.Background(), api.EventsParams{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Events) != 3 || resp.Events[0].ID != 3 {
+				t.Errorf("events = %+v, want the 3 in the 24h before AsOf, newest first", resp.Events)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.sql")
+	os.WriteFile(path, []byte("CREATE TABLE notes (body text);"), 0o600)
+	if _, err := Open(path); err == nil {
+		t.Error("Open succeeded on a database without the ops tables")
+	}
+}
+
+func TestQueries(t *testing.T) {
+	d, err := Open(writeExports(t)["sql"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+
+	events, err := d.ListEvents(ctx, api.EventsParams{Type: "login.failure", IP: "198.51.100.7", Since: "2025-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Events) != 3 || events.Events[0].UserID != nil || events.Events[1].Detail == nil {
+		t.Errorf("failures = %+v", events.Events)
+	}
+	// An ISO since on the same day as stored rows must compare as a time,
+	// not as text.
+	events, _ = d.ListEvents(ctx, api.EventsParams{Since: "2025-03-01T11:15:00Z"})
+	if len(events.Events) != 2 {
+		t.Errorf("events since 11:15 = %d, want 2", len(events.Events))
+	}
+	events, _ = d.ListEvents(ctx, api.EventsParams{UserID: "1", Since: "2025-01-01T00:00:00Z", Limit: 1, Offset: 0})
+	if len(events.Events) != 1 || *events.Events[0].UserID != 1 {
+		t.Errorf("user events = %+v", events.Events)
+	}
+
+	sessions, err := d.ListSessions(ctx, api.SessionsParams{UserID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions.Sessions) != 1 || sessions.Sessions[0].ID != "s-live" {
+		t.Errorf("sessions = %+v, want only the one active as of AsOf", sessions.Sessions)
+	}
+
+	stats, err := d.GetEventStats(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats["login.failure"] != 2 || stats.Stats["login.success"] != 1 || stats.Since != "2025-02-28T12:00:00Z" {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	agents, err := d.ListAgents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agents.Agents) != 1 || agents.Agents[0].Name != "ops-bot" || *agents.Agents[0].Description != "On-call bot" {
+		t.Errorf("agents = %+v", agents.Agents)
+	}
+}
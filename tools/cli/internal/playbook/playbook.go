@@ -0,0 +1,162 @@
+// Package playbook runs versioned incident response procedures that chain
+// existing ops operations (snapshot, revoke, report) with per-step
+// confirmation and an audit log.
+package playbook
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin/*.yaml
+var builtinFS embed.FS
+
+// Playbook is a named, versioned sequence of steps.
+type Playbook struct {
+	Name        string  `yaml:"name"`
+	Version     string  `yaml:"version"`
+	Description string  `yaml:"description"`
+	Params      []Param `yaml:"params"`
+	Steps       []Step  `yaml:"steps"`
+}
+
+// Param is an input supplied on the command line as --<name> <value>.
+type Param struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	Default     string `yaml:"default"`
+}
+
+// Step is a single operation. With values are text/template strings
+// rendered against the playbook params.
+type Step struct {
+	Name   string            `yaml:"name"`
+	Action string            `yaml:"action"`
+	With   map[string]string `yaml:"with"`
+	// When names a param; the step is skipped if that param is empty.
+	When string `yaml:"when"`
+	// Confirm defaults to true. Set false for steps that never need a prompt.
+	Confirm *bool `yaml:"confirm"`
+}
+
+var actions = map[string]bool{
+	"snapshot":        true,
+	"stats":           true,
+	"revoke_sessions": true,
+	"delete_agent":    true,
+	"report":          true,
+}
+
+// NeedsConfirm reports whether the step prompts before running.
+func (s Step) NeedsConfirm() bool {
+	return s.Confirm == nil || *s.Confirm
+}
+
+// Builtins returns the names of the embedded playbooks.
+func Builtins() []string {
+	entries, _ := builtinFS.ReadDir("builtin")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load resolves a playbook by builtin name or file path.
+func Load(nameOrPath string) (*Playbook, error) {
+	data, err := builtinFS.ReadFile("builtin/" + nameOrPath + ".yaml")
+	if err != nil {
+		data, err = os.ReadFile(nameOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("unknown playbook %q (builtins: %s)", nameOrPath, strings.Join(Builtins(), ", "))
+		}
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a playbook from YAML.
+func Parse(data []byte) (*Playbook, error) {
+	var pb Playbook
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pb); err != nil {
+		return nil, fmt.Errorf("parse playbook: %w", err)
+	}
+	if pb.Name == "" {
+		return nil, fmt.Errorf("playbook name is required")
+	}
+	if len(pb.Steps) == 0 {
+		return nil, fmt.Errorf("playbook %q has no steps", pb.Name)
+	}
+
+	params := make(map[string]bool, len(pb.Params))
+	for _, p := range pb.Params {
+		params[p.Name] = true
+	}
+	for i, s := range pb.Steps {
+		if s.Name == "" {
+			return nil, fmt.Errorf("step %d: name is required", i+1)
+		}
+		if !actions[s.Action] {
+			return nil, fmt.Errorf("step %q: unknown action %q", s.Name, s.Action)
+		}
+		if s.When != "" && !params[s.When] {
+			return nil, fmt.Errorf("step %q: when references unknown param %q", s.Name, s.When)
+		}
+		for k, v := range s.With {
+			if _, err := template.New(k).Option("missingkey=error").Parse(v); err != nil {
+				return nil, fmt.Errorf("step %q: with.%s: %w", s.Name, k, err)
+			}
+		}
+	}
+	return &pb, nil
+}
+
+// ResolveParams applies defaults and checks required params.
+func (pb *Playbook) ResolveParams(given map[string]string) (map[string]string, error) {
+	known := make(map[string]bool, len(pb.Params))
+	out := make(map[string]string, len(pb.Params))
+	for _, p := range pb.Params {
+		known[p.Name] = true
+		v := given[p.Name]
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			return nil, fmt.Errorf("missing required param --%s (%s)", p.Name, p.Description)
+		}
+		out[p.Name] = v
+	}
+	for k := range given {
+		if !known[k] {
+			return nil, fmt.Errorf("unknown param --%s for playbook %s", k, pb.Name)
+		}
+	}
+	return out, nil
+}
+
+// render expands a step's With templates against params.
+func (s Step) render(params map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(s.With))
+	for k, v := range s.With {
+		tmpl, err := template.New(k).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, params); err != nil {
+			return nil, fmt.Errorf("with.%s: %w", k, err)
+		}
+		out[k] = b.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,73 @@
+package playbook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinsLoad(t *testing.T) {
+	names := Builtins()
+	if len(names) != 3 {
+		t.Fatalf("expected 3 builtins, got %v", names)
+	}
+	for _, name := range names {
+		pb, err := Load(name)
+		if err != nil {
+			t.Fatalf("Load(%q): %v", name, err)
+		}
+		if pb.Name != name {
+			t.Errorf("expected name %q, got %q", name, pb.Name)
+		}
+	}
+}
+
+func TestLoadUnknown(t *testing.T) {
+	_, err := Load("does-not-exist")
+	if err == nil || !strings.Contains(err.Error(), "compromised-account") {
+		t.Fatalf("expected error listing builtins, got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no name", "steps: [{name: a, action: report}]", "name is required"},
+		{"no steps", "name: x", "no steps"},
+		{"unknown action", "name: x\nsteps: [{name: a, action: nuke}]", "unknown action"},
+		{"unknown when", "name: x\nsteps: [{name: a, action: report, when: user}]", "unknown param"},
+		{"bad template", "name: x\nsteps: [{name: a, action: report, with: {id: '{{.user'}}]", "with.id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	pb, err := Load("compromised-account")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := pb.ResolveParams(map[string]string{}); err == nil || !strings.Contains(err.Error(), "--user") {
+		t.Fatalf("expected missing --user error, got %v", err)
+	}
+	if _, err := pb.ResolveParams(map[string]string{"user": "42", "usr": "1"}); err == nil || !strings.Contains(err.Error(), "--usr") {
+		t.Fatalf("expected unknown param error, got %v", err)
+	}
+
+	params, err := pb.ResolveParams(map[string]string{"user": "42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params["since"] != "7d" {
+		t.Fatalf("expected default since 7d, got %q", params["since"])
+	}
+}
@@ -0,0 +1,272 @@
+package playbook
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/evidence"
+)
+
+// Ops is the subset of api.Client operations playbooks use. Snapshots
+// collect evidence bundles, so it includes evidence.Ops.
+type Ops interface {
+	evidence.Ops
+	RevokeSessions(ctx context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error)
+	DeleteAgent(ctx context.Context, name string) (*api.DeleteAgentResponse, error)
+}
+
+// Step statuses recorded in the log.
+const (
+	StatusOK       = "ok"
+	StatusSkipped  = "skipped"
+	StatusDeclined = "declined"
+	StatusDryRun   = "dry-run"
+	StatusFailed   = "failed"
+)
+
+// StepResult is one audit log entry.
+type StepResult struct {
+	Time     time.Time         `json:"ts"`
+	Playbook string            `json:"playbook"`
+	Version  string            `json:"version"`
+	Step     string            `json:"step"`
+	Action   string            `json:"action"`
+	Args     map[string]string `json:"args,omitempty"`
+	Status   string            `json:"status"`
+	Summary  string            `json:"summary,omitempty"`
+	Files    []string          `json:"files,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Runner executes playbooks.
+type Runner struct {
+	Ops Ops
+	// OutDir receives snapshots and reports.
+	OutDir string
+	// Confirm is asked before each step that needs confirmation. Nil approves.
+	Confirm func(step Step, args map[string]string) bool
+	// Log receives every StepResult as a JSON line.
+	Log io.Writer
+	// DryRun records mutating steps without performing them.
+	DryRun bool
+	// Version, Target and Environment are recorded in snapshot manifests.
+	Version     string
+	Target      string
+	Environment string
+
+	now func() time.Time
+}
+
+// Run executes pb's steps in order and stops at the first failure. Declined
+// and skipped steps do not stop the run.
+func (r *Runner) Run(ctx context.Context, pb *Playbook, params map[string]string) ([]StepResult, error) {
+	if r.now == nil {
+		r.now = time.Now
+	}
+	if err := os.MkdirAll(r.OutDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create output dir: %w", err)
+	}
+
+	var results []StepResult
+	for _, step := range pb.Steps {
+		res := StepResult{Playbook: pb.Name, Version: pb.Version, Step: step.Name, Action: step.Action}
+
+		args, err := step.render(params)
+		if err != nil {
+			res.Status = StatusFailed
+			res.Error = err.Error()
+			results = append(results, r.record(res))
+			return results, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		res.Args = args
+
+		switch {
+		case step.When != "" && params[step.When] == "":
+			res.Status = StatusSkipped
+			res.Summary = fmt.Sprintf("--%s not set", step.When)
+		case step.NeedsConfirm() && r.Confirm != nil && !r.Confirm(step, args):
+			res.Status = StatusDeclined
+		case r.DryRun && mutates(step.Action):
+			res.Status = StatusDryRun
+		default:
+			res.Summary, res.Files, err = r.exec(ctx, pb, step, args, params, results)
+			if err != nil {
+				res.Status = StatusFailed
+				res.Error = err.Error()
+				results = append(results, r.record(res))
+				return results, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+			res.Status = StatusOK
+		}
+		results = append(results, r.record(res))
+	}
+	return results, nil
+}
+
+func mutates(action string) bool {
+	return action == "revoke_sessions" || action == "delete_agent"
+}
+
+func (r *Runner) record(res StepResult) StepResult {
+	res.Time = r.now().UTC()
+	if r.Log != nil {
+		json.NewEncoder(r.Log).Encode(res)
+	}
+	return res
+}
+
+func (r *Runner) exec(ctx context.Context, pb *Playbook, step Step, args, params map[string]string, prior []StepResult) (string, []string, error) {
+	switch step.Action {
+	case "snapshot":
+		return r.snapshot(ctx, step, args)
+	case "stats":
+		return r.stats(ctx, step, args)
+	case "revoke_sessions":
+		return r.revokeSessions(ctx, args)
+	case "delete_agent":
+		if args["name"] == "" {
+			return "", nil, fmt.Errorf("name is required")
+		}
+		resp, err := r.Ops.DeleteAgent(ctx, args["name"])
+		if err != nil {
+			return "", nil, err
+		}
+		return resp.Message, nil, nil
+	case "report":
+		return r.report(pb, params, prior)
+	}
+	return "", nil, fmt.Errorf("unknown action %q", step.Action)
+}
+
+// snapshot writes an evidence bundle, the same as plctl evidence collect,
+// signed when the sign_key arg names a key.
+func (r *Runner) snapshot(ctx context.Context, step Step, args map[string]string) (string, []string, error) {
+	since, err := api.ParseSince(args["since"], r.now())
+	if err != nil {
+		return "", nil, err
+	}
+	var key ed25519.PrivateKey
+	if args["sign_key"] != "" {
+		if key, err = evidence.LoadSigningKey(args["sign_key"]); err != nil {
+			return "", nil, err
+		}
+	}
+	c := &evidence.Collector{Ops: r.Ops, Version: r.Version, Target: r.Target, Environment: r.Environment}
+	b, err := c.Collect(ctx, evidence.Query{
+		Type:    args["type"],
+		UserID:  args["user"],
+		IP:      args["ip"],
+		ActorID: args["actor"],
+		Since:   since,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	name := step.Name + ".tar.gz"
+	if err := evidence.WriteFile(filepath.Join(r.OutDir, name), b, key); err != nil {
+		return "", nil, err
+	}
+	signed := "unsigned"
+	if key != nil {
+		signed = "signed"
+	}
+	return fmt.Sprintf("%d event(s), %d session(s) since %s (%s)", len(b.Events), len(b.Sessions), since, signed), []string{name}, nil
+}
+
+func (r *Runner) stats(ctx context.Context, step Step, args map[string]string) (string, []string, error) {
+	since, err := api.ParseSince(args["since"], r.now())
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := r.Ops.GetEventStats(ctx, since)
+	if err != nil {
+		return "", nil, err
+	}
+	name, err := r.writeJSON(step.Name+"-stats.json", resp)
+	if err != nil {
+		return "", nil, err
+	}
+	total := 0
+	for _, n := range resp.Stats {
+		total += n
+	}
+	return fmt.Sprintf("%d event(s) across %d type(s)", total, len(resp.Stats)), []string{name}, nil
+}
+
+func (r *Runner) revokeSessions(ctx context.Context, args map[string]string) (string, []string, error) {
+	scope := args["scope"]
+	req := api.RevokeSessionsRequest{Scope: scope}
+	switch scope {
+	case "user", "session":
+		if args["id"] == "" {
+			return "", nil, fmt.Errorf("id is required for %s scope", scope)
+		}
+		req.ID = args["id"]
+	case "all":
+	default:
+		return "", nil, fmt.Errorf("scope must be all, user or session")
+	}
+	resp, err := r.Ops.RevokeSessions(ctx, req)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%d session(s) revoked", resp.Revoked), nil, nil
+}
+
+func (r *Runner) writeJSON(name string, v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(r.OutDir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", name, err)
+	}
+	return name, nil
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`# Incident report: {{.Playbook.Name}} v{{.Playbook.Version}}
+
+{{.Playbook.Description}}
+
+Generated {{.Generated}}
+
+## Parameters
+
+{{range $k, $v := .Params}}- **{{$k}}**: {{if $v}}{{$v}}{{else}}-{{end}}
+{{end}}
+## Steps
+
+| Step | Action | Status | Summary |
+|---|---|---|---|
+{{range .Results}}| {{.Step}} | {{.Action}} | {{.Status}} | {{.Summary}}{{if .Error}} ({{.Error}}){{end}}{{if .Files}} — {{join .Files ", "}}{{end}} |
+{{end}}`))
+
+func (r *Runner) report(pb *Playbook, params map[string]string, prior []StepResult) (string, []string, error) {
+	var b strings.Builder
+	err := reportTmpl.Execute(&b, map[string]interface{}{
+		"Playbook":  pb,
+		"Params":    params,
+		"Results":   prior,
+		"Generated": r.now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	const name = "report.md"
+	if err := os.WriteFile(filepath.Join(r.OutDir, name), []byte(b.String()), 0o600); err != nil {
+		return "", nil, fmt.Errorf("write report: %w", err)
+	}
+	return fmt.Sprintf("%d step(s) reported", len(prior)), []string{name}, nil
+}
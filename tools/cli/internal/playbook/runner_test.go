@@ -0,0 +1,204 @@
+package playbook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/evidence"
+)
+
+type fakeOps struct {
+	eventParams []api.EventsParams
+	revokes     []api.RevokeSessionsRequest
+	deleted     []string
+	revokeErr   error
+}
+
+func (f *fakeOps) ListAllEvents(_ context.Context, p api.EventsParams) ([]api.Event, error) {
+	f.eventParams = append(f.eventParams, p)
+	return []api.Event{{ID: 1, Type: "login.success"}, {ID: 2, Type: "login.failure"}}, nil
+}
+
+func (f *fakeOps) ListAllSessions(_ context.Context, _ api.SessionsParams) ([]api.Session, error) {
+	return []api.Session{{ID: "s1", UserID: 42}}, nil
+}
+
+func (f *fakeOps) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 9}, Since: since}, nil
+}
+
+func (f *fakeOps) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{}, nil
+}
+
+func (f *fakeOps) RevokeSessions(_ context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error) {
+	if f.revokeErr != nil {
+		return nil, f.revokeErr
+	}
+	f.revokes = append(f.revokes, req)
+	return &api.RevokeSessionsResponse{Success: true, Revoked: 1}, nil
+}
+
+func (f *fakeOps) DeleteAgent(_ context.Context, name string) (*api.DeleteAgentResponse, error) {
+	f.deleted = append(f.deleted, name)
+	return &api.DeleteAgentResponse{Success: true, Message: "revoked"}, nil
+}
+
+func newRunner(ops Ops, dir string, log *bytes.Buffer) *Runner {
+	return &Runner{
+		Ops:    ops,
+		OutDir: dir,
+		Log:    log,
+		now:    func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
+	}
+}
+
+func statuses(results []StepResult) string {
+	s := make([]string, len(results))
+	for i, r := range results {
+		s[i] = r.Status
+	}
+	return strings.Join(s, ",")
+}
+
+func TestRunCompromisedAccount(t *testing.T) {
+	pb, _ := Load("compromised-account")
+	params, _ := pb.ResolveParams(map[string]string{"user": "42"})
+	ops := &fakeOps{}
+	dir := t.TempDir()
+	var log bytes.Buffer
+
+	results, err := newRunner(ops, dir, &log).Run(context.Background(), pb, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := statuses(results); got != "ok,ok,skipped,ok" {
+		t.Fatalf("unexpected statuses: %s", got)
+	}
+	if ops.eventParams[0].UserID != "42" || ops.eventParams[0].Since != "2026-03-01T12:00:00Z" {
+		t.Fatalf("unexpected snapshot params: %+v", ops.eventParams[0])
+	}
+	if len(ops.revokes) != 1 || ops.revokes[0].Scope != "user" || ops.revokes[0].ID != "42" {
+		t.Fatalf("unexpected revokes: %+v", ops.revokes)
+	}
+	if len(ops.deleted) != 0 {
+		t.Fatal("expected agent step to be skipped without --agent")
+	}
+
+	for _, f := range []string{"snapshot.tar.gz", "report.md"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("expected %s: %v", f, err)
+		}
+	}
+	b, err := evidence.Open(filepath.Join(dir, "snapshot.tar.gz"))
+	if err != nil {
+		t.Fatalf("snapshot is not a valid evidence bundle: %v", err)
+	}
+	if len(b.Events) != 2 || len(b.Sessions) != 1 || b.Manifest.Query.UserID != "42" || b.Signature != nil {
+		t.Fatalf("unexpected bundle: %d events, %d sessions, manifest %+v", len(b.Events), len(b.Sessions), b.Manifest)
+	}
+	report, _ := os.ReadFile(filepath.Join(dir, "report.md"))
+	if !strings.Contains(string(report), "| revoke-sessions | revoke_sessions | ok | 1 session(s) revoked |") {
+		t.Fatalf("report missing revoke row:\n%s", report)
+	}
+
+	if lines := strings.Count(log.String(), "\n"); lines != 4 {
+		t.Fatalf("expected 4 log lines, got %d", lines)
+	}
+	var first StepResult
+	json.Unmarshal([]byte(strings.SplitN(log.String(), "\n", 2)[0]), &first)
+	if first.Playbook != "compromised-account" || first.Version != "1" || first.Step != "snapshot" {
+		t.Fatalf("unexpected log entry: %+v", first)
+	}
+}
+
+func TestRunSignedSnapshot(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	pb, _ := Load("compromised-account")
+	params, _ := pb.ResolveParams(map[string]string{"user": "42", "sign_key": keyPath})
+	results, err := newRunner(&fakeOps{}, dir, &bytes.Buffer{}).Run(context.Background(), pb, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(results[0].Summary, "(signed)") {
+		t.Errorf("snapshot summary = %q", results[0].Summary)
+	}
+	b, err := evidence.Open(filepath.Join(dir, "snapshot.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.SignedBy(pub) {
+		t.Error("snapshot bundle is not signed by the sign_key")
+	}
+}
+
+func TestRunDeclinedStepContinues(t *testing.T) {
+	pb, _ := Load("compromised-account")
+	params, _ := pb.ResolveParams(map[string]string{"user": "42", "agent": "leaky"})
+	ops := &fakeOps{}
+	r := newRunner(ops, t.TempDir(), &bytes.Buffer{})
+	r.Confirm = func(step Step, _ map[string]string) bool { return step.Action != "revoke_sessions" }
+
+	results, err := r.Run(context.Background(), pb, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := statuses(results); got != "ok,declined,ok,ok" {
+		t.Fatalf("unexpected statuses: %s", got)
+	}
+	if len(ops.revokes) != 0 || len(ops.deleted) != 1 || ops.deleted[0] != "leaky" {
+		t.Fatalf("unexpected mutations: revokes=%v deleted=%v", ops.revokes, ops.deleted)
+	}
+}
+
+func TestRunDryRunSkipsMutations(t *testing.T) {
+	pb, _ := Load("leaked-agent-key")
+	params, _ := pb.ResolveParams(map[string]string{"agent": "leaky"})
+	ops := &fakeOps{}
+	r := newRunner(ops, t.TempDir(), &bytes.Buffer{})
+	r.DryRun = true
+
+	results, err := r.Run(context.Background(), pb, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := statuses(results); got != "ok,ok,dry-run,ok" {
+		t.Fatalf("unexpected statuses: %s", got)
+	}
+	if len(ops.deleted) != 0 {
+		t.Fatal("expected dry-run not to delete agent")
+	}
+	if ops.eventParams[0].ActorID != "agent:leaky" {
+		t.Fatalf("expected actor filter agent:leaky, got %q", ops.eventParams[0].ActorID)
+	}
+}
+
+func TestRunStopsOnFailure(t *testing.T) {
+	pb, _ := Load("compromised-account")
+	params, _ := pb.ResolveParams(map[string]string{"user": "42"})
+	ops := &fakeOps{revokeErr: errors.New("Forbidden (code: INSUFFICIENT_TRUST_LEVEL)")}
+
+	results, err := newRunner(ops, t.TempDir(), &bytes.Buffer{}).Run(context.Background(), pb, params)
+	if err == nil || !strings.Contains(err.Error(), "revoke-sessions") {
+		t.Fatalf("expected revoke-sessions failure, got %v", err)
+	}
+	if got := statuses(results); got != "ok,failed" {
+		t.Fatalf("unexpected statuses: %s", got)
+	}
+}
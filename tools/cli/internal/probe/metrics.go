@@ -0,0 +1,108 @@
+package probe
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Metrics aggregates results for Prometheus scraping.
+type Metrics struct {
+	mu       sync.Mutex
+	runs     int
+	failures int
+	last     *Result
+	stepFail map[string]int
+}
+
+// Record adds a cycle's result.
+func (m *Metrics) Record(r Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stepFail == nil {
+		m.stepFail = make(map[string]int)
+	}
+	m.runs++
+	if !r.OK {
+		m.failures++
+	}
+	for _, s := range r.Steps {
+		if !s.OK {
+			m.stepFail[s.Name]++
+		}
+	}
+	m.last = &r
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// Steps that did not run in the last cycle report success 0 and no latency.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cw := &countWriter{w: w}
+
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	metric("plctl_probe_runs_total", "counter", "Probe cycles run.")
+	fmt.Fprintf(cw, "plctl_probe_runs_total %d\n", m.runs)
+	metric("plctl_probe_failures_total", "counter", "Probe cycles with at least one failed step.")
+	fmt.Fprintf(cw, "plctl_probe_failures_total %d\n", m.failures)
+	metric("plctl_probe_step_failures_total", "counter", "Failures per step.")
+	for _, name := range Steps {
+		fmt.Fprintf(cw, "plctl_probe_step_failures_total{step=%q} %d\n", name, m.stepFail[name])
+	}
+	if m.last == nil {
+		return cw.n, cw.err
+	}
+
+	ran := make(map[string]Step, len(m.last.Steps))
+	for _, s := range m.last.Steps {
+		ran[s.Name] = s
+	}
+	metric("plctl_probe_success", "gauge", "Whether the last cycle passed every step.")
+	fmt.Fprintf(cw, "plctl_probe_success %d\n", b2i(m.last.OK))
+	metric("plctl_probe_last_run_timestamp_seconds", "gauge", "Start time of the last cycle.")
+	fmt.Fprintf(cw, "plctl_probe_last_run_timestamp_seconds %d\n", m.last.Time.Unix())
+	metric("plctl_probe_step_success", "gauge", "Whether the step passed in the last cycle.")
+	for _, name := range Steps {
+		fmt.Fprintf(cw, "plctl_probe_step_success{step=%q} %d\n", name, b2i(ran[name].OK))
+	}
+	metric("plctl_probe_step_latency_seconds", "gauge", "Step latency in the last cycle.")
+	for _, name := range Steps {
+		if s, ok := ran[name]; ok {
+			fmt.Fprintf(cw, "plctl_probe_step_latency_seconds{step=%q} %g\n", name, s.Latency.Seconds())
+		}
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
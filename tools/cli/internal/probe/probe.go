@@ -0,0 +1,170 @@
+// Package probe runs a synthetic login and session lifecycle against the
+// public auth API and confirms its effects through the ops API.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/userauth"
+)
+
+// Step names, in the order they run.
+const (
+	StepLogin       = "login"
+	StepMe          = "me"
+	StepRefresh     = "refresh"
+	StepLogout      = "logout"
+	StepEvent       = "login_event"
+	StepSessionGone = "session_gone"
+)
+
+// Steps lists every step name.
+var Steps = []string{StepLogin, StepMe, StepRefresh, StepLogout, StepEvent, StepSessionGone}
+
+// Auth is the end-user API the probe drives; *userauth.Client satisfies it.
+type Auth interface {
+	Login(ctx context.Context, email, password string) (*userauth.LoginResult, error)
+	Me(ctx context.Context) (int, error)
+	Refresh(ctx context.Context) error
+	Logout(ctx context.Context) error
+	Tokens() (access, refresh *userauth.Claims)
+}
+
+// Step is the outcome of one step of a cycle.
+type Step struct {
+	Name      string        `json:"step"`
+	OK        bool          `json:"ok"`
+	Latency   time.Duration `json:"-"`
+	LatencyMS float64       `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Result is one probe cycle. Steps that could not run (everything after a
+// failed login, or the session check after a failed logout) are absent.
+type Result struct {
+	Time  time.Time `json:"time"`
+	OK    bool      `json:"ok"`
+	UID   int       `json:"uid,omitempty"`
+	SID   string    `json:"sid,omitempty"`
+	Steps []Step    `json:"steps"`
+}
+
+// Prober runs cycles with a test account.
+type Prober struct {
+	// NewAuth returns a client with an empty cookie jar for each cycle.
+	NewAuth  func() Auth
+	Ops      api.Reader
+	Email    string
+	Password string
+	// Wait bounds how long the ops checks poll for the event pipeline and
+	// session store to catch up (default 30s).
+	Wait time.Duration
+	// Poll is the interval between ops checks (default 2s).
+	Poll time.Duration
+}
+
+// Run performs one cycle.
+func (p *Prober) Run(ctx context.Context) Result {
+	wait, poll := p.Wait, p.Poll
+	if wait <= 0 {
+		wait = 30 * time.Second
+	}
+	if poll <= 0 {
+		poll = 2 * time.Second
+	}
+	start := time.Now().UTC()
+	r := Result{Time: start, OK: true}
+	step := func(name string, fn func() error) bool {
+		t := time.Now()
+		err := fn()
+		s := Step{Name: name, OK: err == nil, Latency: time.Since(t)}
+		s.LatencyMS = float64(s.Latency.Microseconds()) / 1000
+		if err != nil {
+			s.Error = err.Error()
+			r.OK = false
+		}
+		r.Steps = append(r.Steps, s)
+		return err == nil
+	}
+
+	auth := p.NewAuth()
+	if !step(StepLogin, func() error {
+		if _, err := auth.Login(ctx, p.Email, p.Password); err != nil {
+			return err
+		}
+		_, refresh := auth.Tokens()
+		if refresh == nil {
+			return errors.New("no refresh token issued")
+		}
+		r.UID, r.SID = refresh.UID, refresh.SID
+		return nil
+	}) {
+		return r
+	}
+	step(StepMe, func() error {
+		uid, err := auth.Me(ctx)
+		if err == nil && uid != r.UID {
+			err = fmt.Errorf("me returned user %d, token has %d", uid, r.UID)
+		}
+		return err
+	})
+	step(StepRefresh, func() error { return auth.Refresh(ctx) })
+	// Log out even when me or refresh failed so probes never leak sessions.
+	loggedOut := step(StepLogout, func() error { return auth.Logout(ctx) })
+
+	since := start.Add(-5 * time.Second).Format(time.RFC3339)
+	step(StepEvent, func() error {
+		return until(ctx, wait, poll, func() (bool, error) {
+			events, err := api.AllEvents(ctx, p.Ops, api.EventsParams{Type: "login.success", UserID: fmt.Sprint(r.UID), Since: since})
+			if err != nil {
+				return false, err
+			}
+			for _, e := range events {
+				if userauth.SessionID(e) == r.SID {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, fmt.Sprintf("no login.success event for session %s", r.SID))
+	})
+	if loggedOut {
+		step(StepSessionGone, func() error {
+			return until(ctx, wait, poll, func() (bool, error) {
+				sessions, err := api.AllSessions(ctx, p.Ops, api.SessionsParams{UserID: fmt.Sprint(r.UID)})
+				if err != nil {
+					return false, err
+				}
+				for _, s := range sessions {
+					if s.ID == r.SID {
+						return false, nil
+					}
+				}
+				return true, nil
+			}, fmt.Sprintf("session %s still listed after logout", r.SID))
+		})
+	}
+	return r
+}
+
+// until retries check until it reports done, fails, or wait elapses.
+func until(ctx context.Context, wait, every time.Duration, check func() (bool, error), timeout string) error {
+	deadline := time.Now().Add(wait)
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		if time.Now().Add(every).After(deadline) {
+			return fmt.Errorf("%s after %s", timeout, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(every):
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"github.com/private-landing/cli/internal/userauth"
+)
+
+// fakeServer plays both sides: the auth API creates sessions and events,
+// and the ops reader lists them.
+type fakeServer struct {
+	mu        sync.Mutex
+	sessions  []api.Session
+	events    []api.Event
+	noEvent   bool
+	noRevoke  bool
+	failLogin bool
+}
+
+func (f *fakeServer) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ev := api.FilterEvents(f.events, p)
+	return &api.ListEventsResponse{Events: ev}, nil
+}
+
+func (f *fakeServer) ListSessions(_ context.Context, p api.SessionsParams) (*api.ListSessionsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := api.FilterSessions(f.sessions, p)
+	return &api.ListSessionsResponse{Sessions: s}, nil
+}
+
+func (f *fakeServer) GetEventStats(context.Context, string) (*api.EventStatsResponse, error) {
+	return nil, errors.New("unused")
+}
+
+func (f *fakeServer) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return nil, errors.New("unused")
+}
+
+type fakeAuth struct {
+	srv    *fakeServer
+	claims *userauth.Claims
+}
+
+func (a *fakeAuth) Login(context.Context, string, string) (*userauth.LoginResult, error) {
+	if a.srv.failLogin {
+		return nil, errors.New("Authentication failed (code: INVALID_CREDENTIALS)")
+	}
+	a.srv.mu.Lock()
+	defer a.srv.mu.Unlock()
+	a.claims = &userauth.Claims{UID: 9, SID: "sess-1", Typ: "refresh"}
+	a.srv.sessions = append(a.srv.sessions, api.Session{ID: "sess-1", UserID: 9})
+	if !a.srv.noEvent {
+		uid := 9
+		detail := `{"sessionId":"sess-1"}`
+		a.srv.events = append(a.srv.events, api.Event{ID: 1, Type: "login.success", UserID: &uid, Detail: &detail,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339)})
+	}
+	return &userauth.LoginResult{}, nil
+}
+
+func (a *fakeAuth) Me(context.Context) (int, error) { return 9, nil }
+func (a *fakeAuth) Refresh(context.Context) error   { return nil }
+
+func (a *fakeAuth) Logout(context.Context) error {
+	a.srv.mu.Lock()
+	defer a.srv.mu.Unlock()
+	if !a.srv.noRevoke {
+		a.srv.sessions = nil
+	}
+	return nil
+}
+
+func (a *fakeAuth) Tokens() (*userauth.Claims, *userauth.Claims) { return a.claims, a.claims }
+
+func run(srv *fakeServer) Result {
+	p := &Prober{
+		NewAuth: func() Auth { return &fakeAuth{srv: srv} },
+		Ops:     srv,
+		Wait:    50 * time.Millisecond,
+		Poll:    10 * time.Millisecond,
+	}
+	return p.Run(context.Background())
+}
+
+func stepNames(r Result) string {
+	var names []string
+	for _, s := range r.Steps {
+		mark := "+"
+		if !s.OK {
+			mark = "-"
+		}
+		names = append(names, mark+s.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestRunHealthy(t *testing.T) {
+	r := run(&fakeServer{})
+	if !r.OK || r.SID != "sess-1" || r.UID != 9 {
+		t.Fatalf("result = %+v", r)
+	}
+	if got := stepNames(r); got != "+login +me +refresh +logout +login_event +session_gone" {
+		t.Errorf("steps = %s", got)
+	}
+}
+
+func TestRunDetectsPipelineFailures(t *testing.T) {
+	r := run(&fakeServer{noEvent: true, noRevoke: true})
+	if r.OK {
+		t.Fatal("expected failure")
+	}
+	if got := stepNames(r); got != "+login +me +refresh +logout -login_event -session_gone" {
+		t.Errorf("steps = %s", got)
+	}
+	if !strings.Contains(r.Steps[5].Error, "still listed") {
+		t.Errorf("session error = %q", r.Steps[5].Error)
+	}
+}
+
+func TestRunStopsAfterFailedLogin(t *testing.T) {
+	r := run(&fakeServer{failLogin: true})
+	if got := stepNames(r); got != "-login" {
+		t.Errorf("steps = %s", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var m Metrics
+	m.Record(run(&fakeServer{}))
+	m.Record(run(&fakeServer{noEvent: true}))
+	var b strings.Builder
+	m.WriteTo(&b)
+	out := b.String()
+	for _, want := range []string{
+		"plctl_probe_runs_total 2",
+		"plctl_probe_failures_total 1",
+		`plctl_probe_step_failures_total{step="login_event"} 1`,
+		`plctl_probe_step_success{step="login_event"} 0`,
+		`plctl_probe_step_success{step="login"} 1`,
+		"plctl_probe_success 0",
+		"# TYPE plctl_probe_step_latency_seconds gauge",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+}
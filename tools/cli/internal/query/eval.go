@@ -0,0 +1,663 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// kind is a static type.
+type kind int
+
+const (
+	kAny kind = iota // a detail field: decided per event
+	kString
+	kInt
+	kTime
+	kBool
+	kNull
+)
+
+func (k kind) String() string {
+	return [...]string{"any", "string", "int", "time", "bool", "null"}[k]
+}
+
+type fieldType struct {
+	kind     kind
+	nullable bool
+}
+
+// fields is the api.Event schema. detail.<path> reaches into the detail
+// JSON and has type any.
+var fields = map[string]fieldType{
+	"id":         {kind: kInt},
+	"type":       {kind: kString},
+	"ip_address": {kind: kString},
+	"user_id":    {kind: kInt, nullable: true},
+	"detail":     {kind: kString, nullable: true},
+	"created_at": {kind: kTime},
+	"actor_id":   {kind: kString},
+}
+
+var aliases = map[string]string{"ip": "ip_address", "user": "user_id", "actor": "actor_id"}
+
+// functions maps each function to its argument kinds and result kind.
+var functions = map[string]struct {
+	args   []kind
+	result kind
+	fn     func(any) any
+}{
+	"hour":    {[]kind{kTime}, kInt, func(v any) any { return int64(v.(time.Time).Hour()) }},
+	"weekday": {[]kind{kTime}, kInt, func(v any) any { return int64(v.(time.Time).Weekday()) }},
+	"date":    {[]kind{kTime}, kString, func(v any) any { return v.(time.Time).Format(time.DateOnly) }},
+	"lower": {[]kind{kString, kAny}, kString, func(v any) any {
+		if s, ok := v.(string); ok {
+			return strings.ToLower(s)
+		}
+		return nil
+	}},
+	"len": {[]kind{kString, kAny}, kInt, func(v any) any {
+		if s, ok := v.(string); ok {
+			return int64(len([]rune(s)))
+		}
+		return nil
+	}},
+}
+
+// Fields lists the queryable fields, for help text.
+func Fields() []string {
+	out := make([]string, 0, len(fields)+1)
+	for f := range fields {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return append(out, "detail.<path>")
+}
+
+// Functions lists the available functions, for help text.
+func Functions() []string {
+	out := make([]string, 0, len(functions))
+	for f := range functions {
+		out = append(out, f+"()")
+	}
+	sort.Strings(out)
+	return out
+}
+
+// env is one event being evaluated, with its detail decoded at most once.
+type env struct {
+	e       *api.Event
+	detail  any
+	decoded bool
+}
+
+func (v *env) detailValue() any {
+	if !v.decoded {
+		v.decoded = true
+		if v.e.Detail != nil {
+			json.Unmarshal([]byte(*v.e.Detail), &v.detail)
+		}
+	}
+	return v.detail
+}
+
+type value struct {
+	kind     kind
+	nullable bool
+	lit      *literal // set for literals
+	get      func(*env) any
+}
+
+type pred func(*env) bool
+
+// Query is a parsed, type-checked expression.
+type Query struct {
+	src    string
+	match  pred
+	params api.EventsParams
+}
+
+// Parse parses and type checks src. Errors are *Error.
+func Parse(src string) (*Query, error) {
+	n, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	match, err := compile(n)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{src: src, match: match}
+	pushdown(n, &q.params)
+	return q, nil
+}
+
+// String returns the source expression.
+func (q *Query) String() string { return q.src }
+
+// Match reports whether e satisfies the query.
+func (q *Query) Match(e api.Event) bool {
+	return q.match(&env{e: &e})
+}
+
+// Params returns the exact type, user, IP and actor filters implied by the
+// query's top-level conjunction, so the server can narrow what it sends.
+// Match must still be applied to the results.
+func (q *Query) Params() api.EventsParams { return q.params }
+
+// Filter returns the events that match, in order.
+func (q *Query) Filter(events []api.Event) []api.Event {
+	var out []api.Event
+	for _, e := range events {
+		if q.Match(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Collect pages events since since from r, narrowed server-side by Params,
+// and returns those that match. scan caps how many events are read (0 for
+// no cap), since the rest of the query is evaluated locally; partial
+// reports that the cap was reached, so older matches may be missing.
+func (q *Query) Collect(ctx context.Context, r api.Reader, since string, scan int) (events []api.Event, partial bool, err error) {
+	p := q.params
+	p.Since, p.Limit = since, scan
+	events, err = api.AllEvents(ctx, r, p)
+	return q.Filter(events), scan > 0 && len(events) >= scan, err
+}
+
+// Expr is a parsed value expression such as type or hour(created_at),
+// evaluated per event, for grouping.
+type Expr struct {
+	src string
+	get func(*env) any
+}
+
+// ParseExpr parses and type checks a field or function call. Errors are
+// *Error.
+func ParseExpr(src string) (*Expr, error) {
+	n, err := parseValue(src)
+	if err != nil {
+		return nil, err
+	}
+	v, err := compileValue(n)
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, get: v.get}, nil
+}
+
+// String returns the source expression.
+func (x *Expr) String() string { return x.src }
+
+// Eval returns the expression's value for e: a string, int64, bool,
+// time.Time or nil, or a decoded JSON value for detail fields.
+func (x *Expr) Eval(e api.Event) any {
+	return x.get(&env{e: &e})
+}
+
+func pushdown(n node, p *api.EventsParams) {
+	switch n := n.(type) {
+	case *logical:
+		if n.op == "and" {
+			pushdown(n.l, p)
+			pushdown(n.r, p)
+		}
+	case *comparison:
+		f, ok := n.l.(*fieldRef)
+		lit, isLit := n.r.(*literal)
+		if !ok || !isLit || n.op != "=" {
+			return
+		}
+		switch canonical(f.name) {
+		case "type":
+			p.Type, _ = lit.v.(string)
+		case "ip_address":
+			p.IP, _ = lit.v.(string)
+		case "actor_id":
+			p.ActorID, _ = lit.v.(string)
+		case "user_id":
+			if id, ok := lit.v.(int64); ok {
+				p.UserID = strconv.FormatInt(id, 10)
+			}
+		}
+	}
+}
+
+func canonical(name string) string {
+	if a, ok := aliases[name]; ok {
+		return a
+	}
+	return name
+}
+
+func compile(n node) (pred, error) {
+	switch n := n.(type) {
+	case *logical:
+		l, err := compile(n.l)
+		if err != nil {
+			return nil, err
+		}
+		r, err := compile(n.r)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "and" {
+			return func(v *env) bool { return l(v) && r(v) }, nil
+		}
+		return func(v *env) bool { return l(v) || r(v) }, nil
+	case *negation:
+		x, err := compile(n.x)
+		if err != nil {
+			return nil, err
+		}
+		return func(v *env) bool { return !x(v) }, nil
+	case *comparison:
+		return compileComparison(n)
+	}
+	return nil, errorf(n.position(), "expected a comparison")
+}
+
+func compileValue(n node) (value, error) {
+	switch n := n.(type) {
+	case *literal:
+		v := value{lit: n, get: func(*env) any { return n.v }}
+		switch n.v.(type) {
+		case string:
+			v.kind = kString
+		case int64:
+			v.kind = kInt
+		case bool:
+			v.kind = kBool
+		default:
+			v.kind = kNull
+		}
+		return v, nil
+	case *fieldRef:
+		return compileField(n)
+	case *call:
+		fn, ok := functions[n.fn]
+		if !ok {
+			return value{}, errorf(n.pos, "unknown function %s (have %s)", n.fn, strings.Join(Functions(), ", "))
+		}
+		arg, err := compileValue(n.arg)
+		if err != nil {
+			return value{}, err
+		}
+		accepted := false
+		for _, k := range fn.args {
+			accepted = accepted || k == arg.kind
+		}
+		if !accepted {
+			return value{}, errorf(n.arg.position(), "%s() takes %s, not %s", n.fn, fn.args[0], arg.kind)
+		}
+		get := arg.get
+		return value{kind: fn.result, nullable: arg.nullable || arg.kind == kAny, get: func(v *env) any {
+			if x := get(v); x != nil {
+				return fn.fn(x)
+			}
+			return nil
+		}}, nil
+	}
+	return value{}, errorf(n.position(), "expected a field or value")
+}
+
+func compileField(f *fieldRef) (value, error) {
+	name := canonical(f.name)
+	if p, ok := strings.CutPrefix(name, "detail."); ok {
+		keys := strings.Split(p, ".")
+		for _, k := range keys {
+			if k == "" {
+				return value{}, errorf(f.pos, "empty key in %s", f.name)
+			}
+		}
+		return value{kind: kAny, nullable: true, get: func(v *env) any { return lookup(v.detailValue(), keys) }}, nil
+	}
+	t, ok := fields[name]
+	if !ok {
+		msg := "unknown field " + f.name
+		if s := suggest(f.name); s != "" {
+			msg += "; did you mean " + s + "?"
+		} else {
+			msg += " (have " + strings.Join(Fields(), ", ") + ")"
+		}
+		return value{}, errorf(f.pos, "%s", msg)
+	}
+	val := value{kind: t.kind, nullable: t.nullable}
+	switch name {
+	case "id":
+		val.get = func(v *env) any { return int64(v.e.ID) }
+	case "type":
+		val.get = func(v *env) any { return v.e.Type }
+	case "ip_address":
+		val.get = func(v *env) any { return v.e.IPAddress }
+	case "actor_id":
+		val.get = func(v *env) any { return v.e.ActorID }
+	case "user_id":
+		val.get = func(v *env) any {
+			if v.e.UserID == nil {
+				return nil
+			}
+			return int64(*v.e.UserID)
+		}
+	case "detail":
+		val.get = func(v *env) any {
+			if v.e.Detail == nil {
+				return nil
+			}
+			return *v.e.Detail
+		}
+	case "created_at":
+		val.get = func(v *env) any {
+			t, err := api.ParseTimestamp(v.e.CreatedAt)
+			if err != nil {
+				return nil
+			}
+			return t.UTC()
+		}
+	}
+	return val, nil
+}
+
+// lookup walks a decoded JSON value by object keys and array indexes.
+// Numbers come back as float64, like encoding/json.
+func lookup(v any, keys []string) any {
+	for _, k := range keys {
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[k]
+		case []any:
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			v = x[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// suggest returns the closest field name within two edits.
+func suggest(name string) string {
+	best, dist := "", 3
+	for _, c := range append(Fields(), "ip", "user", "actor") {
+		if d := editDistance(strings.ToLower(name), c); d < dist {
+			best, dist = c, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// describe names a value in an error message.
+func describe(n node, v value) string {
+	switch n := n.(type) {
+	case *fieldRef:
+		return n.name + " (" + v.kind.String() + ")"
+	case *literal:
+		return n.tok.String() + " (" + v.kind.String() + ")"
+	case *call:
+		return n.fn + "() (" + v.kind.String() + ")"
+	}
+	return v.kind.String()
+}
+
+// coerceTime turns a string literal compared with a time into a time.
+func coerceTime(other value, lit *value) error {
+	if other.kind != kTime || lit.lit == nil || lit.kind != kString {
+		return nil
+	}
+	s := lit.lit.v.(string)
+	t, err := api.ParseTimestamp(s)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, s); err != nil {
+			return errorf(lit.lit.tok.pos, "%s is not a timestamp (use 2006-01-02, 2006-01-02 15:04:05 or RFC 3339)", lit.lit.tok)
+		}
+	}
+	t = t.UTC()
+	lit.kind = kTime
+	lit.get = func(*env) any { return t }
+	return nil
+}
+
+func comparable(a, b kind) bool {
+	return a == b || a == kAny || b == kAny
+}
+
+func compileComparison(n *comparison) (pred, error) {
+	l, err := compileValue(n.l)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "in" {
+		return compileIn(n, l)
+	}
+	r, err := compileValue(n.r)
+	if err != nil {
+		return nil, err
+	}
+	if err := coerceTime(l, &r); err != nil {
+		return nil, err
+	}
+	if err := coerceTime(r, &l); err != nil {
+		return nil, err
+	}
+	lget, rget := l.get, r.get
+	mismatch := func() error {
+		return errorf(n.pos, "cannot compare %s %s %s", describe(n.l, l), n.op, describe(n.r, r))
+	}
+
+	switch n.op {
+	case "=", "!=":
+		want := n.op == "="
+		if l.kind == kNull || r.kind == kNull {
+			other, on := l, n.l
+			if l.kind == kNull {
+				other, on = r, n.r
+			}
+			if !other.nullable && other.kind != kNull {
+				return nil, errorf(n.pos, "%s is never null", describe(on, other))
+			}
+			return func(v *env) bool { return (lget(v) == nil && rget(v) == nil) == want }, nil
+		}
+		if !comparable(l.kind, r.kind) {
+			return nil, mismatch()
+		}
+		return func(v *env) bool {
+			a, b := lget(v), rget(v)
+			if a == nil || b == nil {
+				return !want && (a != nil || b != nil)
+			}
+			return equal(a, b) == want
+		}, nil
+
+	case "<", "<=", ">", ">=":
+		if !comparable(l.kind, r.kind) || l.kind == kBool || r.kind == kBool || l.kind == kNull || r.kind == kNull {
+			return nil, mismatch()
+		}
+		op := n.op
+		return func(v *env) bool {
+			c, ok := order(lget(v), rget(v))
+			if !ok {
+				return false
+			}
+			switch op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			}
+			return c >= 0
+		}, nil
+
+	case "~", "!~", "matches":
+		if l.kind != kString && l.kind != kAny {
+			return nil, errorf(n.l.position(), "%s needs a string on the left, not %s", n.op, describe(n.l, l))
+		}
+		if r.lit == nil || r.kind != kString {
+			return nil, errorf(n.r.position(), "%s needs a string pattern on the right", n.op)
+		}
+		pattern := r.lit.v.(string)
+		var match func(string) bool
+		if n.op == "matches" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, errorf(n.r.position(), "invalid regular expression: %v", err)
+			}
+			match = re.MatchString
+		} else {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errorf(n.r.position(), "invalid glob pattern %s", r.lit.tok)
+			}
+			match = func(s string) bool { ok, _ := path.Match(pattern, s); return ok }
+		}
+		want := n.op != "!~"
+		return func(v *env) bool {
+			s, ok := lget(v).(string)
+			return ok && match(s) == want
+		}, nil
+
+	case "contains", "startswith", "endswith":
+		if l.kind != kString && l.kind != kAny {
+			return nil, errorf(n.l.position(), "%s needs a string on the left, not %s", n.op, describe(n.l, l))
+		}
+		if r.kind != kString && r.kind != kAny {
+			return nil, errorf(n.r.position(), "%s needs a string on the right, not %s", n.op, describe(n.r, r))
+		}
+		test := map[string]func(string, string) bool{
+			"contains": strings.Contains, "startswith": strings.HasPrefix, "endswith": strings.HasSuffix,
+		}[n.op]
+		return func(v *env) bool {
+			a, ok1 := lget(v).(string)
+			b, ok2 := rget(v).(string)
+			return ok1 && ok2 && test(a, b)
+		}, nil
+	}
+	return nil, errorf(n.pos, "unknown operator %s", n.op)
+}
+
+func compileIn(n *comparison, l value) (pred, error) {
+	get := l.get
+	switch r := n.r.(type) {
+	case *intRange:
+		if l.kind != kInt && l.kind != kAny {
+			return nil, errorf(n.pos, "a range needs an int on the left, not %s", describe(n.l, l))
+		}
+		lo, hi := float64(r.lo), float64(r.hi)
+		return func(v *env) bool {
+			x, ok := number(get(v))
+			return ok && x >= lo && x <= hi
+		}, nil
+	case *list:
+		items := make([]any, len(r.items))
+		for i, it := range r.items {
+			iv, _ := compileValue(it)
+			if err := coerceTime(l, &iv); err != nil {
+				return nil, err
+			}
+			if iv.kind == kNull {
+				return nil, errorf(it.tok.pos, "use = null instead of null in a list")
+			}
+			if !comparable(l.kind, iv.kind) {
+				return nil, errorf(it.tok.pos, "cannot compare %s with list item %s", describe(n.l, l), describe(it, iv))
+			}
+			items[i] = iv.get(nil)
+		}
+		return func(v *env) bool {
+			x := get(v)
+			if x == nil {
+				return false
+			}
+			for _, it := range items {
+				if equal(x, it) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, errorf(n.r.position(), "expected a range or list after in")
+}
+
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	if t, ok := a.(time.Time); ok {
+		u, ok := b.(time.Time)
+		return ok && t.Equal(u)
+	}
+	switch a.(type) {
+	case string, bool:
+		return a == b
+	}
+	return false
+}
+
+// order compares two values of the same dynamic kind.
+func order(a, b any) (int, bool) {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if t, ok := a.(time.Time); ok {
+		u, ok := b.(time.Time)
+		return t.Compare(u), ok
+	}
+	if s, ok := a.(string); ok {
+		u, ok := b.(string)
+		return strings.Compare(s, u), ok
+	}
+	return 0, false
+}
@@ -0,0 +1,440 @@
+// Package query implements a small expression language over security
+// events, evaluated client-side where the server's type, user, IP and actor
+// filters are not enough:
+//
+//	type ~ "login.*" and detail.email endswith "@corp.com" and hour(created_at) in 0..6
+//
+// Expressions are type checked against the api.Event schema when parsed, so
+// mistakes are reported with their position before any event is read.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is a parse or type error at a byte offset in the source.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Caret renders src with a caret under the error position.
+func (e *Error) Caret(src string) string {
+	pos := min(max(e.Pos, 0), len(src))
+	return src + "\n" + strings.Repeat(" ", utf8.RuneCountInString(src[:pos])) + "^ " + e.Msg
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokKind int
+
+const (
+	tEOF tokKind = iota
+	tIdent
+	tString
+	tInt
+	tPunct
+)
+
+type token struct {
+	kind tokKind
+	text string // identifier, punctuation, or the raw literal
+	str  string // decoded string literal
+	num  int64
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tEOF:
+		return "end of query"
+	case tString:
+		return strconv.Quote(t.str)
+	}
+	return strconv.Quote(t.text)
+}
+
+// puncts are the operators and delimiters, longest first.
+var puncts = []string{"==", "!=", "!~", "<=", ">=", "..", "=", "~", "<", ">", "(", ")", "[", "]", ","}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != byte(r) {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, errorf(i, "unterminated string")
+			}
+			raw := src[i : end+1]
+			quoted := raw
+			if r == '\'' {
+				quoted = strconv.Quote(strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`))
+			}
+			s, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, errorf(i, "invalid string %s", raw)
+			}
+			toks = append(toks, token{kind: tString, text: raw, str: s, pos: i})
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			end := i + 1
+			for end < len(src) && src[end] >= '0' && src[end] <= '9' {
+				end++
+			}
+			n, err := strconv.ParseInt(src[i:end], 10, 64)
+			if err != nil {
+				return nil, errorf(i, "invalid number %s", src[i:end])
+			}
+			toks = append(toks, token{kind: tInt, text: src[i:end], num: n, pos: i})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(src) {
+				c, sz := utf8.DecodeRuneInString(src[end:])
+				if !(unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-' ||
+					(c == '.' && !strings.HasPrefix(src[end:], ".."))) {
+					break
+				}
+				end += sz
+			}
+			toks = append(toks, token{kind: tIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			matched := false
+			for _, p := range puncts {
+				if strings.HasPrefix(src[i:], p) {
+					toks = append(toks, token{kind: tPunct, text: p, pos: i})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errorf(i, "unexpected %q", r)
+			}
+		}
+	}
+	return append(toks, token{kind: tEOF, pos: len(src)}), nil
+}
+
+// AST nodes.
+type (
+	node interface{ position() int }
+
+	logical struct { // and, or
+		op   string
+		l, r node
+		pos  int
+	}
+	negation struct {
+		x   node
+		pos int
+	}
+	comparison struct {
+		op   string // = != ~ !~ < <= > >= in contains startswith endswith matches
+		l, r node
+		pos  int
+	}
+	fieldRef struct {
+		name string
+		pos  int
+	}
+	call struct {
+		fn  string
+		arg node
+		pos int
+	}
+	literal struct {
+		v   any // string, int64, bool or nil
+		tok token
+	}
+	intRange struct {
+		lo, hi int64
+		pos    int
+	}
+	list struct {
+		items []*literal
+		pos   int
+	}
+)
+
+func (n *logical) position() int    { return n.pos }
+func (n *negation) position() int   { return n.pos }
+func (n *comparison) position() int { return n.pos }
+func (n *fieldRef) position() int   { return n.pos }
+func (n *call) position() int       { return n.pos }
+func (n *literal) position() int    { return n.tok.pos }
+func (n *intRange) position() int   { return n.pos }
+func (n *list) position() int       { return n.pos }
+
+// Comparison operators: symbols, and those spelled as words.
+var (
+	symbolOps = map[string]bool{"=": true, "==": true, "!=": true, "~": true, "!~": true, "<": true, "<=": true, ">": true, ">=": true}
+	wordOps   = map[string]bool{"in": true, "contains": true, "startswith": true, "endswith": true, "matches": true}
+)
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func parse(src string) (node, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	if p.peek().kind == tEOF {
+		return nil, errorf(0, "empty query")
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, errorf(t.pos, "unexpected %s; expected and, or or end of query", t)
+	}
+	return n, nil
+}
+
+// parseValue parses src as a single field, function call or literal.
+func parseValue(src string) (node, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	if p.peek().kind == tEOF {
+		return nil, errorf(0, "empty expression")
+	}
+	n, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, errorf(t.pos, "unexpected %s; expected a single field or function call", t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tIdent && strings.EqualFold(t.text, word) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) punct(s string) bool {
+	if t := p.peek(); t.kind == tPunct && t.text == s {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	l, err := p.and()
+	for err == nil {
+		pos := p.peek().pos
+		if !p.keyword("or") {
+			return l, nil
+		}
+		var r node
+		if r, err = p.and(); err == nil {
+			l = &logical{op: "or", l: l, r: r, pos: pos}
+		}
+	}
+	return nil, err
+}
+
+func (p *parser) and() (node, error) {
+	l, err := p.unary()
+	for err == nil {
+		pos := p.peek().pos
+		if !p.keyword("and") {
+			return l, nil
+		}
+		var r node
+		if r, err = p.unary(); err == nil {
+			l = &logical{op: "and", l: l, r: r, pos: pos}
+		}
+	}
+	return nil, err
+}
+
+func (p *parser) unary() (node, error) {
+	pos := p.peek().pos
+	if p.keyword("not") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &negation{x: x, pos: pos}, nil
+	}
+	if p.punct("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.punct(")") {
+			t := p.peek()
+			return nil, errorf(t.pos, "expected ) but found %s", t)
+		}
+		return x, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	l, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	negated := false
+	if t.kind == tIdent && strings.EqualFold(t.text, "not") {
+		p.next()
+		negated = true
+		t = p.peek()
+		if t.kind != tIdent || !wordOps[strings.ToLower(t.text)] {
+			return nil, errorf(t.pos, "expected in, contains, startswith, endswith or matches after not")
+		}
+	}
+	var op string
+	switch {
+	case t.kind == tPunct && symbolOps[t.text]:
+		op = t.text
+		if op == "==" {
+			op = "="
+		}
+	case t.kind == tIdent && wordOps[strings.ToLower(t.text)]:
+		op = strings.ToLower(t.text)
+	default:
+		return nil, errorf(t.pos, "expected an operator (=, !=, ~, <, in, contains, …) but found %s", t)
+	}
+	p.next()
+	var r node
+	if op == "in" {
+		r, err = p.set()
+	} else {
+		r, err = p.operand()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var n node = &comparison{op: op, l: l, r: r, pos: t.pos}
+	if negated {
+		n = &negation{x: n, pos: t.pos}
+	}
+	return n, nil
+}
+
+// set parses the right side of in: lo..hi or [a, b, …].
+func (p *parser) set() (node, error) {
+	t := p.peek()
+	if p.punct("[") {
+		l := &list{pos: t.pos}
+		for {
+			item, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			lit, ok := item.(*literal)
+			if !ok {
+				return nil, errorf(item.position(), "list items must be literals")
+			}
+			l.items = append(l.items, lit)
+			if p.punct("]") {
+				return l, nil
+			}
+			if !p.punct(",") {
+				t := p.peek()
+				return nil, errorf(t.pos, "expected , or ] but found %s", t)
+			}
+		}
+	}
+	if t.kind == tInt {
+		p.next()
+		if !p.punct("..") {
+			u := p.peek()
+			return nil, errorf(u.pos, "expected .. after %s in a range", t.text)
+		}
+		hi := p.next()
+		if hi.kind != tInt {
+			return nil, errorf(hi.pos, "expected an integer after .. but found %s", hi)
+		}
+		if hi.num < t.num {
+			return nil, errorf(t.pos, "empty range %d..%d", t.num, hi.num)
+		}
+		return &intRange{lo: t.num, hi: hi.num, pos: t.pos}, nil
+	}
+	return nil, errorf(t.pos, "expected a range (0..6) or a list ([\"a\", \"b\"]) after in but found %s", t)
+}
+
+func (p *parser) operand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tString:
+		return &literal{v: t.str, tok: t}, nil
+	case tInt:
+		return &literal{v: t.num, tok: t}, nil
+	case tIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literal{v: true, tok: t}, nil
+		case "false":
+			return &literal{v: false, tok: t}, nil
+		case "null":
+			return &literal{v: nil, tok: t}, nil
+		case "and", "or", "not":
+			return nil, errorf(t.pos, "expected a field or value but found %s", t)
+		}
+		if p.punct("(") {
+			arg, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			if !p.punct(")") {
+				u := p.peek()
+				return nil, errorf(u.pos, "expected ) to close %s( but found %s", t.text, u)
+			}
+			return &call{fn: strings.ToLower(t.text), arg: arg, pos: t.pos}, nil
+		}
+		return &fieldRef{name: t.text, pos: t.pos}, nil
+	case tEOF:
+		return nil, errorf(t.pos, "unexpected end of query; expected a field or value")
+	}
+	return nil, errorf(t.pos, "expected a field or value but found %s", t)
+}
@@ -0,0 +1,199 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+var events = []api.Event{
+	{ID: 1, Type: "login.failure", IPAddress: "198.51.100.7", Detail: ptr(`{"email":"*@corp.com"}`), CreatedAt: "2025-03-01 03:15:00", ActorID: "app:private-landing"},
+	{ID: 2, Type: "login.success", IPAddress: "203.0.113.1", UserID: ptr(4), Detail: ptr(`{"sessionId":"s1"}`), CreatedAt: "2025-03-01 09:00:00", ActorID: "app:private-landing"},
+	{ID: 3, Type: "challenge.issued", IPAddress: "198.51.100.7", Detail: ptr(`{"difficulty":3,"meta":{"tags":["a","b"]}}`), CreatedAt: "2025-03-02T05:59:59Z", ActorID: "app:private-landing"},
+	{ID: 4, Type: "session.ops_revoke", IPAddress: "10.0.0.1", CreatedAt: "2025-03-02 23:00:00", ActorID: "agent:ops-bot"},
+}
+
+func ids(t *testing.T, src string) []int {
+	t.Helper()
+	q, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", src, err)
+	}
+	var out []int
+	for _, e := range q.Filter(events) {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	for src, want := range map[string][]int{
+		`type ~ "login.*" and detail.email endswith "@corp.com" and hour(created_at) in 0..6`: {1},
+		`type ~ "login.*"`:                               {1, 2},
+		`type !~ "login.*"`:                              {3, 4},
+		`type = "login.success" or actor ~ "agent:*"`:    {2, 4},
+		`not (ip = "198.51.100.7")`:                      {2, 4},
+		`user_id = null`:                                 {1, 3, 4},
+		`user != null and user >= 4`:                     {2},
+		`detail.difficulty > 2`:                          {3},
+		`detail.meta.tags.1 = "b"`:                       {3},
+		`detail.missing = null and detail != null`:       {1, 2, 3},
+		`created_at >= "2025-03-02"`:                     {3, 4},
+		`created_at < "2025-03-01T09:00:00Z"`:            {1},
+		`date(created_at) = "2025-03-02"`:                {3, 4},
+		`weekday(created_at) = 0`:                        {3, 4},
+		`id in [1, 4]`:                                   {1, 4},
+		`type not in ["login.failure", "login.success"]`: {3, 4},
+		`type matches "^(login|session)\\."`:             {1, 2, 4},
+		`lower(type) contains "OPS"`:                     nil,
+		`type contains "ops" AND ip startswith '10.'`:    {4},
+		`len(ip) > 11`:                                   {1, 3},
+	} {
+		if got := ids(t, src); !equalInts(got, want) {
+			t.Errorf("%s = %v, want %v", src, got, want)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestErrors(t *testing.T) {
+	for src, want := range map[string]struct {
+		col int
+		msg string
+	}{
+		``:                         {1, "empty query"},
+		`tpye = "x"`:               {1, "did you mean type?"},
+		`colour = 1`:               {1, "unknown field colour (have"},
+		`type = `:                  {8, "unexpected end of query"},
+		`type "x"`:                 {6, "expected an operator"},
+		`type = "x" and`:           {15, "unexpected end of query"},
+		`type = "x" user = 1`:      {12, "expected and, or"},
+		`(type = "x"`:              {12, "expected )"},
+		`type = "unterminated`:     {8, "unterminated string"},
+		`user_id = "4"`:            {9, "cannot compare user_id (int) = \"4\" (string)"},
+		`type = null`:              {6, "type (string) is never null"},
+		`created_at > "yesterday"`: {14, "not a timestamp"},
+		`hour(type) = 1`:           {6, "hour() takes time, not string"},
+		`shout(type) = 1`:          {1, "unknown function shout"},
+		`type ~ "[a"`:              {8, "invalid glob pattern"},
+		`type matches "("`:         {14, "invalid regular expression"},
+		`id in 6..0`:               {7, "empty range"},
+		`type in 0..6`:             {6, "a range needs an int"},
+		`id in [1, "2"]`:           {11, "cannot compare id (int) with list item"},
+		`type contains 3`:          {15, "contains needs a string on the right"},
+		`type not = "x"`:           {10, "expected in, contains"},
+		`type = "x" & 1`:           {12, "unexpected '&'"},
+	} {
+		_, err := Parse(src)
+		var qe *Error
+		if !errors.As(err, &qe) {
+			t.Errorf("Parse(%q) = %v, want *Error", src, err)
+			continue
+		}
+		if qe.Pos+1 != want.col || !strings.Contains(qe.Msg, want.msg) {
+			t.Errorf("Parse(%q) = %v, want column %d containing %q", src, err, want.col, want.msg)
+		}
+	}
+}
+
+func TestExpr(t *testing.T) {
+	for src, want := range map[string][]any{
+		`type`:                {"login.failure", "login.success", "challenge.issued", "session.ops_revoke"},
+		`user`:                {nil, int64(4), nil, nil},
+		`hour(created_at)`:    {int64(3), int64(9), int64(5), int64(23)},
+		`detail.difficulty`:   {nil, nil, float64(3), nil},
+		`lower(detail.email)`: {"*@corp.com", nil, nil, nil},
+	} {
+		x, err := ParseExpr(src)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q): %v", src, err)
+		}
+		for i, e := range events {
+			if got := x.Eval(e); got != want[i] {
+				t.Errorf("%s on event %d = %#v, want %#v", src, e.ID, got, want[i])
+			}
+		}
+	}
+
+	for src, msg := range map[string]string{
+		``:           "empty expression",
+		`tpye`:       "did you mean type?",
+		`type = "x"`: "expected a single field or function call",
+		`hour(type)`: "hour() takes time, not string",
+	} {
+		if _, err := ParseExpr(src); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseExpr(%q) = %v, want %q", src, err, msg)
+		}
+	}
+}
+
+func TestCaret(t *testing.T) {
+	src := `type = "é" and tpye = "x"`
+	_, err := Parse(src)
+	var qe *Error
+	if !errors.As(err, &qe) {
+		t.Fatal(err)
+	}
+	lines := strings.Split(qe.Caret(src), "\n")
+	if want := len([]rune(`type = "é" and `)); len(lines) != 2 || strings.Index(lines[1], "^") != want {
+		t.Errorf("caret not under column %d:\n%s", want, qe.Caret(src))
+	}
+}
+
+func TestParams(t *testing.T) {
+	q, err := Parse(`type = "login.failure" and ip = "198.51.100.7" and (actor = "x" or user = 3) and not user = 4`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := q.Params()
+	if p.Type != "login.failure" || p.IP != "198.51.100.7" || p.ActorID != "" || p.UserID != "" {
+		t.Errorf("params = %+v; only top-level conjuncts may be pushed down", p)
+	}
+	q, _ = Parse(`user_id = 4 and actor_id = "agent:x"`)
+	if p := q.Params(); p.UserID != "4" || p.ActorID != "agent:x" {
+		t.Errorf("params = %+v", p)
+	}
+}
+
+type reader struct {
+	api.Reader
+	got api.EventsParams
+}
+
+func (r *reader) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	r.got = p
+	return &api.ListEventsResponse{Events: api.FilterEvents(events, api.EventsParams{Type: p.Type})}, nil
+}
+
+func TestCollect(t *testing.T) {
+	q, _ := Parse(`type = "login.failure" and detail.email endswith "@corp.com"`)
+	r := &reader{}
+	got, partial, err := q.Collect(context.Background(), r, "2025-01-01T00:00:00Z", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != 1 || partial || r.got.Type != "login.failure" || r.got.Since != "2025-01-01T00:00:00Z" {
+		t.Errorf("got %v (partial %v) with params %+v", got, partial, r.got)
+	}
+
+	// Reading only the first event hits the cap.
+	if _, partial, _ := q.Collect(context.Background(), r, "2025-01-01T00:00:00Z", 1); !partial {
+		t.Error("scan cap reached but not reported")
+	}
+}
@@ -0,0 +1,251 @@
+// Package replay plays recorded security events back on a virtual clock, so
+// the tail view, rules and gateway clients see them as if they were live.
+// Everything downstream keys off event time, so a replay produces the same
+// alerts at any speed.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Max is the speed that plays events back to back without waiting.
+const Max = 0
+
+// Load reads newline-delimited events in any shape api.DecodeEvent accepts
+// (REST rows, WebSocket payloads, tee and archive export files) and returns
+// them oldest first. Events sharing a timestamp keep ID order.
+func Load(r io.Reader) ([]api.Event, error) {
+	type timed struct {
+		at time.Time
+		e  api.Event
+	}
+	var all []timed
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		e, err := api.DecodeEvent(data)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		at, err := api.ParseTimestamp(e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: created_at: %w", line, err)
+		}
+		all = append(all, timed{at, e})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	slices.SortStableFunc(all, func(a, b timed) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return a.e.ID - b.e.ID
+	})
+	events := make([]api.Event, len(all))
+	for i, t := range all {
+		events[i] = t.e
+	}
+	return events, nil
+}
+
+// ParseSpeed parses a playback speed such as "10x", "0.5" or "max".
+func ParseSpeed(s string) (float64, error) {
+	if s == "max" {
+		return Max, nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid speed %q (want e.g. 1x, 10x, 0.5x or max)", s)
+	}
+	return v, nil
+}
+
+// FormatSpeed renders a speed the way ParseSpeed reads it.
+func FormatSpeed(speed float64) string {
+	if speed == Max {
+		return "max"
+	}
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "x"
+}
+
+// Player steps through a recording. Its clock is in event time and runs
+// speed times faster than the wall time passed to Advance; quiet stretches
+// longer than maxGap are cut short. A Player is not safe for concurrent use.
+type Player struct {
+	events []api.Event
+	times  []time.Time
+	speed  float64
+	maxGap time.Duration
+	pos    int // events played
+	clock  time.Time
+	paused bool
+}
+
+// NewPlayer returns a player positioned at the first event. events must be
+// in time order, as Load returns them; unparseable timestamps take the time
+// of the event before.
+func NewPlayer(events []api.Event, speed float64, maxGap time.Duration) *Player {
+	p := &Player{events: events, times: make([]time.Time, len(events)), speed: speed, maxGap: maxGap}
+	for i, e := range events {
+		t, err := api.ParseTimestamp(e.CreatedAt)
+		if err != nil && i > 0 {
+			t = p.times[i-1]
+		}
+		p.times[i] = t
+	}
+	if len(events) > 0 {
+		p.clock = p.times[0]
+	}
+	return p
+}
+
+// Len is the number of events in the recording.
+func (p *Player) Len() int { return len(p.events) }
+
+// Pos is the number of events played so far.
+func (p *Player) Pos() int { return p.pos }
+
+// Done reports whether every event has been played.
+func (p *Player) Done() bool { return p.pos >= len(p.events) }
+
+// Played returns the events played so far, oldest first.
+func (p *Player) Played() []api.Event { return p.events[:p.pos] }
+
+// Clock is the current position in event time.
+func (p *Player) Clock() time.Time { return p.clock }
+
+// Start is the time of the first event.
+func (p *Player) Start() time.Time {
+	if len(p.times) == 0 {
+		return time.Time{}
+	}
+	return p.times[0]
+}
+
+// End is the time of the last event.
+func (p *Player) End() time.Time {
+	if len(p.times) == 0 {
+		return time.Time{}
+	}
+	return p.times[len(p.times)-1]
+}
+
+// Progress is the clock's position between Start and End, from 0 to 1.
+func (p *Player) Progress() float64 {
+	span := p.End().Sub(p.Start())
+	if span <= 0 {
+		if p.Done() {
+			return 1
+		}
+		return 0
+	}
+	return min(max(float64(p.clock.Sub(p.Start()))/float64(span), 0), 1)
+}
+
+// Speed is the playback speed; Max plays without waiting.
+func (p *Player) Speed() float64 { return p.speed }
+
+// SetSpeed changes the playback speed.
+func (p *Player) SetSpeed(speed float64) { p.speed = speed }
+
+// Paused reports whether Advance is holding the clock.
+func (p *Player) Paused() bool { return p.paused }
+
+// Pause holds the clock until Resume.
+func (p *Player) Pause() { p.paused = true }
+
+// Resume lets the clock run again.
+func (p *Player) Resume() { p.paused = false }
+
+// gap is how far the clock has to move to reach the next event, with quiet
+// stretches cut to maxGap.
+func (p *Player) gap() time.Duration {
+	d := max(p.times[p.pos].Sub(p.clock), 0)
+	if p.maxGap > 0 {
+		d = min(d, p.maxGap)
+	}
+	return d
+}
+
+// Wait is the wall time until the next event is due: zero at Max speed,
+// and zero once done or while paused.
+func (p *Player) Wait() time.Duration {
+	if p.Done() || p.paused || p.speed == Max {
+		return 0
+	}
+	return time.Duration(float64(p.gap()) / p.speed)
+}
+
+// Advance moves the clock forward by wall time at the current speed and
+// returns the events that became due. At Max speed it plays the next
+// timestamp's events regardless of wall. It returns nothing while paused.
+func (p *Player) Advance(wall time.Duration) []api.Event {
+	if p.Done() || p.paused {
+		return nil
+	}
+	if p.speed == Max {
+		p.clock = p.times[p.pos]
+	} else {
+		// Skip the part of a long gap beyond maxGap before spending wall
+		// time on it.
+		if skip := p.times[p.pos].Sub(p.clock) - p.gap(); skip > 0 {
+			p.clock = p.clock.Add(skip)
+		}
+		p.clock = p.clock.Add(time.Duration(float64(wall) * p.speed))
+	}
+	return p.due()
+}
+
+// Step plays the next timestamp's events immediately, even while paused.
+func (p *Player) Step() []api.Event {
+	if p.Done() {
+		return nil
+	}
+	p.clock = p.times[p.pos]
+	return p.due()
+}
+
+func (p *Player) due() []api.Event {
+	start := p.pos
+	for p.pos < len(p.events) && !p.times[p.pos].After(p.clock) {
+		p.pos++
+	}
+	if p.Done() {
+		p.clock = p.End()
+	}
+	return p.events[start:p.pos]
+}
+
+// Seek moves the clock to t, clamped to the recording, and marks events
+// before t as played. Events at exactly t are due on the next Advance.
+// Callers rebuild downstream state from Played.
+func (p *Player) Seek(t time.Time) {
+	if len(p.times) == 0 {
+		return
+	}
+	if t.Before(p.Start()) {
+		t = p.Start()
+	}
+	if t.After(p.End()) {
+		t = p.End()
+	}
+	p.clock = t
+	p.pos = sort.Search(len(p.times), func(i int) bool { return !p.times[i].Before(t) })
+}
@@ -0,0 +1,137 @@
+package replay
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func ids(events []api.Event) []int {
+	out := []int{}
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestLoadSorts(t *testing.T) {
+	// Newest first, as events --json prints them, and in mixed shapes.
+	input := strings.Join([]string{
+		`{"id":3,"type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01 12:00:20"}`,
+		`{"type":"event","payload":{"event_id":2,"event_type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:10Z"}}`,
+		``,
+		`{"event_id":4,"event_type":"login.success","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:10Z"}`,
+		`{"id":1,"type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:00Z"}`,
+	}, "\n")
+	events, err := Load(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(events); !slices.Equal(got, []int{1, 2, 4, 3}) {
+		t.Errorf("order = %v, want [1 2 4 3]", got)
+	}
+
+	_, err = Load(strings.NewReader(`{"id":1,"type":"x","created_at":"2026-03-01T12:00:00Z"}` + "\n" + `{"id":2,"type":"x","created_at":"yesterday"}`))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("bad timestamp error = %v, want line 2", err)
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"10x": 10, "1": 1, "0.5x": 0.5, "max": Max} {
+		if got, err := ParseSpeed(in); err != nil || got != want {
+			t.Errorf("ParseSpeed(%q) = %v, %v; want %v", in, got, err, want)
+		}
+		if in != "1" && FormatSpeed(want) != in {
+			t.Errorf("FormatSpeed(%v) = %q, want %q", want, FormatSpeed(want), in)
+		}
+	}
+	for _, in := range []string{"", "0x", "-2x", "fast"} {
+		if _, err := ParseSpeed(in); err == nil {
+			t.Errorf("ParseSpeed(%q) accepted", in)
+		}
+	}
+}
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns events numbered from 1 at the given offsets from t0.
+func at(offsets ...time.Duration) []api.Event {
+	out := make([]api.Event, len(offsets))
+	for i, d := range offsets {
+		out[i] = api.Event{ID: i + 1, Type: "login.failure", CreatedAt: t0.Add(d).Format(time.RFC3339)}
+	}
+	return out
+}
+
+func TestPlayerAdvance(t *testing.T) {
+	p := NewPlayer(at(0, 10*time.Second, 10*time.Second, time.Hour), 10, 0)
+	if got := ids(p.Advance(0)); !slices.Equal(got, []int{1}) {
+		t.Fatalf("first advance = %v, want [1]", got)
+	}
+	if w := p.Wait(); w != time.Second {
+		t.Errorf("wait = %v, want 1s for 10s of event time at 10x", w)
+	}
+	if got := p.Advance(500 * time.Millisecond); len(got) != 0 {
+		t.Errorf("half way = %v, want nothing", ids(got))
+	}
+	if got := ids(p.Advance(500 * time.Millisecond)); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("advance = %v, want [2 3]", got)
+	}
+
+	p.Pause()
+	if got := p.Advance(time.Hour); len(got) != 0 || p.Wait() != 0 {
+		t.Errorf("paused advance = %v", ids(got))
+	}
+	if got := ids(p.Step()); !slices.Equal(got, []int{4}) || !p.Done() || p.Progress() != 1 {
+		t.Errorf("step = %v, done %v, progress %v", got, p.Done(), p.Progress())
+	}
+}
+
+func TestPlayerMaxGap(t *testing.T) {
+	p := NewPlayer(at(0, time.Hour), 1, 5*time.Second)
+	p.Advance(0)
+	if w := p.Wait(); w != 5*time.Second {
+		t.Errorf("wait = %v, want the 5s max gap", w)
+	}
+	if got := p.Advance(4 * time.Second); len(got) != 0 {
+		t.Errorf("early = %v", ids(got))
+	}
+	if got := ids(p.Advance(time.Second)); !slices.Equal(got, []int{2}) {
+		t.Errorf("after max gap = %v, want [2]", got)
+	}
+
+	p = NewPlayer(at(0, time.Minute, time.Hour), Max, 0)
+	var order []int
+	for !p.Done() {
+		if p.Wait() != 0 {
+			t.Fatal("max speed waited")
+		}
+		order = append(order, ids(p.Advance(0))...)
+	}
+	if !slices.Equal(order, []int{1, 2, 3}) {
+		t.Errorf("max speed order = %v", order)
+	}
+}
+
+func TestPlayerSeek(t *testing.T) {
+	p := NewPlayer(at(0, time.Minute, 2*time.Minute, 4*time.Minute), 1, 0)
+	p.Seek(t0.Add(2 * time.Minute))
+	if p.Pos() != 2 || !p.Clock().Equal(t0.Add(2*time.Minute)) {
+		t.Fatalf("seek to 2m = pos %d at %v", p.Pos(), p.Clock())
+	}
+	if got := ids(p.Played()); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("played = %v", got)
+	}
+	if got := ids(p.Advance(0)); !slices.Equal(got, []int{3}) {
+		t.Errorf("advance after seek = %v, want the event at the seek time", got)
+	}
+
+	p.Seek(t0.Add(-time.Hour))
+	if p.Pos() != 0 || p.Progress() != 0 {
+		t.Errorf("seek before start = pos %d, progress %v", p.Pos(), p.Progress())
+	}
+}
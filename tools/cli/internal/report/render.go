@@ -0,0 +1,159 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Formats lists the supported output formats.
+var Formats = []string{"markdown", "html"}
+
+var (
+	mdTmpl = template.Must(template.New("report.md.tmpl").Funcs(template.FuncMap{
+		"date":       formatDate,
+		"datetime":   formatDateTime,
+		"typeChart":  typeChart,
+		"dailyChart": dailyChart,
+		"cell":       mdCell,
+	}).ParseFS(templateFS, "templates/report.md.tmpl"))
+
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("report.html.tmpl").Funcs(htmltemplate.FuncMap{
+		"date":     formatDate,
+		"datetime": formatDateTime,
+		// Chart SVG is built from escaped strings, so it is safe to inline.
+		"typeChart":  func(c []TypeCount) htmltemplate.HTML { return htmltemplate.HTML(typeChart(c)) },
+		"dailyChart": func(d []Day) htmltemplate.HTML { return htmltemplate.HTML(dailyChart(d)) },
+		"row":        func(label string, c TypeCount) labeledCount { return labeledCount{label, c} },
+		"trendClass": trendClass,
+	}).ParseFS(templateFS, "templates/report.html.tmpl"))
+)
+
+type labeledCount struct {
+	Label string
+	Count TypeCount
+}
+
+// Render writes r in the given format ("markdown"/"md" or "html").
+func Render(w io.Writer, r *Report, format string) error {
+	switch format {
+	case "markdown", "md":
+		return mdTmpl.Execute(w, r)
+	case "html":
+		return htmlTmpl.Execute(w, r)
+	}
+	return fmt.Errorf("unknown format %q (want %s)", format, strings.Join(Formats, " or "))
+}
+
+// mdCell escapes a value for a Markdown table cell. Renderers pass inline
+// HTML through, so angle brackets are escaped along with pipes.
+var mdCell = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", `\|`, "\n", " ").Replace
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func trendClass(c TypeCount) string {
+	switch {
+	case c.Count > c.Previous:
+		return "up"
+	case c.Count < c.Previous:
+		return "down"
+	}
+	return ""
+}
+
+// --- Inline SVG charts ---
+
+const (
+	chartWidth   = 640
+	barHeight    = 18
+	barGap       = 6
+	labelWidth   = 180
+	maxChartBars = 15
+	barColor     = "#0969da"
+	prevColor    = "#d0d7de"
+)
+
+// typeChart draws horizontal bars per event type, with the previous period
+// as a thinner grey bar underneath.
+func typeChart(counts []TypeCount) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	if len(counts) > maxChartBars {
+		counts = counts[:maxChartBars]
+	}
+	peak := 1
+	for _, c := range counts {
+		peak = max(peak, c.Count, c.Previous)
+	}
+	plot := float64(chartWidth - labelWidth - 50)
+	height := len(counts)*(barHeight+barGap) + barGap
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12" role="img" aria-label="Events by type">`,
+		chartWidth, height, chartWidth, height)
+	for i, c := range counts {
+		y := barGap + i*(barHeight+barGap)
+		cur := int(float64(c.Count) / float64(peak) * plot)
+		prev := int(float64(c.Previous) / float64(peak) * plot)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, labelWidth-8, y+13, escape(c.Type))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, labelWidth, y, cur, barHeight-6, barColor)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="4" fill="%s"/>`, labelWidth, y+barHeight-5, prev, prevColor)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%d</text>`, labelWidth+cur+6, y+11, c.Count)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// dailyChart draws one column per day.
+func dailyChart(days []Day) string {
+	if len(days) == 0 {
+		return ""
+	}
+	const height, top, bottom = 160, 16, 20
+	peak := 1
+	for _, d := range days {
+		peak = max(peak, d.Failures)
+	}
+	slot := float64(chartWidth) / float64(len(days))
+	width := max(slot*0.7, 1)
+	plot := float64(height - top - bottom)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="10" role="img" aria-label="Failed logins per day">`,
+		chartWidth, height, chartWidth, height)
+	// Label at most ~10 days to keep the axis readable.
+	every := max(len(days)/10, 1)
+	for i, d := range days {
+		h := float64(d.Failures) / float64(peak) * plot
+		x := float64(i)*slot + (slot-width)/2
+		y := float64(height-bottom) - h
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %d</title></rect>`,
+			x, y, width, h, barColor, escape(d.Date), d.Failures)
+		if d.Failures > 0 && slot >= 24 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%d</text>`, x+width/2, y-3, d.Failures)
+		}
+		if i%every == 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x+width/2, height-6, escape(d.Date[5:]))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+func escape(s string) string {
+	return htmltemplate.HTMLEscapeString(s)
+}
@@ -0,0 +1,254 @@
+// Package report builds a periodic security digest from the ops API and
+// renders it as Markdown or HTML with inline SVG charts.
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Ops is the subset of api.Client operations a report reads.
+type Ops interface {
+	ListAllEvents(ctx context.Context, params api.EventsParams) ([]api.Event, error)
+	ListAllSessions(ctx context.Context, params api.SessionsParams) ([]api.Session, error)
+	GetEventStats(ctx context.Context, since string) (*api.EventStatsResponse, error)
+}
+
+// topN is the number of rows kept in ranked tables.
+const topN = 10
+
+// TypeCount is one event type's count for the period and the one before it.
+type TypeCount struct {
+	Type     string
+	Count    int
+	Previous int
+}
+
+// Trend formats the change against the previous period.
+func (c TypeCount) Trend() string {
+	return trend(c.Count, c.Previous)
+}
+
+// Ranked is a key with an occurrence count.
+type Ranked struct {
+	Key   string
+	Count int
+}
+
+// AgentChange is an agent provisioned or revoked during the period.
+type AgentChange struct {
+	Name       string
+	TrustLevel string
+	At         string
+}
+
+// Day is one bucket of the daily series.
+type Day struct {
+	Date     string
+	Failures int
+}
+
+// Report is the data behind a digest.
+type Report struct {
+	Target    string
+	Generated time.Time
+	Since     time.Time
+	Until     time.Time
+	PrevSince time.Time
+
+	Counts    []TypeCount
+	Total     TypeCount
+	TopIPs    []Ranked
+	TopUsers  []Ranked
+	Daily     []Day
+	Logins    TypeCount
+	Failures  TypeCount
+	Challenge struct {
+		Issued TypeCount
+		Failed TypeCount
+	}
+	ActiveSessions    int
+	AgentsProvisioned []AgentChange
+	AgentsRevoked     []AgentChange
+}
+
+// Builder collects report data.
+type Builder struct {
+	Ops    Ops
+	Target string
+
+	now func() time.Time
+}
+
+// Build assembles a report for the period starting at since (a timestamp or
+// a duration such as 7d) and compares it to the equally long period before.
+func (b *Builder) Build(ctx context.Context, since string) (*Report, error) {
+	if b.now == nil {
+		b.now = time.Now
+	}
+	now := b.now().UTC().Truncate(time.Second)
+	sinceStr, err := api.ParseSince(since, now)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := time.Parse(time.RFC3339, sinceStr)
+	prevStart := start.Add(-now.Sub(start))
+
+	r := &Report{Target: b.Target, Generated: now, Since: start, Until: now, PrevSince: prevStart}
+
+	cur, err := b.Ops.GetEventStats(ctx, sinceStr)
+	if err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	// Stats are cumulative from since, so the previous period is the
+	// difference between the two windows.
+	both, err := b.Ops.GetEventStats(ctx, prevStart.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	counts := make(map[string]*TypeCount)
+	for t, n := range cur.Stats {
+		counts[t] = &TypeCount{Type: t, Count: n}
+	}
+	for t, n := range both.Stats {
+		c, ok := counts[t]
+		if !ok {
+			c = &TypeCount{Type: t}
+			counts[t] = c
+		}
+		c.Previous = max(n-c.Count, 0)
+	}
+	r.Total.Type = "total"
+	for _, c := range counts {
+		r.Counts = append(r.Counts, *c)
+		r.Total.Count += c.Count
+		r.Total.Previous += c.Previous
+	}
+	sort.Slice(r.Counts, func(i, j int) bool {
+		if r.Counts[i].Count != r.Counts[j].Count {
+			return r.Counts[i].Count > r.Counts[j].Count
+		}
+		return r.Counts[i].Type < r.Counts[j].Type
+	})
+	lookup := func(t string) TypeCount {
+		if c, ok := counts[t]; ok {
+			return *c
+		}
+		return TypeCount{Type: t}
+	}
+	r.Logins = lookup("login.success")
+	r.Failures = lookup("login.failure")
+	r.Challenge.Issued = lookup("challenge.issued")
+	r.Challenge.Failed = lookup("challenge.failed")
+
+	failures, err := b.Ops.ListAllEvents(ctx, api.EventsParams{Type: "login.failure", Since: sinceStr})
+	if err != nil {
+		return nil, fmt.Errorf("list failures: %w", err)
+	}
+	r.TopIPs, r.TopUsers = rankFailures(failures)
+	r.Daily = daily(failures, start, now)
+
+	for _, a := range []struct {
+		typ string
+		out *[]AgentChange
+	}{
+		{"agent.provisioned", &r.AgentsProvisioned},
+		{"agent.revoked", &r.AgentsRevoked},
+	} {
+		events, err := b.Ops.ListAllEvents(ctx, api.EventsParams{Type: a.typ, Since: sinceStr})
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", a.typ, err)
+		}
+		*a.out = agentChanges(events)
+	}
+
+	sessions, err := b.Ops.ListAllSessions(ctx, api.SessionsParams{})
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	r.ActiveSessions = len(sessions)
+	return r, nil
+}
+
+func rankFailures(events []api.Event) (ips, users []Ranked) {
+	byIP := make(map[string]int)
+	byUser := make(map[string]int)
+	for _, e := range events {
+		if e.IPAddress != "" {
+			byIP[e.IPAddress]++
+		}
+		if e.UserID != nil {
+			byUser[strconv.Itoa(*e.UserID)]++
+		}
+	}
+	return rank(byIP), rank(byUser)
+}
+
+func rank(m map[string]int) []Ranked {
+	out := make([]Ranked, 0, len(m))
+	for k, n := range m {
+		out = append(out, Ranked{Key: k, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	if len(out) > topN {
+		out = out[:topN]
+	}
+	return out
+}
+
+func daily(events []api.Event, start, end time.Time) []Day {
+	var days []Day
+	index := make(map[string]int)
+	for d := start.Truncate(24 * time.Hour); d.Before(end); d = d.Add(24 * time.Hour) {
+		key := d.Format(time.DateOnly)
+		index[key] = len(days)
+		days = append(days, Day{Date: key})
+	}
+	for _, e := range events {
+		t, err := api.ParseTimestamp(e.CreatedAt)
+		if err != nil {
+			continue
+		}
+		if i, ok := index[t.UTC().Format(time.DateOnly)]; ok {
+			days[i].Failures++
+		}
+	}
+	return days
+}
+
+func agentChanges(events []api.Event) []AgentChange {
+	out := make([]AgentChange, 0, len(events))
+	for _, e := range events {
+		var detail struct {
+			Name       string `json:"name"`
+			TrustLevel string `json:"trustLevel"`
+		}
+		if e.Detail != nil {
+			json.Unmarshal([]byte(*e.Detail), &detail)
+		}
+		out = append(out, AgentChange{Name: detail.Name, TrustLevel: detail.TrustLevel, At: e.CreatedAt})
+	}
+	return out
+}
+
+func trend(cur, prev int) string {
+	switch {
+	case cur == 0 && prev == 0:
+		return "–"
+	case prev == 0:
+		return "new"
+	}
+	pct := float64(cur-prev) / float64(prev) * 100
+	return fmt.Sprintf("%+.0f%%", pct)
+}
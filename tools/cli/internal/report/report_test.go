@@ -0,0 +1,134 @@
+package report
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+type fakeOps struct{}
+
+func (fakeOps) GetEventStats(_ context.Context, since string) (*api.EventStatsResponse, error) {
+	if since == "2026-03-01T12:00:00Z" {
+		return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 4, "login.success": 10, "challenge.issued": 2}}, nil
+	}
+	// Both periods together.
+	return &api.EventStatsResponse{Stats: map[string]int{"login.failure": 6, "login.success": 30, "agent.revoked": 1}}, nil
+}
+
+func (fakeOps) ListAllEvents(_ context.Context, p api.EventsParams) ([]api.Event, error) {
+	uid := 42
+	detail := `{"name":"ci <bot>","trustLevel":"write"}`
+	switch p.Type {
+	case "login.failure":
+		return []api.Event{
+			{Type: "login.failure", IPAddress: "203.0.113.9", UserID: &uid, CreatedAt: "2026-03-08 09:00:00"},
+			{Type: "login.failure", IPAddress: "203.0.113.9", CreatedAt: "2026-03-08 08:00:00"},
+			{Type: "login.failure", IPAddress: "198.51.100.1", UserID: &uid, CreatedAt: "2026-03-06 08:00:00"},
+			{Type: "login.failure", IPAddress: "192.0.2.1", CreatedAt: "2026-03-02 08:00:00"},
+		}, nil
+	case "agent.provisioned":
+		return []api.Event{{Type: p.Type, Detail: &detail, CreatedAt: "2026-03-05 10:00:00"}}, nil
+	}
+	return nil, nil
+}
+
+func (fakeOps) ListAllSessions(context.Context, api.SessionsParams) ([]api.Session, error) {
+	return []api.Session{{ID: "a"}, {ID: "b"}}, nil
+}
+
+func build(t *testing.T) *Report {
+	t.Helper()
+	b := &Builder{
+		Ops:    fakeOps{},
+		Target: "https://staging.example.com",
+		now:    func() time.Time { return time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC) },
+	}
+	r, err := b.Build(context.Background(), "7d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestBuild(t *testing.T) {
+	r := build(t)
+
+	if got := r.PrevSince.Format(time.RFC3339); got != "2026-02-22T12:00:00Z" {
+		t.Errorf("PrevSince = %s", got)
+	}
+	if r.Failures.Count != 4 || r.Failures.Previous != 2 {
+		t.Errorf("Failures = %+v, want 4 vs 2", r.Failures)
+	}
+	if r.Logins.Count != 10 || r.Logins.Previous != 20 {
+		t.Errorf("Logins = %+v, want 10 vs 20", r.Logins)
+	}
+	if r.Total.Count != 16 || r.Total.Previous != 23 {
+		t.Errorf("Total = %+v, want 16 vs 23", r.Total)
+	}
+	if r.Counts[0].Type != "login.success" {
+		t.Errorf("Counts not sorted by count: %+v", r.Counts)
+	}
+	if len(r.TopIPs) != 3 || r.TopIPs[0] != (Ranked{"203.0.113.9", 2}) {
+		t.Errorf("TopIPs = %+v", r.TopIPs)
+	}
+	if len(r.TopUsers) != 1 || r.TopUsers[0] != (Ranked{"42", 2}) {
+		t.Errorf("TopUsers = %+v", r.TopUsers)
+	}
+	if len(r.Daily) != 8 || r.Daily[7].Failures != 2 || r.Daily[1].Failures != 1 {
+		t.Errorf("Daily = %+v", r.Daily)
+	}
+	if len(r.AgentsProvisioned) != 1 || r.AgentsProvisioned[0].TrustLevel != "write" {
+		t.Errorf("AgentsProvisioned = %+v", r.AgentsProvisioned)
+	}
+	if r.ActiveSessions != 2 {
+		t.Errorf("ActiveSessions = %d", r.ActiveSessions)
+	}
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		cur, prev int
+		want      string
+	}{
+		{0, 0, "–"},
+		{3, 0, "new"},
+		{4, 2, "+100%"},
+		{10, 20, "-50%"},
+		{5, 5, "+0%"},
+	}
+	for _, tt := range tests {
+		if got := trend(tt.cur, tt.prev); got != tt.want {
+			t.Errorf("trend(%d, %d) = %q, want %q", tt.cur, tt.prev, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := build(t)
+
+	for _, format := range []string{"markdown", "html"} {
+		t.Run(format, func(t *testing.T) {
+			var b strings.Builder
+			if err := Render(&b, r, format); err != nil {
+				t.Fatal(err)
+			}
+			out := b.String()
+			for _, want := range []string{"2026-03-01 – 2026-03-08", "<svg", "203.0.113.9", "-50%"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q", want)
+				}
+			}
+			if strings.Contains(out, "ci <bot>") {
+				t.Error("agent name not escaped")
+			}
+		})
+	}
+
+	if err := Render(&strings.Builder{}, r, "pdf"); err == nil {
+		t.Error("Render(pdf) succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Action is a response taken when a rule fires.
+type Action struct {
+	// Type is one of webhook, file, command or revoke.
+	Type string `yaml:"type"`
+	// URL receives the alert as a JSON POST (webhook).
+	URL string `yaml:"url"`
+	// Path is appended with the alert as one JSON line (file).
+	Path string `yaml:"path"`
+	// Command is executed with the alert JSON on stdin (command).
+	Command []string `yaml:"command"`
+	// Scope selects user or session revocation (revoke).
+	Scope string `yaml:"scope"`
+	// Cooldown suppresses repeats for the same rule and group.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// DryRun logs the action instead of performing it.
+	DryRun bool `yaml:"dry_run"`
+}
+
+// ErrNoRevoker is returned by revoke actions when no write agent is configured.
+var ErrNoRevoker = errors.New("revoke action requires a write agent")
+
+// Revoker revokes sessions. *api.Client satisfies it.
+type Revoker interface {
+	RevokeSessions(ctx context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error)
+}
+
+// Dispatcher runs rule actions, applying cooldowns and dry-run.
+type Dispatcher struct {
+	// Revoker performs revoke actions. Nil disables them.
+	Revoker Revoker
+	// DryRun logs every action instead of performing it.
+	DryRun bool
+	// Log receives one line per action outcome.
+	Log io.Writer
+
+	http *http.Client
+	now  func() time.Time
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewDispatcher creates a dispatcher. Pass a nil revoker when the configured
+// agent lacks write trust.
+func NewDispatcher(revoker Revoker, dryRun bool, log io.Writer) *Dispatcher {
+	return &Dispatcher{
+		Revoker: revoker,
+		DryRun:  dryRun,
+		Log:     log,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		last:    make(map[string]time.Time),
+	}
+}
+
+// Dispatch runs every action of rule for the alert and joins their errors.
+func (d *Dispatcher) Dispatch(ctx context.Context, rule Rule, alert Alert) error {
+	var errs []error
+	for i, a := range rule.Actions {
+		key := fmt.Sprintf("%s\x00%d\x00%s", rule.Name, i, alert.GroupKey())
+		at := alert.FiredAt
+		if at.IsZero() {
+			at = d.now()
+		}
+		if d.cooling(key, a.Cooldown, at) {
+			d.logf(rule, alert, a, "suppressed (cooldown)")
+			continue
+		}
+		if d.DryRun || a.DryRun {
+			d.logf(rule, alert, a, "dry-run")
+			continue
+		}
+		if err := d.run(ctx, a, alert); err != nil {
+			d.logf(rule, alert, a, "failed: "+err.Error())
+			errs = append(errs, fmt.Errorf("%s/%s: %w", rule.Name, a.Type, err))
+			continue
+		}
+		d.stamp(key, at)
+		d.logf(rule, alert, a, "ok")
+	}
+	return errors.Join(errs...)
+}
+
+// cooling reports whether key last ran less than cooldown before at.
+// Cooldowns are measured in alert time so replays suppress the same repeats
+// as the live stream.
+func (d *Dispatcher) cooling(key string, cooldown time.Duration, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.last[key]
+	return ok && cooldown > 0 && at.Sub(last) < cooldown
+}
+
+// stamp starts key's cooldown. Only actions that ran and succeeded stamp
+// it, so a failure or a dry run never holds back the next real attempt.
+func (d *Dispatcher) stamp(key string, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last[key] = at
+}
+
+func (d *Dispatcher) logf(rule Rule, alert Alert, a Action, outcome string) {
+	if d.Log == nil {
+		return
+	}
+	group := alert.GroupKey()
+	if group == "" {
+		group = "-"
+	}
+	fmt.Fprintf(d.Log, "%s rule=%s group=%s count=%d action=%s %s\n",
+		alert.FiredAt.UTC().Format(time.RFC3339), rule.Name, group, alert.Count, a.Type, outcome)
+}
+
+func (d *Dispatcher) run(ctx context.Context, a Action, alert Alert) error {
+	switch a.Type {
+	case "webhook":
+		return d.webhook(ctx, a.URL, alert)
+	case "file":
+		return appendJSONLine(a.Path, alert)
+	case "command":
+		return runCommand(ctx, a.Command, alert)
+	case "revoke":
+		return d.revoke(ctx, a.Scope, alert)
+	}
+	return fmt.Errorf("unknown action type %q", a.Type)
+}
+
+func (d *Dispatcher) webhook(ctx context.Context, url string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func appendJSONLine(path string, alert Alert) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(alert)
+}
+
+func runCommand(ctx context.Context, argv []string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"PLCTL_RULE="+alert.Rule,
+		"PLCTL_GROUP="+alert.GroupKey(),
+		"PLCTL_COUNT="+strconv.Itoa(alert.Count),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (d *Dispatcher) revoke(ctx context.Context, scope string, alert Alert) error {
+	if d.Revoker == nil {
+		return ErrNoRevoker
+	}
+	target, err := revokeTarget(scope, alert)
+	if err != nil {
+		return err
+	}
+	_, err = d.Revoker.RevokeSessions(ctx, api.RevokeSessionsRequest{Scope: scope, ID: target})
+	return err
+}
+
+// revokeTarget picks the user ID or session ID to revoke from the most
+// recent event in the alert that carries one.
+func revokeTarget(scope string, alert Alert) (interface{}, error) {
+	for i := len(alert.Events) - 1; i >= 0; i-- {
+		e := alert.Events[i]
+		switch scope {
+		case "user":
+			if e.UserID != nil {
+				return *e.UserID, nil
+			}
+		case "session":
+			if sid := flattenDetail(e)["sessionId"]; sid != "" {
+				return sid, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s id in alert events", scope)
+}
@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+type fakeRevoker struct {
+	reqs []api.RevokeSessionsRequest
+}
+
+func (f *fakeRevoker) RevokeSessions(_ context.Context, req api.RevokeSessionsRequest) (*api.RevokeSessionsResponse, error) {
+	f.reqs = append(f.reqs, req)
+	return &api.RevokeSessionsResponse{Success: true, Revoked: 1}, nil
+}
+
+func testAlert(at time.Time) Alert {
+	uid := 42
+	detail := `{"sessionId":"sess-abc"}`
+	return Alert{
+		Rule:    "r",
+		Group:   map[string]string{"user": "42"},
+		Count:   1,
+		FiredAt: at,
+		Events:  []api.Event{{Type: "login.success", UserID: &uid, Detail: &detail}},
+	}
+}
+
+func TestDispatchWebhook(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, false, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "webhook", URL: srv.URL}}}
+	if err := d.Dispatch(context.Background(), rule, testAlert(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Rule != "r" || got.Group["user"] != "42" {
+		t.Fatalf("unexpected webhook body: %+v", got)
+	}
+}
+
+func TestDispatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.ndjson")
+	d := NewDispatcher(nil, false, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "file", Path: path}}}
+	now := time.Now()
+	d.Dispatch(context.Background(), rule, testAlert(now))
+	d.Dispatch(context.Background(), rule, testAlert(now.Add(time.Second)))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestDispatchCooldown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.ndjson")
+	var log bytes.Buffer
+	d := NewDispatcher(nil, false, &log)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "file", Path: path, Cooldown: time.Minute}}}
+	now := time.Now()
+
+	d.Dispatch(context.Background(), rule, testAlert(now))
+	d.Dispatch(context.Background(), rule, testAlert(now.Add(30*time.Second)))
+	d.Dispatch(context.Background(), rule, testAlert(now.Add(2*time.Minute)))
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("expected cooldown to suppress 1 of 3, got %d lines", lines)
+	}
+	if !strings.Contains(log.String(), "suppressed (cooldown)") {
+		t.Fatalf("expected cooldown in log, got %q", log.String())
+	}
+}
+
+func TestDispatchCooldownOnlyAfterSuccess(t *testing.T) {
+	hits, fail := 0, true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	d := NewDispatcher(nil, true, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "webhook", URL: srv.URL, Cooldown: time.Hour}}}
+	now := time.Now()
+
+	// Neither a dry run nor a failure starts the cooldown.
+	d.Dispatch(context.Background(), rule, testAlert(now))
+	d.DryRun = false
+	if err := d.Dispatch(context.Background(), rule, testAlert(now.Add(time.Second))); err == nil {
+		t.Fatal("expected the webhook failure")
+	}
+	fail = false
+	if err := d.Dispatch(context.Background(), rule, testAlert(now.Add(2*time.Second))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.Dispatch(context.Background(), rule, testAlert(now.Add(3*time.Second)))
+	if hits != 2 {
+		t.Fatalf("webhook called %d times, want the failure, the retry and then cooldown", hits)
+	}
+}
+
+func TestDispatchDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.ndjson")
+	var log bytes.Buffer
+	rev := &fakeRevoker{}
+	d := NewDispatcher(rev, true, &log)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "file", Path: path}, {Type: "revoke", Scope: "user"}}}
+
+	if err := d.Dispatch(context.Background(), rule, testAlert(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected dry-run not to write file")
+	}
+	if len(rev.reqs) != 0 {
+		t.Fatal("expected dry-run not to revoke")
+	}
+	if strings.Count(log.String(), "dry-run") != 2 {
+		t.Fatalf("expected 2 dry-run log lines, got %q", log.String())
+	}
+}
+
+func TestDispatchRevoke(t *testing.T) {
+	rev := &fakeRevoker{}
+	d := NewDispatcher(rev, false, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "revoke", Scope: "user"}, {Type: "revoke", Scope: "session"}}}
+
+	if err := d.Dispatch(context.Background(), rule, testAlert(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rev.reqs) != 2 {
+		t.Fatalf("expected 2 revocations, got %d", len(rev.reqs))
+	}
+	if rev.reqs[0].Scope != "user" || rev.reqs[0].ID != 42 {
+		t.Fatalf("unexpected user revoke: %+v", rev.reqs[0])
+	}
+	if rev.reqs[1].Scope != "session" || rev.reqs[1].ID != "sess-abc" {
+		t.Fatalf("unexpected session revoke: %+v", rev.reqs[1])
+	}
+}
+
+func TestDispatchRevokeWithoutWriteAgent(t *testing.T) {
+	d := NewDispatcher(nil, false, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "revoke", Scope: "user"}}}
+	err := d.Dispatch(context.Background(), rule, testAlert(time.Now()))
+	if !errors.Is(err, ErrNoRevoker) {
+		t.Fatalf("expected ErrNoRevoker, got %v", err)
+	}
+}
+
+func TestDispatchCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	d := NewDispatcher(nil, false, nil)
+	rule := Rule{Name: "r", Actions: []Action{{Type: "command", Command: []string{"sh", "-c", "cat > " + out}}}}
+	if err := d.Dispatch(context.Background(), rule, testAlert(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), `"rule":"r"`) {
+		t.Fatalf("expected alert JSON on stdin, got %q", data)
+	}
+}
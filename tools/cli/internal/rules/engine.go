@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Alert is emitted when a rule's threshold is reached for a group.
+type Alert struct {
+	Rule    string            `json:"rule"`
+	Group   map[string]string `json:"group,omitempty"`
+	Count   int               `json:"count"`
+	Window  string            `json:"window,omitempty"`
+	FiredAt time.Time         `json:"fired_at"`
+	Events  []api.Event       `json:"events"`
+}
+
+// GroupKey renders the alert group as a stable "field=value" string.
+func (a Alert) GroupKey() string {
+	keys := make([]string, 0, len(a.Group))
+	for k := range a.Group {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + a.Group[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+type bucketKey struct {
+	rule  int
+	group string
+}
+
+type sample struct {
+	at    time.Time
+	event api.Event
+}
+
+// sweepEvery controls how often stale buckets are pruned.
+const sweepEvery = 256
+
+// Engine evaluates events against a rule set using per-group sliding windows.
+// Event time is taken from CreatedAt so replays behave like the live stream.
+// An Engine is not safe for concurrent use.
+type Engine struct {
+	rules   []Rule
+	buckets map[bucketKey][]sample
+	evals   int
+	now     func() time.Time
+}
+
+// NewEngine creates an engine for the given rules.
+func NewEngine(rules []Rule) *Engine {
+	return &Engine{
+		rules:   rules,
+		buckets: make(map[bucketKey][]sample),
+		now:     time.Now,
+	}
+}
+
+// Rules returns the engine's rule set.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Evaluate feeds one event through every rule and returns the alerts it fires.
+// A group's window is cleared after it fires, so the next alert needs a fresh
+// Threshold events.
+func (e *Engine) Evaluate(ev api.Event) []Alert {
+	at, err := api.ParseTimestamp(ev.CreatedAt)
+	if err != nil {
+		at = e.now()
+	}
+	detail := flattenDetail(ev)
+
+	var alerts []Alert
+	for i, r := range e.rules {
+		if !r.Match.matches(ev, detail) {
+			continue
+		}
+
+		group := make(map[string]string, len(r.GroupBy))
+		parts := make([]string, len(r.GroupBy))
+		for j, f := range r.GroupBy {
+			v := fieldValue(ev, detail, f)
+			group[f] = v
+			parts[j] = v
+		}
+		key := bucketKey{rule: i, group: strings.Join(parts, "\x00")}
+
+		samples := prune(e.buckets[key], at, r.Window)
+		samples = append(samples, sample{at: at, event: ev})
+		if len(samples) < r.Threshold {
+			e.buckets[key] = samples
+			continue
+		}
+		delete(e.buckets, key)
+
+		events := make([]api.Event, len(samples))
+		for j, s := range samples {
+			events[j] = s.event
+		}
+		a := Alert{
+			Rule:    r.Name,
+			Count:   len(samples),
+			FiredAt: at,
+			Events:  events,
+		}
+		if len(group) > 0 {
+			a.Group = group
+		}
+		if r.Window > 0 {
+			a.Window = r.Window.String()
+		}
+		alerts = append(alerts, a)
+	}
+
+	e.evals++
+	if e.evals%sweepEvery == 0 {
+		e.sweep(at)
+	}
+	return alerts
+}
+
+// prune drops samples that fall outside the window ending at now.
+func prune(samples []sample, now time.Time, window time.Duration) []sample {
+	if window <= 0 {
+		return samples
+	}
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(samples) && !samples[i].at.After(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
+
+func (e *Engine) sweep(now time.Time) {
+	for k, samples := range e.buckets {
+		samples = prune(samples, now, e.rules[k.rule].Window)
+		if len(samples) == 0 {
+			delete(e.buckets, k)
+		} else {
+			e.buckets[k] = samples
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func event(typ, ip string, at time.Time) api.Event {
+	return api.Event{Type: typ, IPAddress: ip, CreatedAt: at.UTC().Format(time.RFC3339)}
+}
+
+func TestEngineThresholdWithinWindow(t *testing.T) {
+	e := NewEngine([]Rule{{
+		Name:      "stuffing",
+		Match:     Match{Type: StringList{"login.failure"}},
+		Threshold: 3,
+		Window:    time.Minute,
+		GroupBy:   []string{"ip"},
+	}})
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := e.Evaluate(event("login.failure", "1.1.1.1", base)); len(got) != 0 {
+		t.Fatalf("expected no alert after 1 event, got %d", len(got))
+	}
+	if got := e.Evaluate(event("login.failure", "2.2.2.2", base.Add(time.Second))); len(got) != 0 {
+		t.Fatalf("expected other group not to count, got %d", len(got))
+	}
+	if got := e.Evaluate(event("login.failure", "1.1.1.1", base.Add(10*time.Second))); len(got) != 0 {
+		t.Fatalf("expected no alert after 2 events, got %d", len(got))
+	}
+	got := e.Evaluate(event("login.failure", "1.1.1.1", base.Add(20*time.Second)))
+	if len(got) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(got))
+	}
+	a := got[0]
+	if a.Count != 3 || a.Group["ip"] != "1.1.1.1" || len(a.Events) != 3 {
+		t.Fatalf("unexpected alert: %+v", a)
+	}
+	if a.GroupKey() != "ip=1.1.1.1" {
+		t.Fatalf("expected group key ip=1.1.1.1, got %q", a.GroupKey())
+	}
+
+	// Window resets after firing
+	if got := e.Evaluate(event("login.failure", "1.1.1.1", base.Add(21*time.Second))); len(got) != 0 {
+		t.Fatalf("expected window reset after alert, got %d", len(got))
+	}
+}
+
+func TestEngineWindowExpiry(t *testing.T) {
+	e := NewEngine([]Rule{{
+		Name:      "burst",
+		Match:     Match{Type: StringList{"login.failure"}},
+		Threshold: 2,
+		Window:    time.Minute,
+	}})
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	e.Evaluate(event("login.failure", "1.1.1.1", base))
+	if got := e.Evaluate(event("login.failure", "1.1.1.1", base.Add(2*time.Minute))); len(got) != 0 {
+		t.Fatalf("expected expired sample not to count, got %d alerts", len(got))
+	}
+	if got := e.Evaluate(event("login.failure", "1.1.1.1", base.Add(150*time.Second))); len(got) != 1 {
+		t.Fatalf("expected alert within window, got %d", len(got))
+	}
+}
+
+func TestEngineSingleEventRule(t *testing.T) {
+	e := NewEngine([]Rule{{Name: "revoked", Match: Match{Type: StringList{"agent.revoked"}}, Threshold: 1}})
+	if got := e.Evaluate(event("agent.revoked", "1.1.1.1", time.Now())); len(got) != 1 {
+		t.Fatalf("expected immediate alert, got %d", len(got))
+	}
+	if got := e.Evaluate(event("login.success", "1.1.1.1", time.Now())); len(got) != 0 {
+		t.Fatalf("expected no alert for unmatched type, got %d", len(got))
+	}
+}
+
+func TestEngineGroupByDetail(t *testing.T) {
+	e := NewEngine([]Rule{{
+		Name:      "domain",
+		Threshold: 2,
+		Window:    time.Hour,
+		GroupBy:   []string{"detail.email"},
+	}})
+	corp := `{"email":"*@corp.com"}`
+	other := `{"email":"*@other.com"}`
+	now := time.Now()
+
+	e.Evaluate(api.Event{Type: "login.failure", Detail: &corp, CreatedAt: now.Format(time.RFC3339)})
+	if got := e.Evaluate(api.Event{Type: "login.failure", Detail: &other, CreatedAt: now.Format(time.RFC3339)}); len(got) != 0 {
+		t.Fatalf("expected separate groups, got %d alerts", len(got))
+	}
+	got := e.Evaluate(api.Event{Type: "login.failure", Detail: &corp, CreatedAt: now.Format(time.RFC3339)})
+	if len(got) != 1 || got[0].Group["detail.email"] != "*@corp.com" {
+		t.Fatalf("expected alert for corp group, got %+v", got)
+	}
+}
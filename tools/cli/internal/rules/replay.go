@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Replay feeds newline-delimited recorded events through the engine and
+// returns every alert in order. When d is non-nil each alert is dispatched
+// as well, so actions and cooldowns can be exercised offline. A failed
+// action does not stop the replay; failures are returned together at the
+// end.
+func Replay(ctx context.Context, r io.Reader, engine *Engine, d *Dispatcher) ([]Alert, error) {
+	rulesByName := make(map[string]Rule, len(engine.rules))
+	for _, rule := range engine.rules {
+		rulesByName[rule.Name] = rule
+	}
+
+	var alerts []Alert
+	var errs []error
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		ev, err := api.DecodeEvent(data)
+		if err != nil {
+			return alerts, errors.Join(append(errs, fmt.Errorf("line %d: %w", line, err))...)
+		}
+		for _, a := range engine.Evaluate(ev) {
+			alerts = append(alerts, a)
+			if d != nil {
+				if err := d.Dispatch(ctx, rulesByName[a.Rule], a); err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("read events: %w", err))
+	}
+	return alerts, errors.Join(errs...)
+}
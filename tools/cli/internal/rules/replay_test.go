@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	f, err := Parse([]byte(`
+rules:
+  - name: stuffing
+    match: {type: login.failure}
+    threshold: 3
+    window: 1m
+    group_by: [ip]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Mixed shapes: REST rows and ws payloads
+	input := strings.Join([]string{
+		`{"id":1,"type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:00Z","actor_id":"app:private-landing"}`,
+		`{"event_id":2,"event_type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:10Z","detail":{"email":"*@corp.com"}}`,
+		``,
+		`{"type":"event","payload":{"event_id":3,"event_type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:00:20Z"}}`,
+		`{"id":4,"type":"login.failure","ip_address":"1.1.1.1","created_at":"2026-03-01T12:05:00Z"}`,
+	}, "\n")
+
+	alerts, err := Replay(context.Background(), strings.NewReader(input), NewEngine(f.Rules), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].Events[2].ID != 3 {
+		t.Fatalf("expected third event id 3, got %d", alerts[0].Events[2].ID)
+	}
+}
+
+func TestReplayReportsLine(t *testing.T) {
+	_, err := Replay(context.Background(), strings.NewReader("{}\nnot json\n"), NewEngine(nil), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line 2 error, got %v", err)
+	}
+}
+
+func TestReplayReturnsActionErrors(t *testing.T) {
+	f, err := Parse([]byte(`
+rules:
+  - name: any
+    match: {type: login.failure}
+    actions:
+      - {type: revoke, scope: user}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	input := `{"id":1,"type":"login.failure","ip_address":"1.1.1.1","user_id":4,"created_at":"2026-03-01T12:00:00Z"}` + "\n" +
+		`{"id":2,"type":"login.failure","ip_address":"1.1.1.1","user_id":4,"created_at":"2026-03-01T12:00:10Z"}`
+
+	alerts, err := Replay(context.Background(), strings.NewReader(input), NewEngine(f.Rules), NewDispatcher(nil, false, nil))
+	if len(alerts) != 2 {
+		t.Fatalf("expected the replay to go on after a failure, got %d alerts", len(alerts))
+	}
+	if !errors.Is(err, ErrNoRevoker) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected both revoke failures, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+// Package rules evaluates declarative alert rules against security events
+// and dispatches response actions when a rule's threshold is crossed.
+//
+// A rules file looks like:
+//
+//	rules:
+//	  - name: credential-stuffing
+//	    match:
+//	      type: login.failure
+//	      detail:
+//	        email: "*@corp.com"
+//	    threshold: 20
+//	    window: 5m
+//	    group_by: [ip]
+//	    actions:
+//	      - type: webhook
+//	        url: https://hooks.example.com/alerts
+//	        cooldown: 15m
+//	      - type: revoke
+//	        scope: user
+//	        dry_run: true
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the top-level structure of a rules file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule fires its actions when Threshold matching events arrive within Window
+// for the same group.
+type Rule struct {
+	Name      string        `yaml:"name"`
+	Match     Match         `yaml:"match"`
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	GroupBy   []string      `yaml:"group_by"`
+	Actions   []Action      `yaml:"actions"`
+}
+
+// Match selects events. Every non-empty field must match; within a field any
+// listed value may match.
+type Match struct {
+	// Type holds subscribe_events patterns (login.failure, login.*).
+	Type StringList `yaml:"type"`
+	// User holds user IDs.
+	User StringList `yaml:"user"`
+	// IP holds addresses or CIDR prefixes.
+	IP StringList `yaml:"ip"`
+	// Actor holds actor IDs, with * globs (agent:*).
+	Actor StringList `yaml:"actor"`
+	// Detail maps detail keys to * glob values.
+	Detail map[string]string `yaml:"detail"`
+}
+
+// StringList decodes from either a YAML scalar or a sequence of scalars.
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*l = StringList{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		out := make(StringList, 0, len(node.Content))
+		for _, n := range node.Content {
+			if n.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: expected scalar", n.Line)
+			}
+			out = append(out, n.Value)
+		}
+		*l = out
+		return nil
+	}
+	return fmt.Errorf("line %d: expected scalar or list", node.Line)
+}
+
+// Load reads and validates a rules file.
+func Load(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates rules from YAML.
+func Parse(data []byte) (*File, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+var groupFields = map[string]bool{"type": true, "user": true, "ip": true, "actor": true}
+
+var actionTypes = map[string]bool{"webhook": true, "file": true, "command": true, "revoke": true}
+
+func (f *File) validate() error {
+	seen := make(map[string]bool)
+	for i := range f.Rules {
+		r := &f.Rules[i]
+		if r.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("rule %q: duplicate name", r.Name)
+		}
+		seen[r.Name] = true
+
+		if r.Threshold == 0 {
+			r.Threshold = 1
+		}
+		if r.Threshold < 0 {
+			return fmt.Errorf("rule %q: threshold must be positive", r.Name)
+		}
+		if r.Threshold > 1 && r.Window <= 0 {
+			return fmt.Errorf("rule %q: window is required when threshold > 1", r.Name)
+		}
+		for _, g := range r.GroupBy {
+			if !groupFields[g] && !strings.HasPrefix(g, "detail.") {
+				return fmt.Errorf("rule %q: unknown group_by field %q", r.Name, g)
+			}
+		}
+		for _, ip := range r.Match.IP {
+			if _, err := parseIPMatcher(ip); err != nil {
+				return fmt.Errorf("rule %q: %w", r.Name, err)
+			}
+		}
+		for j, a := range r.Actions {
+			if err := a.validate(); err != nil {
+				return fmt.Errorf("rule %q action %d: %w", r.Name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (a Action) validate() error {
+	if !actionTypes[a.Type] {
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	switch a.Type {
+	case "webhook":
+		if a.URL == "" {
+			return fmt.Errorf("webhook requires url")
+		}
+	case "file":
+		if a.Path == "" {
+			return fmt.Errorf("file requires path")
+		}
+	case "command":
+		if len(a.Command) == 0 {
+			return fmt.Errorf("command requires command")
+		}
+	case "revoke":
+		if a.Scope != "user" && a.Scope != "session" {
+			return fmt.Errorf("revoke scope must be user or session")
+		}
+	}
+	return nil
+}
+
+// matches reports whether the event satisfies every populated match field.
+func (m Match) matches(e api.Event, detail map[string]string) bool {
+	if len(m.Type) > 0 && !anyMatch(m.Type, func(p string) bool { return api.MatchEventType(p, e.Type) }) {
+		return false
+	}
+	if len(m.User) > 0 && !anyMatch(m.User, func(p string) bool { return p == fieldValue(e, detail, "user") }) {
+		return false
+	}
+	if len(m.IP) > 0 && !anyMatch(m.IP, func(p string) bool { return matchIP(p, e.IPAddress) }) {
+		return false
+	}
+	if len(m.Actor) > 0 && !anyMatch(m.Actor, func(p string) bool { return glob(p, e.ActorID) }) {
+		return false
+	}
+	for k, p := range m.Detail {
+		v, ok := detail[k]
+		if !ok || !glob(p, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyMatch(patterns []string, fn func(string) bool) bool {
+	for _, p := range patterns {
+		if fn(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func glob(pattern, s string) bool {
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
+
+func parseIPMatcher(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid ip prefix %q", s)
+		}
+		return p, nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid ip %q", s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func matchIP(pattern, ip string) bool {
+	p, err := parseIPMatcher(pattern)
+	if err != nil {
+		return false
+	}
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return p.Contains(a)
+}
+
+// fieldValue resolves a match or group_by field name against an event.
+func fieldValue(e api.Event, detail map[string]string, field string) string {
+	switch field {
+	case "type":
+		return e.Type
+	case "user":
+		if e.UserID == nil {
+			return ""
+		}
+		return strconv.Itoa(*e.UserID)
+	case "ip":
+		return e.IPAddress
+	case "actor":
+		return e.ActorID
+	}
+	if k, ok := strings.CutPrefix(field, "detail."); ok {
+		return detail[k]
+	}
+	return ""
+}
+
+// flattenDetail decodes the top-level fields of an event's detail JSON into
+// strings. Nested values are kept as compact JSON.
+func flattenDetail(e api.Event) map[string]string {
+	if e.Detail == nil {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(*e.Detail))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			out[k] = v
+		case nil:
+			out[k] = ""
+		case json.Number, bool:
+			out[k] = fmt.Sprint(v)
+		default:
+			b, _ := json.Marshal(v)
+			out[k] = string(b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+const sampleRules = `
+rules:
+  - name: stuffing
+    match:
+      type: login.failure
+    threshold: 5
+    window: 1m
+    group_by: [ip]
+    actions:
+      - type: file
+        path: /tmp/alerts.ndjson
+        cooldown: 10m
+  - name: corp-admin
+    match:
+      type: [login.*]
+      ip: 10.0.0.0/8
+      detail:
+        email: "*@corp.com"
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(sampleRules))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(f.Rules))
+	}
+	r := f.Rules[0]
+	if r.Window != time.Minute {
+		t.Fatalf("expected 1m window, got %s", r.Window)
+	}
+	if len(r.Match.Type) != 1 || r.Match.Type[0] != "login.failure" {
+		t.Fatalf("expected scalar type to decode as list, got %v", r.Match.Type)
+	}
+	if r.Actions[0].Cooldown != 10*time.Minute {
+		t.Fatalf("expected 10m cooldown, got %s", r.Actions[0].Cooldown)
+	}
+	if f.Rules[1].Threshold != 1 {
+		t.Fatalf("expected default threshold 1, got %d", f.Rules[1].Threshold)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"missing name", "rules:\n  - match: {type: x}\n", "name is required"},
+		{"duplicate name", "rules:\n  - name: a\n  - name: a\n", "duplicate name"},
+		{"threshold without window", "rules:\n  - name: a\n    threshold: 3\n", "window is required"},
+		{"unknown group field", "rules:\n  - name: a\n    group_by: [email]\n", "unknown group_by"},
+		{"bad ip", "rules:\n  - name: a\n    match: {ip: nope}\n", "invalid ip"},
+		{"unknown action", "rules:\n  - name: a\n    actions: [{type: page}]\n", "unknown action type"},
+		{"revoke scope", "rules:\n  - name: a\n    actions: [{type: revoke, scope: all}]\n", "scope must be user or session"},
+		{"unknown field", "rules:\n  - name: a\n    treshold: 3\n", "treshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	uid := 42
+	detail := `{"email":"*@corp.com","attempts":3}`
+	e := api.Event{Type: "login.failure", IPAddress: "10.1.2.3", UserID: &uid, ActorID: "app:private-landing", Detail: &detail}
+	d := flattenDetail(e)
+
+	tests := []struct {
+		name  string
+		match Match
+		want  bool
+	}{
+		{"empty matches all", Match{}, true},
+		{"type wildcard", Match{Type: StringList{"login.*"}}, true},
+		{"type mismatch", Match{Type: StringList{"session.*"}}, false},
+		{"user", Match{User: StringList{"42"}}, true},
+		{"user mismatch", Match{User: StringList{"7"}}, false},
+		{"ip cidr", Match{IP: StringList{"10.0.0.0/8"}}, true},
+		{"ip exact mismatch", Match{IP: StringList{"10.1.2.4"}}, false},
+		{"actor glob", Match{Actor: StringList{"app:*"}}, true},
+		{"actor mismatch", Match{Actor: StringList{"agent:*"}}, false},
+		{"detail glob", Match{Detail: map[string]string{"email": "*@corp.com"}}, true},
+		{"detail number", Match{Detail: map[string]string{"attempts": "3"}}, true},
+		{"detail missing key", Match{Detail: map[string]string{"reason": "*"}}, false},
+		{"all fields", Match{Type: StringList{"login.failure"}, IP: StringList{"10.1.2.3"}, User: StringList{"42"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.matches(e, d); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
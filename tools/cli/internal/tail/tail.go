@@ -0,0 +1,125 @@
+// Package tail holds the live tail's scrollback: a fixed-size ring of
+// events with a pause queue and substring search.
+package tail
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// DefaultSize is the number of events kept when no size is configured.
+const DefaultSize = 1000
+
+// Buffer keeps the most recent events up to a fixed size. While paused,
+// incoming events queue up (bounded by the same size) and are appended on
+// resume.
+type Buffer struct {
+	ring    []api.Event
+	head    int // index of the oldest event
+	n       int
+	paused  bool
+	pending []api.Event
+}
+
+// NewBuffer returns a buffer holding at most size events.
+func NewBuffer(size int) *Buffer {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Buffer{ring: make([]api.Event, size)}
+}
+
+// Push records e and returns how many events were evicted from the front
+// of the buffer, so callers can keep indices stable. Paused buffers queue e
+// and evict nothing.
+func (b *Buffer) Push(e api.Event) int {
+	if b.paused {
+		if len(b.pending) == len(b.ring) {
+			b.pending = b.pending[1:]
+		}
+		b.pending = append(b.pending, e)
+		return 0
+	}
+	return b.insert(e)
+}
+
+func (b *Buffer) insert(e api.Event) int {
+	size := len(b.ring)
+	if b.n < size {
+		b.ring[(b.head+b.n)%size] = e
+		b.n++
+		return 0
+	}
+	b.ring[b.head] = e
+	b.head = (b.head + 1) % size
+	return 1
+}
+
+// Len is the number of buffered events, excluding pending ones.
+func (b *Buffer) Len() int { return b.n }
+
+// Cap is the configured size.
+func (b *Buffer) Cap() int { return len(b.ring) }
+
+// At returns the i'th event, oldest first.
+func (b *Buffer) At(i int) api.Event {
+	return b.ring[(b.head+i)%len(b.ring)]
+}
+
+// Paused reports whether incoming events are being held back.
+func (b *Buffer) Paused() bool { return b.paused }
+
+// Pending is the number of events received while paused.
+func (b *Buffer) Pending() int { return len(b.pending) }
+
+// Pause holds back incoming events until Resume.
+func (b *Buffer) Pause() { b.paused = true }
+
+// Resume appends the events queued while paused and returns how many were
+// evicted as a result.
+func (b *Buffer) Resume() int {
+	b.paused = false
+	evicted := 0
+	for _, e := range b.pending {
+		evicted += b.insert(e)
+	}
+	b.pending = nil
+	return evicted
+}
+
+// Find returns the index of the nearest event matching query, starting at
+// from and stepping by dir (1 towards newer, -1 towards older), wrapping
+// around the buffer.
+func (b *Buffer) Find(query string, from, dir int) (int, bool) {
+	if query == "" || b.n == 0 {
+		return 0, false
+	}
+	for step := range b.n {
+		i := ((from+dir*step)%b.n + b.n) % b.n
+		if Match(b.At(i), query) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Match reports whether query occurs, case-insensitively, in any of e's
+// fields, including the raw detail JSON.
+func Match(e api.Event, query string) bool {
+	q := strings.ToLower(query)
+	fields := []string{e.Type, e.IPAddress, e.ActorID, e.CreatedAt, strconv.Itoa(e.ID)}
+	if e.UserID != nil {
+		fields = append(fields, "user:"+strconv.Itoa(*e.UserID))
+	}
+	if e.Detail != nil {
+		fields = append(fields, *e.Detail)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), q) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package tail
+
+import (
+	"testing"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func event(id int, typ string) api.Event {
+	return api.Event{ID: id, Type: typ, IPAddress: "10.0.0.1"}
+}
+
+func ids(b *Buffer) []int {
+	out := make([]int, b.Len())
+	for i := range out {
+		out[i] = b.At(i).ID
+	}
+	return out
+}
+
+func TestBufferEvictsOldest(t *testing.T) {
+	b := NewBuffer(3)
+	evicted := 0
+	for id := 1; id <= 5; id++ {
+		evicted += b.Push(event(id, "login.success"))
+	}
+	if evicted != 2 {
+		t.Errorf("evicted = %d, want 2", evicted)
+	}
+	if got := ids(b); len(got) != 3 || got[0] != 3 || got[2] != 5 {
+		t.Errorf("ids = %v, want [3 4 5]", got)
+	}
+}
+
+func TestBufferPauseResume(t *testing.T) {
+	b := NewBuffer(3)
+	b.Push(event(1, "login.success"))
+	b.Pause()
+	for id := 2; id <= 6; id++ {
+		if n := b.Push(event(id, "login.success")); n != 0 {
+			t.Fatalf("paused push evicted %d", n)
+		}
+	}
+	if b.Len() != 1 || b.Pending() != 3 {
+		t.Fatalf("len = %d, pending = %d, want 1 and 3", b.Len(), b.Pending())
+	}
+	if n := b.Resume(); n != 1 {
+		t.Errorf("resume evicted %d, want 1", n)
+	}
+	if got := ids(b); len(got) != 3 || got[0] != 4 || got[2] != 6 {
+		t.Errorf("ids = %v, want [4 5 6]", got)
+	}
+	if b.Paused() || b.Pending() != 0 {
+		t.Error("buffer still paused after resume")
+	}
+}
+
+func TestFind(t *testing.T) {
+	b := NewBuffer(10)
+	b.Push(event(1, "login.failure"))
+	b.Push(event(2, "session.revoke"))
+	b.Push(event(3, "LOGIN.failure"))
+
+	if i, ok := b.Find("login", 2, -1); !ok || i != 2 {
+		t.Errorf("Find backwards = %d, %v, want 2", i, ok)
+	}
+	if i, ok := b.Find("login", 1, -1); !ok || i != 0 {
+		t.Errorf("Find from 1 = %d, %v, want 0", i, ok)
+	}
+	if i, ok := b.Find("login", 2+1, 1); !ok || i != 0 {
+		t.Errorf("Find wrap = %d, %v, want 0", i, ok)
+	}
+	if _, ok := b.Find("challenge", 0, 1); ok {
+		t.Error("Find matched an absent query")
+	}
+}
+
+func TestMatchDetailAndUser(t *testing.T) {
+	uid := 42
+	detail := `{"reason":"bad_password"}`
+	e := api.Event{ID: 7, Type: "login.failure", UserID: &uid, Detail: &detail}
+	for _, q := range []string{"user:42", "BAD_PASS", "failure"} {
+		if !Match(e, q) {
+			t.Errorf("Match(%q) = false", q)
+		}
+	}
+	if Match(e, "session") {
+		t.Error("Match(session) = true")
+	}
+}
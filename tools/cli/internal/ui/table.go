@@ -51,9 +51,11 @@ func RenderTable(columns []Column, rows [][]string) string {
 	return b.String()
 }
 
+// pad fits s to width runes, so bars and other multi-byte glyphs are not
+// cut mid-character.
 func pad(s string, width int) string {
-	if len(s) >= width {
-		return s[:width]
+	if r := []rune(s); len(r) >= width {
+		return string(r[:width])
 	}
 	return fmt.Sprintf("%-*s", width, s)
 }
@@ -0,0 +1,114 @@
+package userauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrBadSignature is returned by Verify when the signature does not match.
+var ErrBadSignature = errors.New("signature does not match")
+
+// Claims are the fields the server puts in access and refresh tokens.
+type Claims struct {
+	UID int    `json:"uid"`
+	SID string `json:"sid"`
+	Typ string `json:"typ"`
+	Exp int64  `json:"exp,omitempty"`
+	Iat int64  `json:"iat,omitempty"`
+}
+
+// Expiry is the exp claim as a time; zero when absent.
+func (c Claims) Expiry() time.Time {
+	return unix(c.Exp)
+}
+
+// IssuedAt is the iat claim as a time; zero when absent.
+func (c Claims) IssuedAt() time.Time {
+	return unix(c.Iat)
+}
+
+func unix(s int64) time.Time {
+	if s == 0 {
+		return time.Time{}
+	}
+	return time.Unix(s, 0)
+}
+
+// Token is a decoded, unverified JWT.
+type Token struct {
+	Header  map[string]any
+	Claims  Claims
+	Payload map[string]any // every claim, including ones Claims does not model
+
+	signed    string // header.payload
+	signature []byte
+}
+
+// ParseToken splits and decodes a JWT without verifying it. A pasted
+// cookie ("access_token=…; Path=/") is accepted as well as the bare token.
+func ParseToken(s string) (*Token, error) {
+	s = strings.TrimSpace(s)
+	if v, _, ok := strings.Cut(s, ";"); ok {
+		s = v
+	}
+	if i := strings.IndexByte(s, '='); i >= 0 && !strings.Contains(s[:i], ".") {
+		s = s[i+1:]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+	t := &Token{signed: parts[0] + "." + parts[1]}
+	if err := decodeSegment(parts[0], &t.Header); err != nil {
+		return nil, fmt.Errorf("decode token header: %w", err)
+	}
+	if err := decodeSegment(parts[1], &t.Claims); err != nil {
+		return nil, fmt.Errorf("decode token payload: %w", err)
+	}
+	decodeSegment(parts[1], &t.Payload)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode token signature: %w", err)
+	}
+	t.signature = sig
+	return t, nil
+}
+
+func decodeSegment(seg string, out any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// Verify checks an HS256 signature against secret, the server's
+// JWT_ACCESS_SECRET or JWT_REFRESH_SECRET depending on the token type.
+// Expiry is not checked; callers compare Claims.Expiry themselves.
+func (t *Token) Verify(secret []byte) error {
+	if alg, _ := t.Header["alg"].(string); alg != "HS256" {
+		return fmt.Errorf("unsupported alg %q, want HS256", alg)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(t.signed))
+	if !hmac.Equal(mac.Sum(nil), t.signature) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// DecodeClaims reads a JWT's payload without verifying its signature. It is
+// for display only; the server is the authority on whether a token is valid.
+func DecodeClaims(token string) (*Claims, error) {
+	t, err := ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &t.Claims, nil
+}
@@ -0,0 +1,219 @@
+// Package userauth drives the public end-user auth API (/auth/*,
+// /account/*) the way a browser would, keeping the access and refresh
+// token cookies in a persistent jar.
+package userauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Cookie names set by the server's token service.
+const (
+	AccessCookie  = "access_token"
+	RefreshCookie = "refresh_token"
+)
+
+// ErrNotLoggedIn is returned when the jar holds no tokens.
+var ErrNotLoggedIn = errors.New("not logged in (run 'plctl auth login')")
+
+// Client calls the auth API as an end user.
+type Client struct {
+	baseURL string
+	jar     *Jar
+	http    *http.Client
+}
+
+// NewClient returns a client that keeps its cookies in jar.
+func NewClient(baseURL string, jar *Jar) *Client {
+	return &Client{
+		baseURL: baseURL,
+		jar:     jar,
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: 30 * time.Second,
+			// JSON requests never redirect; a redirect means the server
+			// ignored Accept, so surface it rather than follow it.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// LoginResult describes a successful login.
+type LoginResult struct {
+	// Difficulty of the PoW challenge solved, or 0 when none was required.
+	Difficulty int
+}
+
+// Register creates an account.
+func (c *Client) Register(ctx context.Context, email, password string) error {
+	return c.send(ctx, http.MethodPost, "/auth/register", map[string]string{"email": email, "password": password}, nil)
+}
+
+// Login authenticates and stores the token cookies. When the server
+// demands an adaptive PoW challenge, it is solved and the login retried.
+func (c *Client) Login(ctx context.Context, email, password string) (*LoginResult, error) {
+	body := map[string]string{"email": email, "password": password}
+	err := c.send(ctx, http.MethodPost, "/auth/login", body, nil)
+	var ch *challengeError
+	if !errors.As(err, &ch) {
+		return &LoginResult{}, err
+	}
+	solution, err := api.SolvePow(ctx, ch.Nonce, ch.Difficulty)
+	if err != nil {
+		return nil, err
+	}
+	body["challengeNonce"] = ch.Nonce
+	body["challengeSolution"] = strconv.Itoa(solution)
+	if err := c.send(ctx, http.MethodPost, "/auth/login", body, nil); err != nil {
+		return nil, err
+	}
+	return &LoginResult{Difficulty: ch.Difficulty}, nil
+}
+
+// Me returns the user ID of the logged-in account. The server refreshes an
+// expired access token from the refresh token on the way.
+func (c *Client) Me(ctx context.Context) (int, error) {
+	if _, ok := c.jar.Get(RefreshCookie); !ok {
+		return 0, ErrNotLoggedIn
+	}
+	var out struct {
+		UserID int `json:"userId"`
+	}
+	if err := c.send(ctx, http.MethodGet, "/account/me", nil, &out); err != nil {
+		return 0, err
+	}
+	return out.UserID, nil
+}
+
+// Refresh obtains a new access token. The API has no refresh endpoint:
+// protected routes mint one from the refresh token when the access token
+// is missing, so Refresh drops it and calls /account/me.
+func (c *Client) Refresh(ctx context.Context) error {
+	old, _ := c.jar.Get(AccessCookie)
+	c.jar.Delete(AccessCookie)
+	if _, err := c.Me(ctx); err != nil {
+		return err
+	}
+	if token, ok := c.jar.Get(AccessCookie); !ok || token == old {
+		return errors.New("server did not issue a new access token")
+	}
+	return nil
+}
+
+// Logout ends the session and clears the jar.
+func (c *Client) Logout(ctx context.Context) error {
+	if _, ok := c.jar.Get(RefreshCookie); !ok {
+		return ErrNotLoggedIn
+	}
+	if err := c.send(ctx, http.MethodPost, "/auth/logout", nil, nil); err != nil {
+		return err
+	}
+	c.jar.Clear()
+	return nil
+}
+
+// ChangePassword changes the password. The server ends every session for
+// the account, so the jar is cleared too.
+func (c *Client) ChangePassword(ctx context.Context, current, next string) error {
+	if _, ok := c.jar.Get(RefreshCookie); !ok {
+		return ErrNotLoggedIn
+	}
+	body := map[string]string{"currentPassword": current, "newPassword": next}
+	if err := c.send(ctx, http.MethodPost, "/account/password", body, nil); err != nil {
+		return err
+	}
+	c.jar.Clear()
+	return nil
+}
+
+// Tokens decodes the access and refresh tokens in the jar. Either may be
+// nil when the cookie is absent or expired.
+func (c *Client) Tokens() (access, refresh *Claims) {
+	if t, ok := c.jar.Get(AccessCookie); ok {
+		access, _ = DecodeClaims(t)
+	}
+	if t, ok := c.jar.Get(RefreshCookie); ok {
+		refresh, _ = DecodeClaims(t)
+	}
+	return access, refresh
+}
+
+// challengeError is a 403 carrying a PoW challenge.
+type challengeError struct {
+	Nonce      string
+	Difficulty int
+}
+
+func (e *challengeError) Error() string {
+	return fmt.Sprintf("PoW challenge required (difficulty %d)", e.Difficulty)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	// Without an explicit JSON Accept the server answers with redirects.
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			api.APIError
+			Challenge *struct {
+				Nonce      string `json:"nonce"`
+				Difficulty int    `json:"difficulty"`
+			} `json:"challenge"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil {
+			if resp.StatusCode == http.StatusForbidden && apiErr.Challenge != nil && apiErr.Challenge.Nonce != "" {
+				return &challengeError{Nonce: apiErr.Challenge.Nonce, Difficulty: apiErr.Challenge.Difficulty}
+			}
+			if apiErr.Error != "" {
+				msg := apiErr.Error
+				if apiErr.Code != "" {
+					msg = fmt.Sprintf("%s (code: %s)", apiErr.Error, apiErr.Code)
+				}
+				return &api.HTTPError{Status: resp.StatusCode, Message: msg}
+			}
+		}
+		return &api.HTTPError{Status: resp.StatusCode, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody))}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
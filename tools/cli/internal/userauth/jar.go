@@ -0,0 +1,155 @@
+package userauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Jar is a cookie jar for a single API origin that persists to a file, so a
+// login survives between plctl invocations. Domain and path are ignored:
+// every cookie the origin sets is sent back to it.
+type Jar struct {
+	path string
+
+	mu      sync.Mutex
+	cookies map[string]storedCookie
+}
+
+type storedCookie struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// DefaultJarPath is <user config dir>/plctl/auth/<host>.json.
+func DefaultJarPath(apiURL string) string {
+	host := apiURL
+	if u, err := url.Parse(apiURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "plctl", "auth", filepath.Base(host)+".json")
+}
+
+// NewJar returns an empty jar that lives only in memory; Save fails on it.
+func NewJar() *Jar {
+	return &Jar{cookies: make(map[string]storedCookie)}
+}
+
+// LoadJar reads the jar at path. A missing file is an empty jar.
+func LoadJar(path string) (*Jar, error) {
+	j := &Jar{path: path, cookies: make(map[string]storedCookie)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &j.cookies); err != nil {
+		return nil, fmt.Errorf("cookie jar %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// Path is where Save writes the jar.
+func (j *Jar) Path() string { return j.path }
+
+// SetCookies implements http.CookieJar. Cookies that are expired or
+// deleted by the server are removed.
+func (j *Jar) SetCookies(_ *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		expires := c.Expires
+		if c.MaxAge > 0 {
+			expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		if c.MaxAge < 0 || c.Value == "" || !expires.IsZero() && !expires.After(now) {
+			delete(j.cookies, c.Name)
+			continue
+		}
+		j.cookies[c.Name] = storedCookie{Value: c.Value, Expires: expires}
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(_ *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	var out []*http.Cookie
+	for name, c := range j.cookies {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		out = append(out, &http.Cookie{Name: name, Value: c.Value})
+	}
+	return out
+}
+
+// Get returns the value of a live cookie.
+func (j *Jar) Get(name string) (string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	c, ok := j.cookies[name]
+	if !ok || !c.Expires.IsZero() && !c.Expires.After(time.Now()) {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// Delete drops a cookie.
+func (j *Jar) Delete(name string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.cookies, name)
+}
+
+// Clear drops every cookie.
+func (j *Jar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	clear(j.cookies)
+}
+
+// Save writes the jar with owner-only permissions, replacing the file
+// atomically.
+func (j *Jar) Save() error {
+	if j.path == "" {
+		return errors.New("cookie jar has no file")
+	}
+	j.mu.Lock()
+	data, err := json.MarshalIndent(j.cookies, "", "  ")
+	j.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), ".jar-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
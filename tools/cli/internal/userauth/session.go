@@ -0,0 +1,102 @@
+package userauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+// Session states reported by LookupSession.
+const (
+	SessionActive  = "active"
+	SessionExpired = "expired"
+	SessionRevoked = "revoked"
+	// SessionInactive is a missing session with nothing on record to say
+	// how it ended.
+	SessionInactive = "inactive"
+)
+
+// SessionState is what the ops API says about a token's session.
+type SessionState struct {
+	Status  string       `json:"status"`
+	Session *api.Session `json:"session,omitempty"` // set when active
+	Reason  string       `json:"reason,omitempty"`  // why it is no longer active, when known
+}
+
+// revokeLookback bounds the event search for how a session ended.
+const revokeLookback = 30 * 24 * time.Hour
+
+// LookupSession finds the token's session through the ops API. Only the
+// uid and sid claims are used; the token itself is never sent. ListSessions
+// returns active sessions only, so a missing session is told apart as
+// expired or revoked from a refresh token's expiry and the revocation
+// events since the session's login, and reported inactive when neither
+// says.
+func LookupSession(ctx context.Context, r api.Reader, c *Claims, now time.Time) (*SessionState, error) {
+	sessions, err := api.AllSessions(ctx, r, api.SessionsParams{UserID: strconv.Itoa(c.UID)})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.ID == c.SID {
+			return &SessionState{Status: SessionActive, Session: &s}, nil
+		}
+	}
+
+	since := now.Add(-revokeLookback).UTC().Format(time.RFC3339)
+	events, err := api.AllEvents(ctx, r, api.EventsParams{UserID: strconv.Itoa(c.UID), Since: since})
+	if err != nil {
+		return nil, err
+	}
+	// Events are newest first, so the first match is how the session ended.
+	// Walking back past the session's own login means nothing later ended
+	// it: a password change before then revoked other sessions.
+	for _, e := range events {
+		if e.Type == "login.success" && SessionID(e) == c.SID {
+			break
+		}
+		if reason := revokeReason(e, c); reason != "" {
+			return &SessionState{Status: SessionRevoked, Reason: reason}, nil
+		}
+	}
+	// Only a refresh token lives as long as its session; an access token
+	// expires within minutes while the session carries on, so its expiry
+	// says nothing about how the session ended.
+	if c.Typ != "refresh" {
+		return &SessionState{Status: SessionInactive, Reason: "not found and no revocation on record"}, nil
+	}
+	if exp := c.Expiry(); !exp.IsZero() && !exp.After(now) {
+		return &SessionState{Status: SessionExpired, Reason: "token expired " + exp.UTC().Format(time.RFC3339)}, nil
+	}
+	// Gone before its refresh token expired with no event naming it, most
+	// likely an ops revocation, whose events carry no user ID.
+	return &SessionState{Status: SessionRevoked, Reason: "ended before token expiry"}, nil
+}
+
+// SessionID is the sessionId detail field of e, if any, as the server
+// records it on login.success and session.revoke.
+func SessionID(e api.Event) string {
+	var detail struct {
+		SessionID string `json:"sessionId"`
+	}
+	if e.Detail != nil {
+		json.Unmarshal([]byte(*e.Detail), &detail)
+	}
+	return detail.SessionID
+}
+
+func revokeReason(e api.Event, c *Claims) string {
+	switch e.Type {
+	case "session.revoke":
+		if SessionID(e) == c.SID {
+			return fmt.Sprintf("logged out %s", e.CreatedAt)
+		}
+	case "session.revoke_all":
+		return fmt.Sprintf("all sessions ended by a password change %s", e.CreatedAt)
+	}
+	return ""
+}
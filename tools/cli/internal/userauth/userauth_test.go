@@ -0,0 +1,281 @@
+package userauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/private-landing/cli/internal/api"
+)
+
+func token(c Claims) string {
+	payload, _ := json.Marshal(c)
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+// fakeAuth mimics the worker: login demands a difficulty-1 challenge and
+// protected routes refresh a missing access token.
+func fakeAuth(t *testing.T) *httptest.Server {
+	issued := 0
+	exp := time.Now().Add(15 * time.Minute).Unix()
+	setAccess := func(w http.ResponseWriter) {
+		issued++
+		http.SetCookie(w, &http.Cookie{Name: AccessCookie, Value: token(Claims{UID: 7, SID: fmt.Sprintf("s1-%d", issued), Typ: "access", Exp: exp}), MaxAge: 900})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			http.Redirect(w, r, "/#error", http.StatusFound)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/auth/login":
+			if body["challengeNonce"] == "" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"error":"Challenge required","challenge":{"type":"pow","difficulty":1,"nonce":"abc"}}`)
+				return
+			}
+			sum := sha256.Sum256([]byte(body["challengeNonce"] + body["challengeSolution"]))
+			if !strings.HasPrefix(fmt.Sprintf("%x", sum), "0") {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"error":"Invalid solution"}`)
+				return
+			}
+			if body["password"] != "hunter22" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"Authentication failed","code":"INVALID_CREDENTIALS"}`)
+				return
+			}
+			setAccess(w)
+			http.SetCookie(w, &http.Cookie{Name: RefreshCookie, Value: token(Claims{UID: 7, SID: "s1", Typ: "refresh", Exp: exp}), MaxAge: 3600})
+			fmt.Fprint(w, `{"success":true}`)
+		case "/account/me":
+			if _, err := r.Cookie(AccessCookie); err != nil {
+				if _, err := r.Cookie(RefreshCookie); err != nil {
+					w.WriteHeader(http.StatusUnauthorized)
+					fmt.Fprint(w, `{"error":"Token expired","code":"TOKEN_EXPIRED"}`)
+					return
+				}
+				setAccess(w)
+			}
+			fmt.Fprint(w, `{"userId":7}`)
+		case "/auth/logout":
+			http.SetCookie(w, &http.Cookie{Name: AccessCookie, MaxAge: -1})
+			http.SetCookie(w, &http.Cookie{Name: RefreshCookie, MaxAge: -1})
+			fmt.Fprint(w, `{"success":true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestLoginFlow(t *testing.T) {
+	srv := fakeAuth(t)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "auth", "jar.json")
+	jar, err := LoadJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(srv.URL, jar)
+	ctx := context.Background()
+
+	if _, err := c.Me(ctx); !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("Me before login: %v", err)
+	}
+	res, err := c.Login(ctx, "ana@example.com", "hunter22")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if res.Difficulty != 1 {
+		t.Errorf("difficulty = %d, want 1", res.Difficulty)
+	}
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("jar file mode: %v, %v", info, err)
+	}
+
+	// A fresh process sees the saved cookies.
+	jar, _ = LoadJar(path)
+	c = NewClient(srv.URL, jar)
+	access, refresh := c.Tokens()
+	if access == nil || refresh == nil || access.UID != 7 || refresh.Typ != "refresh" || refresh.SID != "s1" {
+		t.Fatalf("tokens = %+v, %+v", access, refresh)
+	}
+	if uid, err := c.Me(ctx); err != nil || uid != 7 {
+		t.Fatalf("Me = %d, %v", uid, err)
+	}
+	if err := c.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if access, _ := c.Tokens(); access.SID != "s1-2" {
+		t.Errorf("access after refresh = %+v", access)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if a, r := c.Tokens(); a != nil || r != nil {
+		t.Error("tokens remain after logout")
+	}
+}
+
+func TestLoginBadPassword(t *testing.T) {
+	srv := fakeAuth(t)
+	defer srv.Close()
+	jar, _ := LoadJar(filepath.Join(t.TempDir(), "jar.json"))
+	_, err := NewClient(srv.URL, jar).Login(context.Background(), "ana@example.com", "nope")
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusUnauthorized || !strings.Contains(err.Error(), "INVALID_CREDENTIALS") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDecodeClaims(t *testing.T) {
+	c, err := DecodeClaims(token(Claims{UID: 3, SID: "abc", Typ: "access", Exp: 1700000000}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.UID != 3 || c.SID != "abc" || c.Expiry().Unix() != 1700000000 {
+		t.Errorf("claims = %+v", c)
+	}
+	if _, err := DecodeClaims("not-a-jwt"); err == nil {
+		t.Error("expected error for non-JWT")
+	}
+}
+
+func TestParseTokenVerify(t *testing.T) {
+	// HS256 over {"alg":"HS256","typ":"JWT"} and the payload below with
+	// secret "s3cret", computed independently of Verify.
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"uid":9,"sid":"x","typ":"refresh","exp":1700000000,"iat":1699990000,"extra":true}`))
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(header + "." + payload))
+	jwt := header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	for _, in := range []string{jwt, "refresh_token=" + jwt + "; Path=/; HttpOnly", "  " + jwt + "\n"} {
+		tok, err := ParseToken(in)
+		if err != nil {
+			t.Fatalf("ParseToken(%q): %v", in, err)
+		}
+		if tok.Claims.UID != 9 || tok.Claims.IssuedAt().Unix() != 1699990000 || tok.Payload["extra"] != true {
+			t.Errorf("token = %+v", tok)
+		}
+		if err := tok.Verify([]byte("s3cret")); err != nil {
+			t.Errorf("Verify: %v", err)
+		}
+		if err := tok.Verify([]byte("other")); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("Verify(wrong secret) = %v, want ErrBadSignature", err)
+		}
+	}
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	tok, err := ParseToken(none + "." + payload + ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tok.Verify([]byte("s3cret")); err == nil || errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify(alg none) = %v, want unsupported alg", err)
+	}
+}
+
+type fakeOps struct {
+	sessions []api.Session
+	events   []api.Event
+}
+
+func (f *fakeOps) ListEvents(_ context.Context, p api.EventsParams) (*api.ListEventsResponse, error) {
+	return &api.ListEventsResponse{Events: api.FilterEvents(f.events, p)}, nil
+}
+
+func (f *fakeOps) ListSessions(_ context.Context, p api.SessionsParams) (*api.ListSessionsResponse, error) {
+	return &api.ListSessionsResponse{Sessions: api.FilterSessions(f.sessions, p)}, nil
+}
+
+func (f *fakeOps) GetEventStats(context.Context, string) (*api.EventStatsResponse, error) {
+	return &api.EventStatsResponse{}, nil
+}
+
+func (f *fakeOps) ListAgents(context.Context) (*api.ListAgentsResponse, error) {
+	return &api.ListAgentsResponse{}, nil
+}
+
+func TestLookupSession(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	detail := func(s string) *string { return &s }
+	uid := 4
+	ops := &fakeOps{
+		sessions: []api.Session{{ID: "live", UserID: 4, ExpiresAt: "2026-05-08 12:00:00"}},
+		events: []api.Event{
+			{ID: 2, Type: "session.revoke", UserID: &uid, Detail: detail(`{"sessionId":"gone"}`), CreatedAt: "2026-05-01 11:00:00"},
+			{ID: 1, Type: "login.success", UserID: &uid, Detail: detail(`{"sessionId":"gone"}`), CreatedAt: "2026-05-01 10:00:00"},
+		},
+	}
+	future, past := now.Add(time.Hour).Unix(), now.Add(-time.Hour).Unix()
+	for _, tc := range []struct {
+		claims Claims
+		want   string
+	}{
+		{Claims{UID: 4, SID: "live", Exp: future}, SessionActive},
+		{Claims{UID: 4, SID: "gone", Exp: future}, SessionRevoked},
+		{Claims{UID: 4, SID: "old", Typ: "refresh", Exp: past}, SessionExpired},
+		{Claims{UID: 4, SID: "cut", Typ: "refresh", Exp: future}, SessionRevoked},
+		{Claims{UID: 4, SID: "cut", Typ: "access", Exp: future}, SessionInactive},
+	} {
+		state, err := LookupSession(context.Background(), ops, &tc.claims, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Status != tc.want || (state.Status != SessionActive && state.Reason == "") {
+			t.Errorf("%s: state = %+v, want %s", tc.claims.SID, state, tc.want)
+		}
+	}
+}
+
+func TestLookupSessionReason(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	detail := func(s string) *string { return &s }
+	uid := 4
+	ops := &fakeOps{
+		events: []api.Event{
+			{ID: 3, Type: "login.success", UserID: &uid, Detail: detail(`{"sessionId":"new"}`), CreatedAt: "2026-05-01 11:00:00"},
+			{ID: 2, Type: "session.revoke_all", UserID: &uid, CreatedAt: "2026-05-01 10:00:00"},
+			{ID: 1, Type: "login.success", UserID: &uid, Detail: detail(`{"sessionId":"old"}`), CreatedAt: "2026-05-01 09:00:00"},
+		},
+	}
+	past := now.Add(-time.Hour).Unix()
+	for _, tc := range []struct {
+		claims Claims
+		want   string
+	}{
+		// A password change before the session's login did not end it.
+		{Claims{UID: 4, SID: "new", Typ: "refresh", Exp: now.Add(time.Hour).Unix()}, "ended before token expiry"},
+		{Claims{UID: 4, SID: "old", Typ: "refresh", Exp: now.Add(time.Hour).Unix()}, "all sessions ended by a password change 2026-05-01 10:00:00"},
+		// An access token says nothing about how its session ended.
+		{Claims{UID: 4, SID: "new", Typ: "access", Exp: past}, "not found and no revocation on record"},
+		{Claims{UID: 4, SID: "old", Typ: "access", Exp: past}, "all sessions ended by a password change 2026-05-01 10:00:00"},
+		{Claims{UID: 4, SID: "new", Typ: "refresh", Exp: past}, "token expired 2026-05-01T11:00:00Z"},
+	} {
+		state, err := LookupSession(context.Background(), ops, &tc.claims, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Reason != tc.want {
+			t.Errorf("%s %s: reason = %q, want %q", tc.claims.Typ, tc.claims.SID, state.Reason, tc.want)
+		}
+	}
+}